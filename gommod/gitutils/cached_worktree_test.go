@@ -0,0 +1,47 @@
+package gitutils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+func TestOpenCachedWorktreeReusesCacheRepo(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("NEXTVER_CACHE_DIR", filepath.Join(root, "cache"))
+	repoDir := filepath.Join(root, "repo")
+	remoteDir := filepath.Join(root, "remote.git")
+	git(t, root, "init", "--quiet", "--bare", remoteDir)
+	git(t, root, "init", "--quiet", "--initial-branch=main", repoDir)
+	git(t, repoDir, "remote", "add", "origin", remoteDir)
+	err := os.WriteFile(filepath.Join(repoDir, "go.mod"), []byte("module example.com/app\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	git(t, repoDir, "add", "go.mod")
+	git(t, repoDir, "commit", "--quiet", "--message", "Initial")
+	git(t, repoDir, "tag", "v1.0.0")
+	git(t, repoDir, "push", "--quiet", "--set-upstream", "origin", "main")
+
+	repo, err := Open(dt.DirPath(repoDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first open clones the cache repo and the second reuses it
+	for range 2 {
+		wt, err := repo.OpenCachedWorktree(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = wt.Checkout("v1.0.0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = wt.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
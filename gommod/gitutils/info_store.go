@@ -24,7 +24,19 @@ func (s *InfoStore) Save(data []byte) (err error) {
 	var file *os.File
 	var fp dt.Filepath
 
-	file, err = s.Filename.Create()
+	fp, err = s.GetFilepath()
+	if err != nil {
+		goto end
+	}
+
+	// Filename may include subdirectories of .git/info, e.g. "gomion/..."
+	err = fp.Dir().MkdirAll(0755)
+	if err != nil {
+		err = NewErr(dt.ErrFailedtoCreateDir, fp.ErrKV(), err)
+		goto end
+	}
+
+	file, err = fp.Create()
 	if err != nil {
 		err = NewErr(
 			dt.ErrFailedtoCreateFile,
@@ -0,0 +1,33 @@
+package gitutils
+
+import (
+	"strings"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// ModuleTagName returns the tag name Go expects for a module version. Modules
+// at the repo root are tagged "vX.Y.Z" while modules in a subdirectory are
+// tagged "<subdir>/vX.Y.Z".
+func ModuleTagName(moduleRelPath dt.PathSegments, version string) string {
+	if pathspec(moduleRelPath) == "." {
+		return version
+	}
+	return string(moduleRelPath) + "/" + version
+}
+
+// ModuleTagVersion returns the version portion of a module tag, e.g. "v1.2.3"
+// for both "v1.2.3" and "cmd/v1.2.3"
+func ModuleTagVersion(tag string) string {
+	return tag[strings.LastIndex(tag, "/")+1:]
+}
+
+// ModuleTagPrefix returns the module directory portion of a module tag, e.g.
+// "cmd" for "cmd/v1.2.3" and "" for "v1.2.3"
+func ModuleTagPrefix(tag string) string {
+	i := strings.LastIndex(tag, "/")
+	if i < 0 {
+		return ""
+	}
+	return tag[:i]
+}
@@ -0,0 +1,47 @@
+package gitutils
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+func TestModuleTagName(t *testing.T) {
+	tests := []struct {
+		name    string
+		relPath dt.PathSegments
+		want    string
+	}{
+		{name: "Empty", relPath: "", want: "v1.2.3"},
+		{name: "RepoRoot", relPath: ".", want: "v1.2.3"},
+		{name: "Subdir", relPath: "cmd", want: "cmd/v1.2.3"},
+		{name: "NestedSubdir", relPath: "tools/gen", want: "tools/gen/v1.2.3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ModuleTagName(tt.relPath, "v1.2.3")
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModuleTagVersion(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{tag: "v1.2.3", want: "v1.2.3"},
+		{tag: "cmd/v1.2.3", want: "v1.2.3"},
+		{tag: "tools/gen/v0.1.0-rc.1", want: "v0.1.0-rc.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got := ModuleTagVersion(tt.tag)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -205,6 +206,11 @@ end:
 	return us, err
 }
 
+// CurrentBranch returns the name of the checked out branch, "HEAD" if detached
+func (r *Repo) CurrentBranch() (GitRef, error) {
+	return r.currentBranch()
+}
+
 // currentBranch returns the name of the current branch
 func (r *Repo) currentBranch() (ref GitRef, err error) {
 	var branch string
@@ -231,6 +237,18 @@ func (r GitRemote) IsValid() bool {
 	return len(r.Name) > 0 && len(r.Branch) > 0
 }
 
+// DefaultRemoteName is the remote used when the current branch tracks none
+const DefaultRemoteName RemoteName = "origin"
+
+// NameOrDefault returns the remote's name, or DefaultRemoteName if the current
+// branch has no upstream
+func (r GitRemote) NameOrDefault() RemoteName {
+	if r.Name != "" {
+		return r.Name
+	}
+	return DefaultRemoteName
+}
+
 // currentRenote the name of the upstream Remote (typically "origin")
 func (r *Repo) currentRemote() (remote GitRemote, err error) {
 	var out string
@@ -427,19 +445,157 @@ end:
 
 // FetchTags fetches all tags from the remote repository
 func (r *Repo) FetchTags(ctx context.Context) error {
-	var remoteName string
+	_, err := r.runGit(ctx, r.Root, "fetch", "--tags", r.remoteName())
+	return err
+}
+
+// CreateTag creates an annotated tag pointing at ref
+func (r *Repo) CreateTag(ctx context.Context, tag, ref, message string) error {
+	_, err := r.runGit(ctx, r.Root, "tag", "--annotate", tag, ref, "--message", message)
+	return err
+}
 
-	// Get remote name (typically "origin")
-	if r.Remote.Name != "" {
-		remoteName = string(r.Remote.Name)
-	} else {
-		remoteName = "origin"
+// PushTag pushes a single tag to the remote repository
+func (r *Repo) PushTag(ctx context.Context, tag string) error {
+	_, err := r.runGit(ctx, r.Root, "push", r.remoteName(), "refs/tags/"+tag)
+	return err
+}
+
+// DeleteTag deletes a local tag
+func (r *Repo) DeleteTag(ctx context.Context, tag string) error {
+	_, err := r.runGit(ctx, r.Root, "tag", "--delete", tag)
+	return err
+}
+
+// CommitPaths stages paths, relative to the repo root, and commits only them
+// with message, leaving anything else already staged uncommitted
+func (r *Repo) CommitPaths(ctx context.Context, message string, paths ...dt.RelFilepath) (err error) {
+	pathArgs := []string{"--"}
+	for _, path := range paths {
+		pathArgs = append(pathArgs, string(path))
 	}
 
-	_, err := r.runGit(ctx, r.Root, "fetch", "--tags", remoteName)
+	_, err = r.runGit(ctx, r.Root, append([]string{"add"}, pathArgs...)...)
+	if err != nil {
+		goto end
+	}
+	_, err = r.runGit(ctx, r.Root, append([]string{"commit", "--message", message}, pathArgs...)...)
+
+end:
 	return err
 }
 
+// SoftReset moves HEAD and the current branch to ref, leaving the changes of
+// the commits it drops staged
+func (r *Repo) SoftReset(ctx context.Context, ref string) error {
+	_, err := r.runGit(ctx, r.Root, "reset", "--soft", ref)
+	return err
+}
+
+// RemoteBranchesContain returns true if commit is reachable from any
+// remote-tracking branch, i.e. it has been pushed
+func (r *Repo) RemoteBranchesContain(ctx context.Context, commit string) (contains bool, err error) {
+	var out string
+
+	out, err = r.runGit(ctx, r.Root, "branch", "--remotes", "--contains", commit)
+	if err != nil {
+		goto end
+	}
+	contains = strings.TrimSpace(out) != ""
+
+end:
+	return contains, err
+}
+
+// CommitCountSince returns the number of commits reachable from HEAD but not from
+// fromRef that touch relPath, ignoring commits that only touch excludePaths
+func (r *Repo) CommitCountSince(ctx context.Context, fromRef string, relPath dt.PathSegments, excludePaths []dt.PathSegments) (count int, err error) {
+	var out string
+
+	args := []string{"rev-list", "--count", fromRef + "..HEAD"}
+	args = append(args, pathspecArgs(relPath, excludePaths)...)
+	out, err = r.runGit(ctx, r.Root, args...)
+	if err != nil {
+		goto end
+	}
+	count, err = strconv.Atoi(strings.TrimSpace(out))
+end:
+	return count, err
+}
+
+// LogEntry is a single commit as reported by CommitsSince
+type LogEntry struct {
+	SHA     string
+	Subject string
+	Body    string
+}
+
+// CommitsSince returns the non-merge commits reachable from HEAD but not from
+// fromRef that touch relPath, newest first, ignoring commits that only touch
+// excludePaths. An empty fromRef returns every commit reachable from HEAD.
+func (r *Repo) CommitsSince(ctx context.Context, fromRef string, relPath dt.PathSegments, excludePaths []dt.PathSegments) (commits []LogEntry, err error) {
+	var out string
+
+	revRange := "HEAD"
+	if fromRef != "" {
+		revRange = fromRef + "..HEAD"
+	}
+
+	// Unit and record separators keep multi-line bodies intact
+	args := []string{"log", "--no-merges", "--format=%H%x1f%s%x1f%b%x1e", revRange}
+	args = append(args, pathspecArgs(relPath, excludePaths)...)
+	out, err = r.runGit(ctx, r.Root, args...)
+	if err != nil {
+		goto end
+	}
+
+	for _, record := range strings.Split(out, "\x1e") {
+		record = strings.TrimLeft(record, "\n")
+		if record == "" {
+			continue
+		}
+		fields := strings.SplitN(record, "\x1f", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		commits = append(commits, LogEntry{
+			SHA:     fields[0],
+			Subject: fields[1],
+			Body:    strings.TrimSpace(fields[2]),
+		})
+	}
+
+end:
+	return commits, err
+}
+
+// pathspecArgs returns the "--" separated pathspecs selecting relPath without
+// excludePaths, which are relative to relPath
+func pathspecArgs(relPath dt.PathSegments, excludePaths []dt.PathSegments) (args []string) {
+	args = []string{"--", pathspec(relPath)}
+	for _, excludePath := range excludePaths {
+		if pathspec(relPath) != "." {
+			excludePath = relPath + "/" + excludePath
+		}
+		args = append(args, ":(exclude)"+string(excludePath))
+	}
+	return args
+}
+
+// remoteName returns the name of the tracking remote, defaulting to "origin"
+func (r *Repo) remoteName() string {
+	return string(r.Remote.NameOrDefault())
+}
+
+// pathspec converts a repo-relative path into a git pathspec where "" and "."
+// both mean the repository root
+func pathspec(relPath dt.PathSegments) string {
+	if relPath == "" || relPath == "." {
+		return "."
+	}
+	return string(relPath)
+}
+
 func (r *Repo) toIntPtr(s string) (n *int) {
 	n = new(int)
 	*n, _ = strconv.Atoi(s)
@@ -447,24 +603,33 @@ func (r *Repo) toIntPtr(s string) (n *int) {
 }
 
 func (r *Repo) Tags(ctx context.Context, prefix string) (tags []string, err error) {
-	out, err := r.runGit(ctx, r.Root, "tag", "--list")
+	var all []string
+
+	all, err = r.AllTags(ctx)
 	if err != nil {
 		goto end
 	}
 
-	// Normalize prefix: "." means root (same as empty)
-	if prefix == "." {
-		prefix = ""
+	for _, tag := range all {
+		if !matchesTagPrefix(tag, prefix) {
+			continue
+		}
+		tags = append(tags, tag)
+	}
+end:
+	return tags, err
+}
+
+// AllTags returns every tag in the repo regardless of module prefix
+func (r *Repo) AllTags(ctx context.Context) (tags []string, err error) {
+	out, err := r.runGit(ctx, r.Root, "tag", "--list")
+	if err != nil {
+		goto end
 	}
 
 	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
 		tag := strings.TrimSpace(line)
-		switch {
-		case tag == "":
-			continue
-		case prefix == "" && strings.Contains(tag, "/"):
-			continue
-		case prefix != "" && !strings.HasPrefix(tag, prefix+"/"):
+		if tag == "" {
 			continue
 		}
 		tags = append(tags, tag)
@@ -475,114 +640,213 @@ end:
 
 // RemoteTags returns all tags from the remote repository
 func (r *Repo) RemoteTags(ctx context.Context, prefix string) (tags []string, err error) {
-	var out string
-	var remoteName string
-
-	// Get remote name (typically "origin")
-	if r.Remote.Name != "" {
-		remoteName = string(r.Remote.Name)
-	} else {
-		remoteName = "origin"
-	}
+	var commits map[string]string
 
-	out, err = r.runGit(ctx, r.Root, "ls-remote", "--tags", remoteName)
+	commits, err = r.RemoteTagCommits(ctx, prefix)
 	if err != nil {
 		goto end
 	}
+	tags = make([]string, 0, len(commits))
+	for tag := range commits {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+end:
+	return tags, err
+}
+
+// RemoteTagCommits returns the commit each tag on the remote repository points
+// at, peeling annotated tags
+func (r *Repo) RemoteTagCommits(ctx context.Context, prefix string) (commits map[string]string, err error) {
+	var out string
 
-	// Normalize prefix: "." means root (same as empty)
-	if prefix == "." {
-		prefix = ""
+	out, err = r.runGit(ctx, r.Root, "ls-remote", "--tags", r.remoteName())
+	if err != nil {
+		goto end
 	}
 
-	// Parse ls-remote output: "hash refs/tags/tagname" or "hash refs/tags/tagname^{}"
+	// Parse ls-remote output: "hash refs/tags/tagname" or, for the commit an
+	// annotated tag points at, "hash refs/tags/tagname^{}"
+	commits = make(map[string]string)
 	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
 		fields := strings.Fields(line)
 		if len(fields) < 2 {
 			continue
 		}
-
-		ref := fields[1]
-		// Skip dereferenced tags (those ending with ^{})
-		if strings.HasSuffix(ref, "^{}") {
+		tag, ok := strings.CutPrefix(fields[1], "refs/tags/")
+		if !ok {
 			continue
 		}
-
-		// Extract tag name from refs/tags/tagname
-		if !strings.HasPrefix(ref, "refs/tags/") {
+		tag, peeled := strings.CutSuffix(tag, "^{}")
+		if !matchesTagPrefix(tag, prefix) {
 			continue
 		}
-		tag := strings.TrimPrefix(ref, "refs/tags/")
-
-		// Apply prefix filter
-		switch {
-		case prefix == "" && strings.Contains(tag, "/"):
-			continue
-		case prefix != "" && !strings.HasPrefix(tag, prefix+"/"):
+		if _, seen := commits[tag]; seen && !peeled {
 			continue
 		}
-
-		tags = append(tags, tag)
+		commits[tag] = fields[0]
 	}
 
 end:
-	return tags, err
+	return commits, err
 }
 
-// CompareRemoteTags checks if remote has newer tags than local
-// Returns: missingTags (tags on remote but not local), error
-func (r *Repo) CompareRemoteTags(ctx context.Context, prefix string) (missingTags []string, err error) {
-	var localTags []string
-	var remoteTags []string
-	var localTagSet map[string]bool
+// TagRef is what a tag resolves to
+type TagRef struct {
+	Commit string `json:"commit"`
+	Tree   string `json:"tree"`
+}
 
-	localTags, err = r.Tags(ctx, prefix)
+// TagRefs returns the commit and tree each local tag resolves to, peeling
+// annotated tags
+func (r *Repo) TagRefs(ctx context.Context, prefix string) (refs map[string]TagRef, err error) {
+	var out string
+
+	out, err = r.runGit(ctx, r.Root, "for-each-ref",
+		"--format=%(refname:lstrip=2) %(objectname) %(tree) %(*objectname) %(*tree)",
+		"refs/tags",
+	)
 	if err != nil {
 		goto end
 	}
 
-	remoteTags, err = r.RemoteTags(ctx, prefix)
+	// "tree" is only set for lightweight tags and "*objectname" and "*tree" for
+	// annotated tags, so the commit and tree are always the last two fields
+	refs = make(map[string]TagRef)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !matchesTagPrefix(fields[0], prefix) {
+			continue
+		}
+		refs[fields[0]] = TagRef{
+			Commit: fields[len(fields)-2],
+			Tree:   fields[len(fields)-1],
+		}
+	}
+
+end:
+	return refs, err
+}
+
+// TagMismatch is a tag that points at different commits locally and on the
+// remote
+type TagMismatch struct {
+	Tag          string
+	LocalCommit  string
+	RemoteCommit string
+}
+
+// TagComparison is the difference between the local and remote tags of a
+// module
+type TagComparison struct {
+	// LocalOnly lists tags that have not been pushed to the remote
+	LocalOnly []string
+
+	// RemoteOnly lists tags that have not been fetched from the remote
+	RemoteOnly []string
+
+	// Mismatched lists tags pointing at different commits locally and remotely
+	Mismatched []TagMismatch
+}
+
+// InSync returns true if the local and remote tags agree
+func (c TagComparison) InSync() bool {
+	return len(c.LocalOnly) == 0 && len(c.RemoteOnly) == 0 && len(c.Mismatched) == 0
+}
+
+// CompareTags compares the local tags matching prefix with the remote's by
+// the commits they point at. Each list is sorted newest semver first.
+func (r *Repo) CompareTags(ctx context.Context, prefix string) (cmp TagComparison, err error) {
+	var local map[string]TagRef
+	var remote map[string]string
+
+	local, err = r.TagRefs(ctx, prefix)
 	if err != nil {
 		goto end
 	}
-
-	// Build set of local tags for fast lookup
-	localTagSet = make(map[string]bool, len(localTags))
-	for _, tag := range localTags {
-		localTagSet[tag] = true
+	remote, err = r.RemoteTagCommits(ctx, prefix)
+	if err != nil {
+		goto end
 	}
 
-	// Find tags that exist on remote but not locally
-	for _, tag := range remoteTags {
-		if !localTagSet[tag] {
-			missingTags = append(missingTags, tag)
+	for tag, ref := range local {
+		remoteCommit, ok := remote[tag]
+		switch {
+		case !ok:
+			cmp.LocalOnly = append(cmp.LocalOnly, tag)
+		case remoteCommit != ref.Commit:
+			cmp.Mismatched = append(cmp.Mismatched, TagMismatch{
+				Tag:          tag,
+				LocalCommit:  ref.Commit,
+				RemoteCommit: remoteCommit,
+			})
 		}
 	}
-
-	// Sort missing tags (newest first if they're semver)
-	if len(missingTags) > 0 {
-		sort.Slice(missingTags, func(i, j int) bool {
-			return semver.Compare(missingTags[i], missingTags[j]) > 0
-		})
+	for tag := range remote {
+		if _, ok := local[tag]; !ok {
+			cmp.RemoteOnly = append(cmp.RemoteOnly, tag)
+		}
 	}
 
+	sortTagsNewestFirst(cmp.LocalOnly)
+	sortTagsNewestFirst(cmp.RemoteOnly)
+	sort.Slice(cmp.Mismatched, func(i, j int) bool {
+		return compareTagVersions(cmp.Mismatched[i].Tag, cmp.Mismatched[j].Tag) > 0
+	})
+
 end:
+	return cmp, err
+}
+
+// sortTagsNewestFirst sorts module tags by descending version
+func sortTagsNewestFirst(tags []string) {
+	sort.Slice(tags, func(i, j int) bool {
+		return compareTagVersions(tags[i], tags[j]) > 0
+	})
+}
+
+// compareTagVersions compares module tags by their versions, falling back to
+// the tag names for non-semver tags
+func compareTagVersions(a, b string) int {
+	c := semver.Compare(ModuleTagVersion(a), ModuleTagVersion(b))
+	if c == 0 {
+		c = strings.Compare(a, b)
+	}
+	return c
+}
+
+// matchesTagPrefix returns true if tag belongs to the module at the
+// repo-relative prefix, where "" and "." mean the repository root
+func matchesTagPrefix(tag, prefix string) bool {
+	switch prefix {
+	case "", ".":
+		return !strings.Contains(tag, "/")
+	default:
+		return strings.HasPrefix(tag, prefix+"/")
+	}
+}
+
+// CompareRemoteTags checks if remote has newer tags than local
+// Returns: missingTags (tags on remote but not local), error
+func (r *Repo) CompareRemoteTags(ctx context.Context, prefix string) (missingTags []string, err error) {
+	var cmp TagComparison
+
+	cmp, err = r.CompareTags(ctx, prefix)
+	missingTags = cmp.RemoteOnly
 	return missingTags, err
 }
 
 type LatestTagArgs struct {
 	IncludeUnreachable bool // default: false
 	AllowNonSemver     bool // default: false
+	ExcludePrereleases bool // default: false; prerelease tags count as baseline
 	ModuleRelPath      dt.RelDirPath
 }
 
-// LatestTag returns the latest reachable semver tag by default.
-// Passing non-default options is currently unsupported.
+// LatestTag returns the latest reachable semver tag by default, including
+// prerelease tags unless args.ExcludePrereleases is set. IncludeUnreachable and
+// AllowNonSemver are currently unsupported.
 func (r *Repo) LatestTag(ctx context.Context, headCommit string, args *LatestTagArgs) (latest string, err error) {
 	var semverTags []string
 	var reachable []string
@@ -604,7 +868,11 @@ func (r *Repo) LatestTag(ctx context.Context, headCommit string, args *LatestTag
 	}
 
 	for _, tag := range tags {
-		if !semver.IsValid(tag) {
+		// Subdirectory modules are tagged "<dir>/vX.Y.Z" so validate only the version
+		if !semver.IsValid(ModuleTagVersion(tag)) {
+			continue
+		}
+		if args.ExcludePrereleases && semver.Prerelease(ModuleTagVersion(tag)) != "" {
 			continue
 		}
 		semverTags = append(semverTags, tag)
@@ -630,7 +898,7 @@ func (r *Repo) LatestTag(ctx context.Context, headCommit string, args *LatestTag
 	}
 
 	sort.Slice(reachable, func(i, j int) bool {
-		return semver.Compare(reachable[i], reachable[j]) > 0
+		return semver.Compare(ModuleTagVersion(reachable[i]), ModuleTagVersion(reachable[j])) > 0
 	})
 	latest = reachable[0]
 end:
@@ -663,6 +931,15 @@ end:
 	return isAncestor, err
 }
 
+// ShowFile returns the content of the repo-relative file relPath at ref
+func (r *Repo) ShowFile(ctx context.Context, ref string, relPath dt.RelFilepath) (content []byte, err error) {
+	var out string
+
+	out, err = r.runGit(ctx, r.Root, "show", ref+":"+filepath.ToSlash(string(relPath)))
+	content = []byte(out)
+	return content, err
+}
+
 func (r *Repo) DiffNameStatus(ctx context.Context, fromRef, toRef string, patterns ...string) (newLines []string, err error) {
 	var lines []string
 	var out string
@@ -940,15 +1217,15 @@ func ensureSafeCacheRepoDir(repoDir dt.DirPath) (err error) {
 		err = fmt.Errorf("refusing to run in non-cache directory: %s", repoDir)
 		goto end
 	}
-	exists, err = dt.FilepathJoin(repoDir, ".git").Exists()
-	if exists {
-		err = fmt.Errorf("cache repo missing .git directory: %s", repoDir)
-		goto end
-	}
+	exists, err = dt.DirPathJoin(repoDir, ".git").Exists()
 	if err != nil {
 		err = fmt.Errorf("filesystem error accessing %s", repoDir)
 		goto end
 	}
+	if !exists {
+		err = fmt.Errorf("cache repo missing .git directory: %s", repoDir)
+		goto end
+	}
 end:
 	return err
 }
@@ -0,0 +1,103 @@
+package gitutils
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// git runs git in dir and returns its trimmed output
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestCompareTags(t *testing.T) {
+	root := t.TempDir()
+	remoteDir := filepath.Join(root, "remote.git")
+	repoDir := filepath.Join(root, "repo")
+
+	git(t, root, "init", "--quiet", "--bare", remoteDir)
+	git(t, root, "init", "--quiet", "--initial-branch=main", repoDir)
+	git(t, repoDir, "remote", "add", "origin", remoteDir)
+	git(t, repoDir, "commit", "--quiet", "--allow-empty", "--message", "First")
+	git(t, repoDir, "push", "--quiet", "--set-upstream", "origin", "main")
+	first := git(t, repoDir, "rev-parse", "HEAD")
+
+	// Annotated tags are compared by the commits they point at
+	git(t, repoDir, "tag", "v1.0.0")
+	git(t, repoDir, "tag", "--annotate", "--message", "v1.0.1", "v1.0.1")
+	git(t, repoDir, "tag", "--annotate", "--message", "v1.1.0", "v1.1.0")
+	git(t, repoDir, "tag", "v0.9.0")
+	git(t, repoDir, "tag", "cmd/v1.0.0")
+	git(t, repoDir, "push", "--quiet", "origin", "--tags")
+	git(t, repoDir, "tag", "--delete", "v0.9.0")
+
+	git(t, repoDir, "commit", "--quiet", "--allow-empty", "--message", "Second")
+	second := git(t, repoDir, "rev-parse", "HEAD")
+	git(t, repoDir, "tag", "v1.2.0")
+	git(t, repoDir, "tag", "--force", "--annotate", "--message", "v1.1.0 moved", "v1.1.0")
+	git(t, repoDir, "tag", "cmd/v1.1.0")
+
+	repo, err := Open(dt.DirPath(repoDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("RepoRoot", func(t *testing.T) {
+		cmp, err := repo.CompareTags(t.Context(), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(cmp.LocalOnly, []string{"v1.2.0"}) {
+			t.Errorf("got local only %q, want [v1.2.0]", cmp.LocalOnly)
+		}
+		if !slices.Equal(cmp.RemoteOnly, []string{"v0.9.0"}) {
+			t.Errorf("got remote only %q, want [v0.9.0]", cmp.RemoteOnly)
+		}
+		want := []TagMismatch{{Tag: "v1.1.0", LocalCommit: second, RemoteCommit: first}}
+		if !slices.Equal(cmp.Mismatched, want) {
+			t.Errorf("got mismatched %+v, want %+v", cmp.Mismatched, want)
+		}
+		if cmp.InSync() {
+			t.Error("got in sync, want not")
+		}
+	})
+
+	t.Run("Subdir", func(t *testing.T) {
+		cmp, err := repo.CompareTags(t.Context(), "cmd")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(cmp.LocalOnly, []string{"cmd/v1.1.0"}) {
+			t.Errorf("got local only %q, want [cmd/v1.1.0]", cmp.LocalOnly)
+		}
+		if len(cmp.RemoteOnly) != 0 || len(cmp.Mismatched) != 0 {
+			t.Errorf("got remote only %q and mismatched %+v, want none", cmp.RemoteOnly, cmp.Mismatched)
+		}
+	})
+}
+
+func TestSortTagsNewestFirst(t *testing.T) {
+	tags := []string{"v1.2.0", "v1.10.0", "v1.10.0-rc.1", "v0.9.0", "v1.2.0-beta"}
+	sortTagsNewestFirst(tags)
+	want := []string{"v1.10.0", "v1.10.0-rc.1", "v1.2.0", "v1.2.0-beta", "v0.9.0"}
+	if !slices.Equal(tags, want) {
+		t.Errorf("got %q, want %q", tags, want)
+	}
+}
@@ -125,6 +125,35 @@ end:
 	return err
 }
 
+// ExportIndex exports the complete staged tree, i.e. every file in the index
+// and not just the changed ones, to args.DestDir using `git checkout-index`.
+// Unlike ExportStagedFiles the result can be built and tested. ModuleRelDir
+// is ignored as packages may depend on files elsewhere in the repo.
+func ExportIndex(ctx context.Context, args ExportStagedArgs) (err error) {
+	var destDir dt.DirPath
+
+	if args.Repo == nil {
+		err = fmt.Errorf("repo cannot be nil")
+		goto end
+	}
+
+	if args.DestDir == "" {
+		err = fmt.Errorf("destination directory cannot be empty")
+		goto end
+	}
+
+	destDir, err = args.DestDir.Abs()
+	if err != nil {
+		goto end
+	}
+
+	// --prefix must end in a slash to be treated as a directory
+	_, err = args.Repo.runGit(ctx, args.Repo.Root, "checkout-index", "--all", "--force", "--prefix="+string(destDir)+"/")
+
+end:
+	return err
+}
+
 // gitShowStaged uses `git show :path` to get the staged version of a file
 func (r *Repo) gitShowStaged(ctx context.Context, relPath string) (content []byte, err error) {
 	var cmd = fmt.Sprintf(":%s", relPath)
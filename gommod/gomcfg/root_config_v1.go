@@ -43,6 +43,54 @@ type rootConfigV1Base struct {
 	Version     int      `json:"version"`
 	ScanDirs    []string `json:"scan_dirs,omitempty"`
 	ModuleSpecs []string `json:"module_specs,omitempty"`
+
+	// ToolDepsInFlux lets modules required only for `tool` directives make the
+	// modules requiring them in-flux
+	ToolDepsInFlux bool `json:"tool_deps_in_flux,omitempty"`
+
+	// IgnorePrereleases makes the latest final release the baseline for
+	// verdicts and version suggestions rather than a later prerelease tag
+	IgnorePrereleases bool `json:"ignore_prereleases,omitempty"`
+
+	// Concurrency bounds how many repos are checked for in-flux status at once;
+	// zero uses GOMAXPROCS
+	Concurrency int `json:"concurrency,omitempty"`
+
+	// Workspaces are named groupings of related repos; a command given
+	// --workspace scans only its repos instead of scan_dirs
+	Workspaces []WorkspaceV1 `json:"workspaces,omitempty"`
+
+	// LintCommand is the golangci-lint executable `run lint` uses for modules
+	// without a golangci-lint tool directive; empty finds it in PATH
+	LintCommand string `json:"lint_command,omitempty"`
+}
+
+// WorkspaceV1 is a named workspace in the root config
+type WorkspaceV1 struct {
+	Name string `json:"name"`
+
+	// Repos are the member repos' root directories, e.g. "~/Projects/go-dt"
+	Repos []string `json:"repos"`
+
+	// ModuleSpecs replace the root module_specs for the workspace's repos
+	ModuleSpecs []string `json:"module_specs,omitempty"`
+
+	// DefaultBranch is the branch member repos are expected to have checked out
+	DefaultBranch string `json:"default_branch,omitempty"`
+
+	// GoWork is an optional go.work file covering the workspace's modules
+	GoWork string `json:"go_work,omitempty"`
+}
+
+// WorkspaceIndex returns the index of the named workspace, or -1 if there is
+// none by that name
+func (c *RootConfigV1) WorkspaceIndex(name string) int {
+	for i, ws := range c.Workspaces {
+		if ws.Name == name {
+			return i
+		}
+	}
+	return -1
 }
 
 //goland:noinspection GoUnusedExportedFunction
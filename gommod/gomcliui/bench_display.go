@@ -0,0 +1,68 @@
+package gomcliui
+
+import (
+	"fmt"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+)
+
+// DisplayBenchResult formats and displays the benchmark comparison of the bench
+// command along with the API verdict for the same baseline
+func DisplayBenchResult(result *gompkg.BenchResult, writer cliutil.Writer) {
+	writer.Printf("\nBenchmarks for %s:\n", result.ModulePath)
+	writer.Printf("- Dir:       %s\n", result.ModuleDir.ToTilde(dt.OrFullPath))
+	writer.Printf("- Compared:  %s vs %s\n", result.BaselineTag, result.Target)
+	writer.Printf("- Settings:  -bench=%s -count=%d, threshold %.1f%%, alpha %.2f\n\n",
+		result.Settings.Benchmarks, result.Settings.Count, result.Settings.Threshold, result.Settings.Alpha)
+
+	if len(result.Comparisons) == 0 {
+		writer.Printf("No benchmarks ran at both %s and %s.\n\n", result.BaselineTag, result.Target)
+	} else {
+		rows := make([][]string, len(result.Comparisons))
+		for i, c := range result.Comparisons {
+			rows[i] = []string{
+				c.Name,
+				c.Unit,
+				formatBenchValue(c.BaselineMedian),
+				formatBenchValue(c.CurrentMedian),
+				formatBenchDelta(c),
+				fmt.Sprintf("p=%.3f n=%d+%d", c.P, c.BaselineN, c.CurrentN),
+			}
+		}
+		DisplayTable([]string{"Benchmark", "Unit", result.BaselineTag, result.Target, "Delta", "Stats"}, rows, writer)
+		writer.Printf("\n")
+	}
+
+	writer.Printf("API verdict:\n")
+	writer.Printf("- Verdict: %s\n", result.Verdict)
+	writer.Printf("- Reason:  %s\n\n", result.VerdictReason)
+	DisplayVerdictEvidence(result.VerdictEvidence, writer)
+
+	if len(result.Regressions) == 0 {
+		DisplaySuccess("No significant benchmark regressions", writer)
+		writer.Printf("\n")
+		return
+	}
+	DisplayWarning(fmt.Sprintf("%d significant benchmark regression(s):", len(result.Regressions)), writer)
+	for _, c := range result.Regressions {
+		writer.Printf("  - %s %s %+.1f%% (p=%.3f)\n", c.Name, c.Unit, c.Delta, c.P)
+	}
+	writer.Printf("\n")
+}
+
+// formatBenchValue formats a benchmark median with at most 4 significant digits
+func formatBenchValue(v float64) string {
+	return fmt.Sprintf("%.4g", v)
+}
+
+// formatBenchDelta formats a comparison's delta the way benchstat does, showing
+// "~" for changes that are not statistically significant
+func formatBenchDelta(c goutils.BenchComparison) string {
+	if !c.Significant {
+		return "~"
+	}
+	return fmt.Sprintf("%+.1f%%", c.Delta)
+}
@@ -0,0 +1,50 @@
+package gomcliui
+
+import (
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+)
+
+// DisplayBumpResult formats and displays the outcome of the bump command
+func DisplayBumpResult(result *gompkg.BumpResult, writer cliutil.Writer) {
+	var rows [][]string
+
+	writer.Printf("\nBumping dependents of %s to %s:\n\n", result.ModulePath, result.Version)
+
+	if len(result.Bumped) == 0 {
+		writer.Printf("No dependents require an older version.\n\n")
+		return
+	}
+
+	for _, b := range result.Bumped {
+		tidy := "ok"
+		switch {
+		case result.DryRun:
+			tidy = "-"
+		case b.TidyErr != nil:
+			tidy = "FAILED"
+		}
+		rows = append(rows, []string{
+			string(b.ModulePath),
+			b.ModuleDir.ToTilde(dt.OrFullPath),
+			string(b.FromVersion),
+			string(result.Version),
+			tidy,
+		})
+	}
+	DisplayTable([]string{"DEPENDENT", "DIR", "FROM", "TO", "TIDY"}, rows, writer)
+
+	for _, b := range result.Bumped {
+		if b.TidyErr != nil {
+			DisplayWarning("go mod tidy failed in "+string(b.ModulePath)+": "+b.TidyErr.Error(), writer)
+		}
+	}
+
+	if result.DryRun {
+		writer.Printf("\nDry run; no go.mod files were changed.\n\n")
+		return
+	}
+
+	writer.Printf("\nDependents are left uncommitted; run `gomion next` to release them in turn.\n\n")
+}
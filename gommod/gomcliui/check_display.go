@@ -0,0 +1,36 @@
+package gomcliui
+
+import (
+	"fmt"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+)
+
+// DisplayCheckTagsResult formats and displays the outcome of `check tags`,
+// grouping the problems by repo
+func DisplayCheckTagsResult(result *gompkg.CheckTagsResult, writer cliutil.Writer) {
+	var repoRoot dt.DirPath
+
+	writer.Printf("\nChecked %d tags in %d repos\n\n", result.TagCount, len(result.Repos))
+	if len(result.Problems) == 0 {
+		DisplaySuccess("All tags match their modules", writer)
+		writer.Printf("\n")
+		return
+	}
+
+	for _, p := range result.Problems {
+		if p.RepoRoot != repoRoot {
+			repoRoot = p.RepoRoot
+			writer.Printf("%s:\n", repoRoot.ToTilde(dt.OrFullPath))
+		}
+		writer.Printf("  - %-16s %s: %s\n", p.Kind, p.Tag, p.Detail)
+		if p.ModulePath != "" {
+			writer.Printf("    %-16s module %s\n", "", p.ModulePath)
+		}
+	}
+	writer.Printf("\n")
+	DisplayWarning(fmt.Sprintf("%d inconsistent tags", len(result.Problems)), writer)
+	writer.Printf("Published tags must never move; fix the module and tag a new version, or delete the tag if it was never pushed.\n\n")
+}
@@ -0,0 +1,56 @@
+package gomcliui
+
+import (
+	"fmt"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+)
+
+// DisplayDependentsResult formats and displays the outcome of the dependents
+// command
+func DisplayDependentsResult(result *gompkg.DependentsResult, writer cliutil.Writer) {
+	var outdated int
+
+	latest := string(result.LatestVersion)
+	if latest == "" {
+		latest = "(never tagged)"
+	}
+
+	writer.Printf("\nDependents of %s:\n", result.ModulePath)
+	writer.Printf("- Dir:    %s\n", result.ModuleDir.ToTilde(dt.OrFullPath))
+	writer.Printf("- Latest: %s\n\n", latest)
+
+	if len(result.Dependents) == 0 {
+		writer.Printf("No local modules depend on it.\n\n")
+		return
+	}
+
+	for _, d := range result.Dependents {
+		requires := string(d.Requires)
+		if d.Indirect {
+			requires += " // indirect"
+		}
+		if d.Outdated {
+			requires += " (outdated)"
+			outdated++
+		}
+		if d.Depth == 1 {
+			writer.Printf("  - %s requires %s\n", d.ModulePath, requires)
+		} else {
+			writer.Printf("  - %s via %s (depth %d)", d.ModulePath, d.Via, d.Depth)
+			if d.Requires != "" {
+				writer.Printf(", requires %s", requires)
+			}
+			writer.Printf("\n")
+		}
+		writer.Printf("    %s\n", d.ModuleDir.ToTilde(dt.OrFullPath))
+	}
+	writer.Printf("\n")
+
+	if outdated > 0 {
+		DisplayWarning(fmt.Sprintf("%d dependents require a version older than %s", outdated, latest), writer)
+		writer.Printf("Update them with: gomion bump %s\n\n", result.ModuleDir.ToTilde(dt.OrFullPath))
+	}
+}
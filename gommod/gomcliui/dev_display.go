@@ -0,0 +1,48 @@
+package gomcliui
+
+import (
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+)
+
+// DisplayDevModeResult formats and displays the outcome of `dev on` or `dev off`
+func DisplayDevModeResult(result *gompkg.DevModeResult, on bool, writer cliutil.Writer) {
+	var rows [][]string
+
+	action := "Removed"
+	if on {
+		action = "Added"
+	}
+
+	writer.Printf("\nDev mode for %s:\n\n", result.RepoDir.ToTilde(dt.OrFullPath))
+
+	if len(result.Replaces) == 0 && len(result.WorkUses) == 0 {
+		writer.Printf("Nothing to change.\n")
+	}
+
+	for _, rep := range result.Replaces {
+		rows = append(rows, []string{string(rep.GoMod), string(rep.ModulePath), rep.Dir})
+	}
+	if len(rows) > 0 {
+		writer.Printf("%s replace directives:\n", action)
+		DisplayTable([]string{"GO.MOD", "MODULE", "REPLACEMENT"}, rows, writer)
+	}
+
+	if len(result.WorkUses) > 0 {
+		writer.Printf("%s go.work uses:\n", action)
+		for _, use := range result.WorkUses {
+			writer.Printf("  %s\n", use)
+		}
+	}
+
+	for _, skip := range result.Skipped {
+		DisplayWarning(string(skip.GoMod)+": "+string(skip.ModulePath)+" "+skip.Reason, writer)
+	}
+
+	if on {
+		writer.Printf("\nRun `gomion dev off` before releasing.\n\n")
+		return
+	}
+	writer.Printf("\n")
+}
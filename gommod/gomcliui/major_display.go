@@ -0,0 +1,34 @@
+package gomcliui
+
+import (
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+)
+
+// DisplayMajorResult formats and displays the preview or outcome of the major command
+func DisplayMajorResult(result *gompkg.MajorResult, writer cliutil.Writer) {
+	baseDir := result.ModuleDir.Dir()
+
+	writer.Printf("\nMigrating %s to %s:\n\n", result.OldPath, result.NewPath)
+	writer.Printf("- Dir:        %s\n", result.ModuleDir.ToTilde(dt.OrFullPath))
+	writer.Printf("- Version:    %s\n", result.Version)
+	writer.Printf("- Dependents: %d\n", len(result.Dependents))
+	for _, dep := range result.Dependents {
+		writer.Printf("  - %s\n", dep)
+	}
+	writer.Printf("- Files:      %d\n\n", len(result.Edits))
+
+	for _, edit := range result.Edits {
+		writer.Printf("%s", edit.Diff(baseDir))
+	}
+
+	if !result.Written {
+		writer.Printf("\nPreview only; rerun with --write to apply these changes.\n\n")
+		return
+	}
+
+	DisplaySuccess("Rewrote "+string(result.OldPath)+" as "+string(result.NewPath), writer)
+	writer.Printf("\nDependents now require %s, which resolves once it is tagged;\n", result.Version)
+	writer.Printf("use `gomion dev on` to build them against the local checkout until then.\n\n")
+}
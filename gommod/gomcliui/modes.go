@@ -18,6 +18,9 @@ type DirtyRepoModeArgs struct {
 	ModuleDir dt.DirPath
 	Writer    cliutil.Writer
 	Logger    *slog.Logger
+
+	// Coverage adds the test coverage comparison to commit message analysis
+	Coverage bool
 }
 
 // NewDirtyRepoMode creates a menu mode for dirty repository operations
@@ -64,7 +67,7 @@ func NewDirtyRepoMode(args DirtyRepoModeArgs) *climenu.BaseMenuMode {
 				Name:        "generate",
 				Description: "Generate commit message using AI analysis",
 				Handler: func(handlerArgs *climenu.OptionHandlerArgs) error {
-					return generateCommitMessageInteractive(args.ModuleDir, args.Writer, args.Logger)
+					return generateCommitMessageInteractive(args.ModuleDir, args.Writer, args.Logger, args.Coverage)
 				},
 			},
 		},
@@ -155,7 +158,7 @@ func NewCommitMessageMode(args CommitMessageModeArgs) *climenu.BaseMenuMode {
 }
 
 // generateCommitMessageInteractive generates a commit message and shows the commit message menu
-func generateCommitMessageInteractive(moduleDir dt.DirPath, writer cliutil.Writer, logger *slog.Logger) (err error) {
+func generateCommitMessageInteractive(moduleDir dt.DirPath, writer cliutil.Writer, logger *slog.Logger, coverage bool) (err error) {
 	var message string
 	var analysisResults *precommit.Results
 	var agent *askai.Agent
@@ -174,6 +177,7 @@ func generateCommitMessageInteractive(moduleDir dt.DirPath, writer cliutil.Write
 		Logger:    logger,
 		Writer:    writer.Writer(),
 		Agent:     agent,
+		Coverage:  coverage,
 	})
 	if err != nil {
 		goto end
@@ -1,6 +1,8 @@
 package gomcliui
 
 import (
+	"strings"
+
 	"github.com/mikeschinkel/go-cliutil"
 	"github.com/mikeschinkel/go-dt"
 	"github.com/mikeschinkel/gomion/gommod/gompkg"
@@ -9,7 +11,7 @@ import (
 // DisplayNextResult formats and displays the engine result for the next command
 func DisplayNextResult(startDir dt.DirPath, result *gompkg.EngineResult, writer cliutil.Writer) {
 	isDirty := result.StagedFiles > 0 || result.UnstagedFiles > 0 || result.UntrackedFiles > 0
-	hasMissingTags := len(result.MissingRemoteTags) > 0
+	hasTagIssues := len(result.MissingRemoteTags) > 0 || len(result.UnpushedTags) > 0 || len(result.MismatchedTags) > 0
 
 	// Header
 	writer.Printf("\nAnalyzing dependency graph:\n\n")
@@ -38,16 +40,22 @@ func DisplayNextResult(startDir dt.DirPath, result *gompkg.EngineResult, writer
 		writer.Printf("- Remote: %s\n", result.Remote.Name)
 	}
 
+	DisplayTagIntegrityWarnings(result, writer)
+
 	// Status assessment and actions
 	switch {
-	case hasMissingTags:
-		writer.Printf("- Status: Fetched remote tags\n\n")
+	case hasTagIssues:
+		writer.Printf("- Status: Tags out of sync with remote\n\n")
 		DisplayMissingTagsActions(result, writer)
 
 	case isDirty:
 		writer.Printf("- Status: Repo DIRTY\n\n")
 		DisplayDirtyActions(startDir, result, writer)
 
+	case len(result.LocalReplaces) > 0:
+		writer.Printf("- Status: Local replace directives committed\n\n")
+		DisplayLocalReplaceActions(result, writer)
+
 	case result.Ahead > 0:
 		writer.Printf("- Status: Ahead of upstream\n\n")
 		DisplayAheadActions(result, writer)
@@ -56,19 +64,91 @@ func DisplayNextResult(startDir dt.DirPath, result *gompkg.EngineResult, writer
 		writer.Printf("- Status: Clean\n")
 		writer.Printf("- Verdict: %s\n", result.Verdict)
 		writer.Printf("- Reason:  %s\n\n", result.VerdictReason)
+		DisplayVerdictEvidence(result.VerdictEvidence, writer)
+		writer.Printf("Next:\n")
+		writer.Printf("  - gomion release --dry-run (to preview the tag)\n\n")
+	}
+}
+
+// DisplayVerdictEvidence lists the analysis signals behind a verdict
+func DisplayVerdictEvidence(evidence []string, writer cliutil.Writer) {
+	if len(evidence) == 0 {
+		return
+	}
+	writer.Printf("Evidence:\n")
+	for _, item := range evidence {
+		writer.Printf("  - %s\n", item)
+	}
+	writer.Printf("\n")
+}
+
+// DisplayLocalReplaceActions shows the local path replace directives that must
+// be removed before the leaf can be released
+func DisplayLocalReplaceActions(result *gompkg.EngineResult, writer cliutil.Writer) {
+	writer.Printf("Release blocked by:\n")
+	for _, rep := range result.LocalReplaces {
+		writer.Printf("  - %s\n", rep)
 	}
+	writer.Printf("\nNext:\n")
+	writer.Printf("  - Remove the replace directives, require released versions and commit\n\n")
 }
 
-// DisplayMissingTagsActions shows that tags were fetched from remote
+// DisplayMissingTagsActions shows the tags fetched from the remote and how to
+// reconcile tags not pushed or pointing at different commits
 func DisplayMissingTagsActions(result *gompkg.EngineResult, writer cliutil.Writer) {
-	writer.Printf("Action Taken:\n")
-	writer.Printf("1. Fetched remote tags:\n")
-	writer.Printf("  - Fetched tags: %v\n", result.MissingRemoteTags)
-	writer.Printf("  - Note: These tags were likely created by GitHub Actions\n")
-	writer.Printf("\nRerun:\n")
+	remote := result.Remote.NameOrDefault()
+
+	if len(result.MissingRemoteTags) > 0 {
+		writer.Printf("Action Taken:\n")
+		writer.Printf("  - Fetched remote tags: %s\n", strings.Join(result.MissingRemoteTags, ", "))
+		writer.Printf("  - Note: These tags were likely created by GitHub Actions\n\n")
+	}
+	if len(result.UnpushedTags) > 0 {
+		writer.Printf("Tags not pushed:\n")
+		for _, tag := range result.UnpushedTags {
+			writer.Printf("  - %s\n", tag)
+		}
+		writer.Printf("\n")
+	}
+	writer.Printf("Next:\n")
+	for _, tag := range result.UnpushedTags {
+		writer.Printf("  - git push %s refs/tags/%s\n", remote, tag)
+	}
+	for _, m := range result.MismatchedTags {
+		writer.Printf("  - git fetch --force %s refs/tags/%s:refs/tags/%s (published tags must not move)\n", remote, m.Tag, m.Tag)
+	}
 	writer.Printf("  - gomion next (to re-analyze with updated tags)\n\n")
 }
 
+// DisplayTagIntegrityWarnings warns about tags that moved since Gomion first
+// saw them or that disagree with the remote. Published versions are immutable
+// in the module proxy, so either breaks `go get` or go.sum verification.
+func DisplayTagIntegrityWarnings(result *gompkg.EngineResult, writer cliutil.Writer) {
+	if len(result.MovedTags) == 0 && len(result.MismatchedTags) == 0 {
+		return
+	}
+	writer.Printf("\n*** WARNING: published tags must never move ***\n")
+	for _, m := range result.MovedTags {
+		writer.Printf("  - %s moved: was commit %s, now %s", m.Tag, shortSHA(m.Recorded.Commit), shortSHA(m.Current.Commit))
+		if m.ContentChanged() {
+			writer.Printf(" with different content (go.sum checksums will fail)")
+		}
+		writer.Printf("\n")
+	}
+	for _, m := range result.MismatchedTags {
+		writer.Printf("  - %s differs from remote: local %s, remote %s\n", m.Tag, shortSHA(m.LocalCommit), shortSHA(m.RemoteCommit))
+	}
+	writer.Printf("  Restore the original tags, and release a new version for any new commits.\n\n")
+}
+
+// shortSHA abbreviates a commit SHA for display
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		sha = sha[:7]
+	}
+	return sha
+}
+
 // DisplayDirtyActionsArgs contains arguments for DisplayDirtyActions
 type DisplayDirtyActionsArgs struct {
 	StartDir              dt.DirPath
@@ -131,3 +211,21 @@ func DisplayAheadActions(result *gompkg.EngineResult, writer cliutil.Writer) {
 	writer.Printf("1. Push:\n")
 	writer.Printf("  - %d commits\n\n", result.Ahead)
 }
+
+// DisplayReleaseWaves shows every in-flux module grouped into waves that can be
+// released in parallel
+func DisplayReleaseWaves(waves gompkg.ReleaseWaves, format gompkg.OutputFormat, writer cliutil.Writer) {
+	if format == gompkg.JSONOutputFormat {
+		writer.Printf("%s\n", waves.JSON())
+		return
+	}
+
+	if len(waves) == 0 {
+		writer.Printf("\nNo in-flux modules; nothing to release.\n\n")
+		return
+	}
+
+	writer.Printf("\nRelease waves (modules in the same wave can be released in parallel):\n\n")
+	writer.Printf("%s\n", waves.TableWriter().Render())
+	writer.Printf("\n%d modules in %d waves\n\n", waves.ModuleCount(), len(waves))
+}
@@ -0,0 +1,29 @@
+package gomcliui
+
+import (
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+)
+
+// DisplayReleaseNotes formats and displays release notes for the notes command
+func DisplayReleaseNotes(notes *gompkg.ReleaseNotes, format gompkg.OutputFormat, writer cliutil.Writer) {
+	if format == gompkg.JSONOutputFormat {
+		writer.Printf("%s\n", notes.JSON())
+		return
+	}
+
+	baseline := notes.BaselineTag
+	if baseline == "" {
+		baseline = "(none)"
+	}
+	writer.Printf("\nRelease notes for %s since %s:\n\n", notes.ModulePath, baseline)
+	writer.Printf("%s\n", notes.Markdown())
+
+	if notes.APINote != "" {
+		writer.Printf("Note: %s\n\n", notes.APINote)
+	}
+
+	if notes.ChangelogFile != "" {
+		DisplaySuccess("Updated "+string(notes.ChangelogFile), writer)
+	}
+}
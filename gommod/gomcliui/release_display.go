@@ -0,0 +1,89 @@
+package gomcliui
+
+import (
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+)
+
+// DisplayReleaseResult formats and displays the outcome of the release command
+func DisplayReleaseResult(result *gompkg.ReleaseResult, writer cliutil.Writer) {
+	baseline := result.BaselineTag
+	if baseline == "" {
+		baseline = "(none; first release)"
+	}
+
+	if result.Aborted {
+		displayReleaseAbort(result, writer)
+		return
+	}
+
+	writer.Printf("\nReleasing leaf module:\n")
+	writer.Printf("- Module:   %s\n", result.ModulePath)
+	writer.Printf("- Dir:      %s\n", result.Leaf.LeafModuleDir.ToTilde(dt.OrFullPath))
+	writer.Printf("- Baseline: %s\n", baseline)
+	writer.Printf("- Verdict:  %s\n", result.Leaf.Verdict)
+	writer.Printf("- Reason:   %s\n", result.Leaf.VerdictReason)
+	writer.Printf("- Version:  %s\n", result.Version)
+	if result.VersionWarning != "" {
+		writer.Printf("- Warning:  %s\n", result.VersionWarning)
+	}
+	if result.Suggestions.Final != "" {
+		writer.Printf("- Series:   next prerelease %s, final %s\n", result.Suggestions.Prerelease, result.Suggestions.Final)
+	}
+	writer.Printf("- Tag:      %s\n", result.Tag)
+	writer.Printf("- Commit:   %s\n\n", result.Commit)
+	DisplayVerdictEvidence(result.Leaf.VerdictEvidence, writer)
+	DisplayTagIntegrityWarnings(result.Leaf, writer)
+
+	if result.DryRun {
+		writer.Printf("Dry run; would run:\n")
+		writer.Printf("  - go mod tidy and go test ./..., committing any go.mod and go.sum changes\n")
+		writer.Printf("  - git tag --annotate %s %s\n", result.Tag, result.Commit)
+		writer.Printf("  - git push %s refs/tags/%s\n", result.Leaf.Remote.NameOrDefault(), result.Tag)
+		writer.Printf("  - gomion bump --version=%s %s\n\n", result.Version, result.Leaf.LeafModuleDir.ToTilde(dt.OrFullPath))
+		return
+	}
+
+	if result.Resumed {
+		writer.Printf("Resumed interrupted release\n")
+	}
+	if result.ReleaseCommit != "" {
+		writer.Printf("Committed go mod tidy changes as %s; push the branch to publish it\n", result.ReleaseCommit)
+	}
+	writer.Printf("Tagged and pushed %s\n", result.Tag)
+	switch result.VerifyProxy {
+	case gompkg.VerifyProxyOff:
+	case "", gompkg.VerifyProxyLocal:
+		writer.Printf("Verified %s@%s resolves through a local module proxy\n", result.ModulePath, result.Version)
+	default:
+		writer.Printf("Verified %s@%s resolves through %s\n", result.ModulePath, result.Version, result.VerifyProxy)
+	}
+	if result.Bump != nil {
+		DisplayBumpResult(result.Bump, writer)
+	}
+
+	if result.Next == nil {
+		writer.Printf("Nothing left in-flux.\n\n")
+		return
+	}
+
+	writer.Printf("Next leaf:\n")
+	writer.Printf("- Module:  %s/go.mod\n", result.Next.LeafModuleDir.ToTilde(dt.OrFullPath))
+	writer.Printf("- Verdict: %s\n", result.Next.Verdict)
+	writer.Printf("- Reason:  %s\n\n", result.Next.VerdictReason)
+	DisplayVerdictEvidence(result.Next.VerdictEvidence, writer)
+}
+
+// displayReleaseAbort shows what rolling back an interrupted release did
+func displayReleaseAbort(result *gompkg.ReleaseResult, writer cliutil.Writer) {
+	writer.Printf("\nAborted release:\n")
+	writer.Printf("- Module:  %s\n", result.ModulePath)
+	writer.Printf("- Dir:     %s\n", result.Leaf.LeafModuleDir.ToTilde(dt.OrFullPath))
+	writer.Printf("- Version: %s\n", result.Version)
+	writer.Printf("- Tag:     %s\n\n", result.Tag)
+	for _, note := range result.AbortNotes {
+		writer.Printf("  - %s\n", note)
+	}
+	writer.Printf("\n")
+}
@@ -0,0 +1,24 @@
+package gomcliui
+
+import (
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+)
+
+// DisplayRetractResult formats and displays the outcome of the retract command
+func DisplayRetractResult(result *gompkg.RetractResult, writer cliutil.Writer) {
+	writer.Printf("\nRetracting %s %s:\n\n", result.ModulePath, result.Retract)
+	writer.Printf("- Dir:    %s\n", result.ModuleDir.ToTilde(dt.OrFullPath))
+	writer.Printf("- Reason: %s\n", result.Retract.Rationale)
+	writer.Printf("- Tags:   %d\n", len(result.Tags))
+	for _, tag := range result.Tags {
+		writer.Printf("  - %s\n", tag)
+	}
+	writer.Printf("\n%s\n", result.Edit.Diff(result.ModuleDir.Dir()))
+
+	DisplaySuccess("Committed retract directive to go.mod as "+result.Commit, writer)
+	writer.Printf("\nThe retraction only takes effect once a later version carrying it is published.\n")
+	writer.Printf("Next:\n")
+	writer.Printf("  - gomion release (to tag a patch with the retraction)\n\n")
+}
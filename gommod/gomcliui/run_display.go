@@ -0,0 +1,49 @@
+package gomcliui
+
+import (
+	"fmt"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+)
+
+// DisplayRunResult displays the per-module summary table that ends the run
+// command, after the streamed output
+func DisplayRunResult(result *gompkg.RunResult, writer cliutil.Writer) {
+	var rows [][]string
+
+	writer.Printf("\n")
+	if len(result.Modules) == 0 {
+		writer.Printf("No modules to %s.\n\n", result.Task)
+		return
+	}
+
+	for _, mr := range result.Modules {
+		note := ""
+		switch mr.Status {
+		case gompkg.RunSkipped:
+			note = mr.Error
+			if mr.BlockedBy != "" {
+				note = "requires " + string(mr.BlockedBy)
+			}
+		case gompkg.RunFailed:
+			note = mr.Error
+		}
+		rows = append(rows, []string{
+			string(mr.ModulePath),
+			string(mr.Status),
+			fmt.Sprintf("%.1fs", mr.Seconds),
+			note,
+		})
+	}
+	DisplayTable([]string{"MODULE", "STATUS", "TIME", "NOTE"}, rows, writer)
+	writer.Printf("\n")
+
+	summary := fmt.Sprintf("%s: %d passed, %d failed, %d skipped", result.Task, result.Passed, result.Failed, result.Skipped)
+	if result.Failed > 0 || result.Skipped > 0 {
+		DisplayError(summary, writer)
+	} else {
+		DisplaySuccess(summary, writer)
+	}
+	writer.Printf("\n")
+}
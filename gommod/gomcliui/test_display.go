@@ -0,0 +1,58 @@
+package gomcliui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+)
+
+// DisplayTestResult formats and displays the outcome of the test command,
+// showing the output of each module's `go test` run
+func DisplayTestResult(result *gompkg.TestResult, writer cliutil.Writer) {
+	impact := result.Impact
+
+	writer.Printf("\nTesting %s and its dependents\n", impact.ModulePath)
+	writer.Printf("- Dir:     %s\n", impact.ModuleDir.ToTilde(dt.OrFullPath))
+	writer.Printf("- Changes: %d files in %d packages\n\n", len(impact.ChangedFiles), len(impact.ChangedPackages))
+
+	for _, pkgPath := range impact.ChangedPackages {
+		writer.Printf("  * %s\n", pkgPath)
+	}
+	if len(impact.ChangedPackages) > 0 {
+		writer.Printf("\n")
+	}
+
+	for _, run := range result.Runs {
+		scope := "all packages"
+		if run.Packages != nil {
+			scope = fmt.Sprintf("%d affected packages", len(run.Packages))
+		}
+		writer.Printf("%s (%s):\n", run.ModulePath, scope)
+		for _, line := range strings.Split(strings.TrimRight(run.Output, "\n"), "\n") {
+			writer.Printf("    %s\n", line)
+		}
+		writer.Printf("\n")
+	}
+
+	if len(result.Skipped) > 0 {
+		writer.Printf("Not affected, skipped:\n")
+		for _, mp := range result.Skipped {
+			writer.Printf("  - %s\n", mp)
+		}
+		writer.Printf("\n")
+	}
+
+	failed := result.Failed()
+	switch {
+	case len(result.Runs) == 0:
+		DisplaySuccess("No packages affected by the changes", writer)
+	case len(failed) == 0:
+		DisplaySuccess(fmt.Sprintf("Tests passed in %d modules", len(result.Runs)), writer)
+	default:
+		DisplayError(fmt.Sprintf("Tests failed in %d of %d modules", len(failed), len(result.Runs)), writer)
+	}
+	writer.Printf("\n")
+}
@@ -0,0 +1,111 @@
+package gomcliui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+)
+
+// DisplayWorkspaces lists the configured workspaces as a table
+func DisplayWorkspaces(workspaces []gompkg.Workspace, writer cliutil.Writer) {
+	var rows [][]string
+
+	if len(workspaces) == 0 {
+		writer.Printf("No workspaces configured. Add one with: gomion workspace add <name> <repos>\n")
+		return
+	}
+
+	for _, ws := range workspaces {
+		rows = append(rows, []string{
+			ws.Name,
+			fmt.Sprintf("%d", len(ws.RepoDirs)),
+			orDash(ws.DefaultBranch),
+			orDash(string(ws.GoWork)),
+		})
+	}
+	writer.Printf("\n")
+	DisplayTable([]string{"NAME", "REPOS", "DEFAULT BRANCH", "GO.WORK"}, rows, writer)
+	writer.Printf("\n")
+}
+
+// DisplayWorkspaceStatus shows a workspace's repos with their branches and
+// managed modules
+func DisplayWorkspaceStatus(status *gompkg.WorkspaceStatus, writer cliutil.Writer) {
+	ws := status.Workspace
+
+	writer.Printf("\nWorkspace %s:\n", ws.Name)
+	writer.Printf("- Default branch: %s\n", orDash(ws.DefaultBranch))
+	if ws.GoWork != "" {
+		exists := ""
+		if !status.GoWorkExists {
+			exists = " (missing)"
+		}
+		writer.Printf("- go.work:        %s%s\n", ws.GoWork, exists)
+	}
+	if len(ws.ModuleSpecs) > 0 {
+		specs := make([]string, len(ws.ModuleSpecs))
+		for i, spec := range ws.ModuleSpecs {
+			specs[i] = string(spec)
+		}
+		writer.Printf("- Module specs:   %s\n", strings.Join(specs, ", "))
+	}
+	writer.Printf("\n")
+
+	for _, r := range status.Repos {
+		writer.Printf("  %s\n", r.Dir.ToTilde(dt.OrFullPath))
+		switch {
+		case r.Missing:
+			writer.Printf("    (not a git repo)\n")
+			continue
+		case r.OffBranch:
+			writer.Printf("    branch: %s (expected %s)\n", r.Branch, ws.DefaultBranch)
+		default:
+			writer.Printf("    branch: %s\n", r.Branch)
+		}
+		if r.Unmanaged {
+			writer.Printf("    (no .gomion/config.json; not managed by gomion)\n")
+		}
+		for _, mp := range r.Modules {
+			writer.Printf("    - %s\n", mp)
+		}
+	}
+	writer.Printf("\n")
+
+	DisplayWorkspaceBranchWarnings(status, writer)
+}
+
+// DisplayWorkspaceWarnings warns that a workspace's go.work is missing and
+// about its repos that are not on its default branch
+func DisplayWorkspaceWarnings(status *gompkg.WorkspaceStatus, writer cliutil.Writer) {
+	ws := status.Workspace
+	if ws.GoWork != "" && !status.GoWorkExists {
+		DisplayWarning(fmt.Sprintf("go.work %s of workspace %s does not exist; go commands run without it", ws.GoWork, ws.Name), writer)
+		writer.Printf("\n")
+	}
+	DisplayWorkspaceBranchWarnings(status, writer)
+}
+
+// DisplayWorkspaceBranchWarnings warns about workspace repos that are not on
+// the workspace's default branch
+func DisplayWorkspaceBranchWarnings(status *gompkg.WorkspaceStatus, writer cliutil.Writer) {
+	offBranch := status.OffBranch()
+	if len(offBranch) == 0 {
+		return
+	}
+	DisplayWarning(fmt.Sprintf("%d repos in workspace %s are not on %s", len(offBranch), status.Workspace.Name, status.Workspace.DefaultBranch), writer)
+	for _, r := range offBranch {
+		writer.Printf("  - %s is on %s\n", r.Dir.ToTilde(dt.OrFullPath), r.Branch)
+	}
+	writer.Printf("\n")
+}
+
+// orDash returns s, or "-" if s is empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,127 @@
+package gomcmds
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/gomion/gommod/gomcliui"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+)
+
+var _ cliutil.CommandHandler = (*BenchCmd)(nil)
+
+var benchOpts = &struct {
+	dir       *string
+	bench     *string
+	count     *int
+	threshold *string
+	staged    *bool
+}{
+	dir:       new(string),
+	bench:     new(string),
+	count:     new(int),
+	threshold: new(string),
+	staged:    new(bool),
+}
+
+var benchFlagSet = &cliutil.FlagSet{
+	Name: "bench",
+	FlagDefs: []cliutil.FlagDef{
+		{
+			Name:     "bench",
+			Usage:    "Regexp of the benchmarks to run (defaults to the module's config, else all)",
+			Required: false,
+			Default:  "",
+			String:   benchOpts.bench,
+		},
+		{
+			Name:     "count",
+			Usage:    "Times to run each benchmark in each tree (defaults to the module's config, else 10)",
+			Required: false,
+			Default:  0,
+			Int:      benchOpts.count,
+		},
+		{
+			Name:     "threshold",
+			Usage:    "Smallest significant slowdown in percent to report as a regression (defaults to the module's config, else 5)",
+			Required: false,
+			Default:  "",
+			String:   benchOpts.threshold,
+		},
+		{
+			Name:     "staged",
+			Usage:    "Benchmark the staged changes instead of the working tree",
+			Required: false,
+			Default:  false,
+			Bool:     benchOpts.staged,
+		},
+	},
+}
+
+// BenchCmd compares a module's benchmarks at its latest tag against the
+// working tree
+type BenchCmd struct {
+	*cliutil.CmdBase
+}
+
+func init() {
+	err := cliutil.RegisterCommand(&BenchCmd{
+		CmdBase: cliutil.NewCmdBase(cliutil.CmdArgs{
+			Name:        "bench",
+			Usage:       "bench [<dir>] [--bench=<regexp>] [--count=<n>] [--threshold=<percent>] [--staged]",
+			Description: "Compare a module's benchmarks against its latest tag and report regressions",
+			FlagSets:    []*cliutil.FlagSet{benchFlagSet},
+			ArgDefs: []*cliutil.ArgDef{
+				{
+					Name:     "dir",
+					Usage:    "Directory of the module to benchmark (defaults to current directory)",
+					Required: false,
+					String:   benchOpts.dir,
+					Example:  "~/Projects/go-dt",
+				},
+			},
+		}),
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Handle executes the bench command
+func (c *BenchCmd) Handle() (err error) {
+	var result *gompkg.BenchResult
+	var threshold float64
+
+	ctx := context.Background()
+
+	if *benchOpts.threshold != "" {
+		threshold, err = strconv.ParseFloat(*benchOpts.threshold, 64)
+		if err != nil {
+			err = NewErr(ErrCommand, ErrInvalidFlags, "error", "invalid threshold", "threshold", *benchOpts.threshold, err)
+			goto end
+		}
+	}
+
+	result, err = gompkg.Bench(ctx, gompkg.BenchArgs{
+		ModuleDir: *benchOpts.dir,
+		Settings: gompkg.BenchConfig{
+			Benchmarks: *benchOpts.bench,
+			Count:      *benchOpts.count,
+			Threshold:  threshold,
+		},
+		Staged: *benchOpts.staged,
+		Config: c.Config.(*gompkg.Config),
+		Logger: c.Logger,
+		Writer: c.Writer,
+	})
+	if err != nil {
+		err = NewErr(ErrCommand, ErrBench, err)
+		goto end
+	}
+
+	gomcliui.DisplayBenchResult(result, c.Writer)
+
+end:
+	return err
+}
@@ -0,0 +1,105 @@
+package gomcmds
+
+import (
+	"context"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/gomion/gommod/gomcliui"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+)
+
+var _ cliutil.CommandHandler = (*BumpCmd)(nil)
+
+var bumpOpts = &struct {
+	dir     *string
+	version *string
+	noTidy  *bool
+	dryRun  *bool
+}{
+	dir:     new(string),
+	version: new(string),
+	noTidy:  new(bool),
+	dryRun:  new(bool),
+}
+
+var bumpFlagSet = &cliutil.FlagSet{
+	Name: "bump",
+	FlagDefs: []cliutil.FlagDef{
+		{
+			Name:     "version",
+			Usage:    "Version to require instead of the module's latest tag",
+			Required: false,
+			Default:  "",
+			String:   bumpOpts.version,
+		},
+		{
+			Name:     "no-tidy",
+			Usage:    "Do not run `go mod tidy` in bumped dependents",
+			Required: false,
+			Default:  false,
+			Bool:     bumpOpts.noTidy,
+		},
+		{
+			Name:     "dry-run",
+			Usage:    "Show which dependents would be bumped without editing them",
+			Required: false,
+			Default:  false,
+			Bool:     bumpOpts.dryRun,
+		},
+	},
+}
+
+// BumpCmd updates local dependents to require the latest release of a module
+type BumpCmd struct {
+	*cliutil.CmdBase
+}
+
+func init() {
+	err := cliutil.RegisterCommand(&BumpCmd{
+		CmdBase: cliutil.NewCmdBase(cliutil.CmdArgs{
+			Name:        "bump",
+			Usage:       "bump [<dir>] [--version=<version>] [--no-tidy] [--dry-run]",
+			Description: "Update dependents to require a module's latest release",
+			FlagSets:    []*cliutil.FlagSet{bumpFlagSet},
+			ArgDefs: []*cliutil.ArgDef{
+				{
+					Name:     "dir",
+					Usage:    "Directory of the released module (defaults to current directory)",
+					Required: false,
+					String:   bumpOpts.dir,
+					Example:  "~/Projects/go-dt",
+				},
+			},
+		}),
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Handle executes the bump command
+func (c *BumpCmd) Handle() (err error) {
+	var result *gompkg.BumpResult
+
+	ctx := context.Background()
+
+	result, err = gompkg.Bump(ctx, gompkg.BumpArgs{
+		ModuleDir: *bumpOpts.dir,
+		Version:   *bumpOpts.version,
+		NoTidy:    *bumpOpts.noTidy,
+		DryRun:    *bumpOpts.dryRun,
+		Config:    c.Config.(*gompkg.Config),
+		Logger:    c.Logger,
+		Writer:    c.Writer,
+	})
+	if result != nil && result.ModulePath != "" {
+		gomcliui.DisplayBumpResult(result, c.Writer)
+	}
+	if err != nil {
+		err = NewErr(ErrCommand, ErrBump, err)
+		goto end
+	}
+
+end:
+	return err
+}
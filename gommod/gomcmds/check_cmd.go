@@ -0,0 +1,101 @@
+package gomcmds
+
+import (
+	"context"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/gomion/gommod/gomcliui"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+)
+
+var _ cliutil.CommandHandler = (*CheckCmd)(nil)
+var _ cliutil.CommandHandler = (*CheckTagsCmd)(nil)
+
+var checkTagsOpts = &struct {
+	dir *string
+}{
+	dir: new(string),
+}
+
+// CheckCmd is the parent command for validators
+type CheckCmd struct {
+	*cliutil.CmdBase
+}
+
+// checkCmd is the package-level instance for child commands to reference
+var checkCmd = &CheckCmd{
+	CmdBase: cliutil.NewCmdBase(cliutil.CmdArgs{
+		Name:        "check",
+		Usage:       "check tags [<dir>]",
+		Description: "Validate managed repos",
+	}),
+}
+
+// CheckTagsCmd validates module tags against module directories and paths
+type CheckTagsCmd struct {
+	*cliutil.CmdBase
+}
+
+func init() {
+	err := cliutil.RegisterCommand(checkCmd)
+	if err != nil {
+		panic(err)
+	}
+
+	err = cliutil.RegisterCommand(&CheckTagsCmd{
+		CmdBase: cliutil.NewCmdBase(cliutil.CmdArgs{
+			Order:       1,
+			Name:        "tags",
+			Usage:       "tags [<dir>]",
+			Description: "Report orphaned, non-semver, wrong-module and major-mismatched tags across all scan dirs",
+			ArgDefs: []*cliutil.ArgDef{
+				{
+					Name:     "dir",
+					Usage:    "Directory to start from (defaults to current directory)",
+					Required: false,
+					String:   checkTagsOpts.dir,
+					Example:  "~/Projects/myrepo",
+				},
+			},
+		}),
+	}, checkCmd)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Handle executes the check command
+// This is a parent command that delegates to subcommands
+func (c *CheckCmd) Handle() (err error) {
+	c.Writer.Printf("Use 'check tags' to validate module tags\n")
+	return nil
+}
+
+// Handle executes the check tags command
+func (c *CheckTagsCmd) Handle() (err error) {
+	var result *gompkg.CheckTagsResult
+
+	ctx := context.Background()
+
+	result, err = gompkg.CheckTags(ctx, gompkg.CheckTagsArgs{
+		StartDir: *checkTagsOpts.dir,
+		RepoDirs: []string{}, // Use config scan_dirs
+		Config:   c.Config.(*gompkg.Config),
+		Logger:   c.Logger,
+		Writer:   c.Writer,
+	})
+	if err != nil {
+		err = NewErr(ErrCommand, ErrCheck, err)
+		goto end
+	}
+
+	gomcliui.DisplayCheckTagsResult(result, c.Writer)
+
+	// Fail so `check tags` can gate CI
+	if len(result.Problems) > 0 {
+		err = NewErr(ErrCommand, ErrCheck, gompkg.ErrInconsistentTags, "count", len(result.Problems))
+	}
+
+end:
+	return err
+}
@@ -0,0 +1,67 @@
+package gomcmds
+
+import (
+	"context"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/gomion/gommod/gomcliui"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+)
+
+var _ cliutil.CommandHandler = (*DependentsCmd)(nil)
+
+var dependentsOpts = &struct {
+	module *string
+}{
+	module: new(string),
+}
+
+// DependentsCmd lists the local modules that depend on a module
+type DependentsCmd struct {
+	*cliutil.CmdBase
+}
+
+func init() {
+	err := cliutil.RegisterCommand(&DependentsCmd{
+		CmdBase: cliutil.NewCmdBase(cliutil.CmdArgs{
+			Name:        "dependents",
+			Usage:       "dependents [<module-path|dir>]",
+			Description: "Show the local modules that depend on a module, directly or transitively",
+			ArgDefs: []*cliutil.ArgDef{
+				{
+					Name:     "module",
+					Usage:    "Module path, name or directory (defaults to the module in the current directory)",
+					Required: false,
+					String:   dependentsOpts.module,
+					Example:  "github.com/mikeschinkel/go-dt",
+				},
+			},
+		}),
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Handle executes the dependents command
+func (c *DependentsCmd) Handle() (err error) {
+	var result *gompkg.DependentsResult
+
+	ctx := context.Background()
+
+	result, err = gompkg.Dependents(ctx, gompkg.DependentsArgs{
+		Module: *dependentsOpts.module,
+		Config: c.Config.(*gompkg.Config),
+		Logger: c.Logger,
+		Writer: c.Writer,
+	})
+	if err != nil {
+		err = NewErr(ErrCommand, ErrDependents, err)
+		goto end
+	}
+
+	gomcliui.DisplayDependentsResult(result, c.Writer)
+
+end:
+	return err
+}
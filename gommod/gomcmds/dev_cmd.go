@@ -0,0 +1,172 @@
+package gomcmds
+
+import (
+	"context"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/gomion/gommod/gomcliui"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+)
+
+var _ cliutil.CommandHandler = (*DevCmd)(nil)
+var _ cliutil.CommandHandler = (*DevOnCmd)(nil)
+var _ cliutil.CommandHandler = (*DevOffCmd)(nil)
+
+var devOpts = &struct {
+	work    *bool
+	modules *[]string
+}{
+	work:    new(bool),
+	modules: new([]string),
+}
+
+var devFlagSet = &cliutil.FlagSet{
+	Name: "dev",
+	FlagDefs: []cliutil.FlagDef{
+		{
+			Name:     "work",
+			Usage:    "Manage go.work `use` entries instead of replace directives",
+			Required: false,
+			Default:  false,
+			Bool:     devOpts.work,
+		},
+	},
+}
+
+// devModulesArgDef is shared by `dev on` and `dev off`; cliutil assigns one
+// positional value per ArgDef so assignDevModules collects the rest
+var devModulesArgDef = &cliutil.ArgDef{
+	Name:     "modules",
+	Usage:    "Module paths, names or dirs to limit to (defaults to all local dependencies)",
+	Required: false,
+	Example:  "go-dt go-cliutil",
+}
+
+// DevCmd is the parent command for toggling dev mode
+type DevCmd struct {
+	*cliutil.CmdBase
+}
+
+// devCmd is the package-level instance for child commands to reference
+var devCmd = &DevCmd{
+	CmdBase: cliutil.NewCmdBase(cliutil.CmdArgs{
+		Name:        "dev",
+		Usage:       "dev on|off [<modules>...] [--work]",
+		Description: "Toggle local replace directives or go.work for local dependencies",
+	}),
+}
+
+// DevOnCmd points the current repo's modules at local dependency checkouts
+type DevOnCmd struct {
+	*cliutil.CmdBase
+}
+
+// DevOffCmd removes what `dev on` added
+type DevOffCmd struct {
+	*cliutil.CmdBase
+}
+
+func init() {
+	err := cliutil.RegisterCommand(devCmd)
+	if err != nil {
+		panic(err)
+	}
+
+	err = cliutil.RegisterCommand(&DevOnCmd{
+		CmdBase: cliutil.NewCmdBase(cliutil.CmdArgs{
+			Order:       1,
+			Name:        "on",
+			Usage:       "on [<modules>...] [--work]",
+			Description: "Add local replace directives (or go.work uses) for local dependencies",
+			FlagSets:    []*cliutil.FlagSet{devFlagSet},
+			ArgDefs:     []*cliutil.ArgDef{devModulesArgDef},
+		}),
+	}, devCmd)
+	if err != nil {
+		panic(err)
+	}
+
+	err = cliutil.RegisterCommand(&DevOffCmd{
+		CmdBase: cliutil.NewCmdBase(cliutil.CmdArgs{
+			Order:       2,
+			Name:        "off",
+			Usage:       "off [<modules>...]",
+			Description: "Remove the replace directives and go.work uses added by `dev on`",
+			ArgDefs:     []*cliutil.ArgDef{devModulesArgDef},
+		}),
+	}, devCmd)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Handle executes the dev command
+// This is a parent command that delegates to subcommands
+func (c *DevCmd) Handle() (err error) {
+	c.Writer.Printf("Use 'dev on' or 'dev off' to toggle dev mode\n")
+	return nil
+}
+
+// AssignArgs collects every positional argument as a module
+func (c *DevOnCmd) AssignArgs(args []string) (err error) {
+	return assignDevModules(c.CmdBase, args)
+}
+
+// AssignArgs collects every positional argument as a module
+func (c *DevOffCmd) AssignArgs(args []string) (err error) {
+	return assignDevModules(c.CmdBase, args)
+}
+
+// assignDevModules validates args against devModulesArgDef and then keeps all
+// of them, since `dev on` and `dev off` take any number of modules
+func assignDevModules(c *cliutil.CmdBase, args []string) (err error) {
+	err = c.AssignArgs(args)
+	if err != nil {
+		goto end
+	}
+	*devOpts.modules = args
+
+end:
+	return err
+}
+
+// Handle executes the dev on command
+func (c *DevOnCmd) Handle() (err error) {
+	var result *gompkg.DevModeResult
+
+	result, err = gompkg.DevOn(context.Background(), devModeArgs(c.CmdBase))
+	if result != nil && result.RepoDir != "" {
+		gomcliui.DisplayDevModeResult(result, true, c.Writer)
+	}
+	if err != nil {
+		err = NewErr(ErrCommand, ErrDev, err)
+	}
+	return err
+}
+
+// Handle executes the dev off command
+func (c *DevOffCmd) Handle() (err error) {
+	var result *gompkg.DevModeResult
+
+	result, err = gompkg.DevOff(context.Background(), devModeArgs(c.CmdBase))
+	if result != nil && result.RepoDir != "" {
+		gomcliui.DisplayDevModeResult(result, false, c.Writer)
+	}
+	if err != nil {
+		err = NewErr(ErrCommand, ErrDev, err)
+	}
+	return err
+}
+
+// devModeArgs builds the DevModeArgs shared by `dev on` and `dev off`
+func devModeArgs(c *cliutil.CmdBase) (args gompkg.DevModeArgs) {
+	args = gompkg.DevModeArgs{
+		StartDir: ".",
+		UseWork:  *devOpts.work,
+		Config:   c.Config.(*gompkg.Config),
+		Logger:   c.Logger,
+		Writer:   c.Writer,
+		Modules:  *devOpts.modules,
+	}
+	return args
+}
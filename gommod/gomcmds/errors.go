@@ -6,13 +6,26 @@ import (
 
 // Layer sentinels
 var (
-	ErrCommand  = errors.New("command")
-	ErrScan     = errors.New("scan")
-	ErrInit     = errors.New("init")
-	ErrRequires = errors.New("requires")
-	ErrTree     = errors.New("tree")
-	ErrProject  = errors.New("project")
-	ErrModspec  = errors.New("modspec")
+	ErrCommand    = errors.New("command")
+	ErrScan       = errors.New("scan")
+	ErrInit       = errors.New("init")
+	ErrRequires   = errors.New("requires")
+	ErrTree       = errors.New("tree")
+	ErrProject    = errors.New("project")
+	ErrModspec    = errors.New("modspec")
+	ErrRelease    = errors.New("release")
+	ErrBump       = errors.New("bump")
+	ErrDev        = errors.New("dev")
+	ErrNotes      = errors.New("notes")
+	ErrMajor      = errors.New("major")
+	ErrBench      = errors.New("bench")
+	ErrRetract    = errors.New("retract")
+	ErrCheck      = errors.New("check")
+	ErrGraph      = errors.New("graph")
+	ErrDependents = errors.New("dependents")
+	ErrTest       = errors.New("test")
+	ErrRun        = errors.New("run")
+	ErrWorkspace  = errors.New("workspace")
 )
 
 // Category sentinels
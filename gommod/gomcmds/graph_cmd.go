@@ -0,0 +1,201 @@
+package gomcmds
+
+import (
+	"context"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+)
+
+var _ cliutil.CommandHandler = (*GraphCmd)(nil)
+
+var graphOpts = &struct {
+	dir       *string
+	format    *string
+	showDirs  *bool
+	showPaths *bool
+	showAll   *bool
+	embedFile *string
+	before    *bool
+	after     *bool
+}{
+	dir:       new(string),
+	format:    new(string),
+	showDirs:  new(bool),
+	showPaths: new(bool),
+	showAll:   new(bool),
+	embedFile: new(string),
+	before:    new(bool),
+	after:     new(bool),
+}
+
+var graphFlagSet = &cliutil.FlagSet{
+	Name: "graph",
+	FlagDefs: []cliutil.FlagDef{
+		{
+			Name:     "format",
+			Usage:    "Output format (ascii, dot, mermaid, json)",
+			Required: false,
+			Default:  string(gompkg.AsciiOutputFormat),
+			String:   graphOpts.format,
+		},
+		{
+			Name:     "show-dirs",
+			Usage:    "Label modules with their relative directory",
+			Required: false,
+			Default:  false,
+			Bool:     graphOpts.showDirs,
+		},
+		{
+			Name:     "show-paths",
+			Usage:    "Label modules with their full module path instead of short name",
+			Required: false,
+			Default:  false,
+			Bool:     graphOpts.showPaths,
+		},
+		{
+			Name:     "show-all",
+			Usage:    "Label modules with both module path and location",
+			Required: false,
+			Default:  false,
+			Bool:     graphOpts.showAll,
+		},
+		{
+			Name:     "embed",
+			Usage:    "Markdown file with a <!-- gomion:embed-requires-tree --> marker to embed the graph into",
+			Required: false,
+			Default:  "",
+			String:   graphOpts.embedFile,
+		},
+		{
+			Name:     "before",
+			Usage:    "Embed the graph before the marker (requires --embed)",
+			Required: false,
+			Default:  false,
+			Bool:     graphOpts.before,
+		},
+		{
+			Name:     "after",
+			Usage:    "Embed the graph after the marker (requires --embed)",
+			Required: false,
+			Default:  false,
+			Bool:     graphOpts.after,
+		},
+	},
+}
+
+// GraphCmd renders the managed modules' dependency graph
+type GraphCmd struct {
+	*cliutil.CmdBase
+}
+
+func init() {
+	err := cliutil.RegisterCommand(&GraphCmd{
+		CmdBase: cliutil.NewCmdBase(cliutil.CmdArgs{
+			Name:        "graph",
+			Usage:       "graph [<dir>] [--format=<format>] [--show-dirs|--show-paths|--show-all] [--embed=<file> --before|--after]",
+			Description: "Render the module dependency graph as ASCII, DOT, Mermaid or JSON, highlighting in-flux modules",
+			FlagSets:    []*cliutil.FlagSet{graphFlagSet},
+			ArgDefs: []*cliutil.ArgDef{
+				{
+					Name:     "dir",
+					Usage:    "Directory to start from (defaults to current directory)",
+					Required: false,
+					String:   graphOpts.dir,
+					Example:  "~/Projects/myrepo",
+				},
+			},
+		}),
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Handle executes the graph command
+func (c *GraphCmd) Handle() (err error) {
+	var result *gompkg.GraphResult
+	var format gompkg.OutputFormat
+
+	ctx := context.Background()
+
+	err = validateGraphFlags()
+	if err != nil {
+		goto end
+	}
+
+	format = gompkg.OutputFormat(*graphOpts.format)
+	switch format {
+	case "":
+		format = gompkg.AsciiOutputFormat
+	case gompkg.AsciiOutputFormat, gompkg.DotOutputFormat, gompkg.MermaidOutputFormat, gompkg.JSONOutputFormat:
+	default:
+		err = NewErr(ErrCommand, ErrInvalidFlags, "error", "invalid format", "format", format)
+		goto end
+	}
+
+	result, err = gompkg.Graph(ctx, gompkg.GraphArgs{
+		StartDir: *graphOpts.dir,
+		Format:   format,
+		Tree: gompkg.TreeOptions{
+			ShowDirs:  *graphOpts.showDirs,
+			ShowPaths: *graphOpts.showPaths,
+			ShowAll:   *graphOpts.showAll,
+		},
+		Config: c.Config.(*gompkg.Config),
+		Logger: c.Logger,
+		Writer: c.Writer,
+	})
+	if err != nil {
+		err = NewErr(ErrCommand, ErrGraph, err)
+		goto end
+	}
+
+	if *graphOpts.embedFile == "" {
+		c.Writer.Printf("%s", result.Output)
+		goto end
+	}
+
+	err = gompkg.EmbedGraph(dt.Filepath(*graphOpts.embedFile), result.Output, graphEmbedLang(format), *graphOpts.before)
+	if err != nil {
+		err = NewErr(ErrCommand, ErrGraph, ErrFileWrite, "file", *graphOpts.embedFile, err)
+		goto end
+	}
+	c.Writer.Printf("Graph embedded into %s\n", *graphOpts.embedFile)
+
+end:
+	return err
+}
+
+// graphEmbedLang returns the markdown code block language for format
+func graphEmbedLang(format gompkg.OutputFormat) string {
+	switch format {
+	case gompkg.DotOutputFormat:
+		return "dot"
+	case gompkg.MermaidOutputFormat:
+		return "mermaid"
+	case gompkg.JSONOutputFormat:
+		return "json"
+	default:
+		return "text"
+	}
+}
+
+// validateGraphFlags checks that --before and --after are used with --embed
+// and that exactly one of them is
+func validateGraphFlags() (err error) {
+	hasEmbed := *graphOpts.embedFile != ""
+	hasBefore := *graphOpts.before
+	hasAfter := *graphOpts.after
+
+	switch {
+	case !hasEmbed && (hasBefore || hasAfter):
+		err = NewErr(ErrCommand, ErrGraph, ErrInvalidFlags, "error", "--before and --after require --embed")
+	case hasEmbed && !hasBefore && !hasAfter:
+		err = NewErr(ErrCommand, ErrGraph, ErrInvalidFlags, "error", "--embed requires either --before or --after")
+	case hasBefore && hasAfter:
+		err = NewErr(ErrCommand, ErrGraph, ErrInvalidFlags, "error", "--before and --after are mutually exclusive")
+	}
+	return err
+}
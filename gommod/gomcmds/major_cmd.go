@@ -0,0 +1,104 @@
+package gomcmds
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/gomion/gommod/gomcliui"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+)
+
+var _ cliutil.CommandHandler = (*MajorCmd)(nil)
+
+var majorOpts = &struct {
+	dir   *string
+	to    *string
+	write *bool
+}{
+	dir:   new(string),
+	to:    new(string),
+	write: new(bool),
+}
+
+var majorFlagSet = &cliutil.FlagSet{
+	Name: "major",
+	FlagDefs: []cliutil.FlagDef{
+		{
+			Name:     "to",
+			Usage:    "Major version to migrate to (defaults to the next major version)",
+			Required: false,
+			Default:  "",
+			String:   majorOpts.to,
+		},
+		{
+			Name:     "write",
+			Usage:    "Apply the changes instead of only previewing them",
+			Required: false,
+			Default:  false,
+			Bool:     majorOpts.write,
+		},
+	},
+}
+
+// MajorCmd migrates a module and its local dependents to a new /vN module path
+type MajorCmd struct {
+	*cliutil.CmdBase
+}
+
+func init() {
+	err := cliutil.RegisterCommand(&MajorCmd{
+		CmdBase: cliutil.NewCmdBase(cliutil.CmdArgs{
+			Name:        "major",
+			Usage:       "major [<dir>] [--to=<major>] [--write]",
+			Description: "Migrate a module and its local dependents to a new /vN major version path",
+			FlagSets:    []*cliutil.FlagSet{majorFlagSet},
+			ArgDefs: []*cliutil.ArgDef{
+				{
+					Name:     "dir",
+					Usage:    "Directory of the module to migrate (defaults to current directory)",
+					Required: false,
+					String:   majorOpts.dir,
+					Example:  "~/Projects/go-dt",
+				},
+			},
+		}),
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Handle executes the major command
+func (c *MajorCmd) Handle() (err error) {
+	var result *gompkg.MajorResult
+	var major int
+
+	ctx := context.Background()
+
+	if *majorOpts.to != "" {
+		major, err = strconv.Atoi(*majorOpts.to)
+		if err != nil {
+			err = NewErr(ErrCommand, ErrInvalidFlags, "error", "invalid major version", "to", *majorOpts.to, err)
+			goto end
+		}
+	}
+
+	result, err = gompkg.Major(ctx, gompkg.MajorArgs{
+		ModuleDir: *majorOpts.dir,
+		Major:     major,
+		Write:     *majorOpts.write,
+		Config:    c.Config.(*gompkg.Config),
+		Logger:    c.Logger,
+		Writer:    c.Writer,
+	})
+	if err != nil {
+		err = NewErr(ErrCommand, ErrMajor, err)
+		goto end
+	}
+
+	gomcliui.DisplayMajorResult(result, c.Writer)
+
+end:
+	return err
+}
@@ -13,9 +13,51 @@ import (
 var _ cliutil.CommandHandler = (*NextCmd)(nil)
 
 var nextOpts = &struct {
-	dir *string
+	dir         *string
+	all         *bool
+	format      *string
+	workspace   *string
+	concurrency *int
 }{
-	dir: new(string),
+	dir:         new(string),
+	all:         new(bool),
+	format:      new(string),
+	workspace:   new(string),
+	concurrency: new(int),
+}
+
+var nextFlagSet = &cliutil.FlagSet{
+	Name: "next",
+	FlagDefs: []cliutil.FlagDef{
+		{
+			Name:     "all",
+			Usage:    "Show every in-flux module grouped into parallel release waves",
+			Required: false,
+			Default:  false,
+			Bool:     nextOpts.all,
+		},
+		{
+			Name:     "format",
+			Usage:    "Output format for --all (table, json)",
+			Required: false,
+			Default:  string(gompkg.TableOutputFormat),
+			String:   nextOpts.format,
+		},
+		workspaceFlagDef(nextOpts.workspace),
+		concurrencyFlagDef(nextOpts.concurrency),
+	},
+}
+
+// concurrencyFlagDef returns the --concurrency bound shared by the commands
+// that run the release engine
+func concurrencyFlagDef(value *int) cliutil.FlagDef {
+	return cliutil.FlagDef{
+		Name:     "concurrency",
+		Usage:    "Most repos to check for in-flux status at once (defaults to the config's concurrency, then the number of CPUs)",
+		Required: false,
+		Default:  0,
+		Int:      value,
+	}
 }
 
 // emptyModeState is a dummy ModeState implementation
@@ -33,7 +75,9 @@ func init() {
 	err := cliutil.RegisterCommand(&NextCmd{
 		CmdBase: cliutil.NewCmdBase(cliutil.CmdArgs{
 			Name:        "next",
+			Usage:       "next [<dir>] [--all] [--format=<format>] [--workspace=<name>] [--concurrency=<n>]",
 			Description: "Determine next Go module to tackle",
+			FlagSets:    []*cliutil.FlagSet{nextFlagSet},
 			ArgDefs: []*cliutil.ArgDef{
 				{
 					Name:     "dir",
@@ -57,16 +101,48 @@ func (c *NextCmd) Handle() (err error) {
 	var startDir string
 	var startDirPath dt.DirPath
 	var engine *gompkg.ReleaseEngine
+	var format gompkg.OutputFormat
+	var ws *gompkg.Workspace
+	var wsStatus *gompkg.WorkspaceStatus
 
 	ctx := context.Background()
 	config = c.Config.(*gompkg.Config)
 
+	format = gompkg.OutputFormat(*nextOpts.format)
+	if format == "" {
+		format = gompkg.TableOutputFormat
+	}
+	if format != gompkg.TableOutputFormat && format != gompkg.JSONOutputFormat {
+		err = NewErr(ErrCommand, ErrInvalidFlags, "error", "invalid format", "format", format)
+		goto end
+	}
+
 	// Determine starting directory for display
 	startDir = *nextOpts.dir
 	if startDir == "" {
 		startDir = "."
 	}
 
+	// Scan only the workspace's repos, starting in one of them
+	config, ws, err = config.ForWorkspace(*nextOpts.workspace)
+	if err != nil {
+		goto end
+	}
+	if ws != nil {
+		startDir, err = ws.StartDir(startDir)
+		if err != nil {
+			goto end
+		}
+	}
+	// Warn first so the warnings do not break JSON output
+	if ws != nil && format == gompkg.TableOutputFormat {
+		wsStatus, err = gompkg.InspectWorkspace(ws)
+		if err != nil {
+			goto end
+		}
+		gomcliui.DisplayWorkspaceWarnings(wsStatus, c.Writer)
+	}
+
 	// Resolve to absolute path for display
 	startDirPath, err = dt.ParseDirPath(startDir)
 	if err != nil {
@@ -79,11 +155,13 @@ func (c *NextCmd) Handle() (err error) {
 
 	// Create and run the release engine (silent mode - no streaming)
 	engine = gompkg.NewReleaseEngine(gompkg.EngineArgs{
-		StartDir: *nextOpts.dir,
-		RepoDirs: []string{}, // Use config scan_dirs
-		Config:   config,
-		Logger:   c.Logger,
-		Writer:   c.Writer,
+		StartDir:    startDir,
+		RepoDirs:    []string{}, // Use config scan_dirs
+		Config:      config,
+		Logger:      c.Logger,
+		Writer:      c.Writer,
+		AllWaves:    *nextOpts.all,
+		Concurrency: *nextOpts.concurrency,
 	})
 
 	result, err = engine.Run(ctx)
@@ -91,6 +169,11 @@ func (c *NextCmd) Handle() (err error) {
 		goto end
 	}
 
+	if *nextOpts.all {
+		gomcliui.DisplayReleaseWaves(result.Waves, format, c.Writer)
+		goto end
+	}
+
 	// Display human-friendly output
 	gomcliui.DisplayNextResult(startDirPath, result, c.Writer)
 
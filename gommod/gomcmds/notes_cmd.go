@@ -0,0 +1,114 @@
+package gomcmds
+
+import (
+	"context"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/gomion/gommod/gomcliui"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+)
+
+var _ cliutil.CommandHandler = (*NotesCmd)(nil)
+
+var notesOpts = &struct {
+	module  *string
+	version *string
+	write   *bool
+	format  *string
+}{
+	module:  new(string),
+	version: new(string),
+	write:   new(bool),
+	format:  new(string),
+}
+
+var notesFlagSet = &cliutil.FlagSet{
+	Name: "notes",
+	FlagDefs: []cliutil.FlagDef{
+		{
+			Name:     "version",
+			Usage:    "Version to title the notes with (defaults to Unreleased)",
+			Required: false,
+			Default:  "",
+			String:   notesOpts.version,
+		},
+		{
+			Name:     "write",
+			Usage:    "Add or update the version's section in the module's CHANGELOG.md",
+			Required: false,
+			Default:  false,
+			Bool:     notesOpts.write,
+		},
+		{
+			Name:     "format",
+			Usage:    "Output format (markdown, json)",
+			Required: false,
+			Default:  string(gompkg.MarkdownOutputFormat),
+			String:   notesOpts.format,
+		},
+	},
+}
+
+// NotesCmd generates release notes for a module from its commits since the latest tag
+type NotesCmd struct {
+	*cliutil.CmdBase
+}
+
+func init() {
+	err := cliutil.RegisterCommand(&NotesCmd{
+		CmdBase: cliutil.NewCmdBase(cliutil.CmdArgs{
+			Name:        "notes",
+			Usage:       "notes [<module>] [--version=<version>] [--write] [--format=<format>]",
+			Description: "Generate release notes and changelog entries for a module",
+			FlagSets:    []*cliutil.FlagSet{notesFlagSet},
+			ArgDefs: []*cliutil.ArgDef{
+				{
+					Name:     "module",
+					Usage:    "Module path, name or directory (defaults to the module in the current directory)",
+					Required: false,
+					String:   notesOpts.module,
+					Example:  "gommod",
+				},
+			},
+		}),
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Handle executes the notes command
+func (c *NotesCmd) Handle() (err error) {
+	var notes *gompkg.ReleaseNotes
+	var format gompkg.OutputFormat
+
+	ctx := context.Background()
+
+	format = gompkg.OutputFormat(*notesOpts.format)
+	if format == "" {
+		format = gompkg.MarkdownOutputFormat
+	}
+	if format != gompkg.MarkdownOutputFormat && format != gompkg.JSONOutputFormat {
+		err = NewErr(ErrCommand, ErrInvalidFlags, "error", "invalid format", "format", format)
+		goto end
+	}
+
+	notes, err = gompkg.ReleaseNotesFor(ctx, gompkg.NotesArgs{
+		StartDir: ".",
+		Module:   *notesOpts.module,
+		Version:  *notesOpts.version,
+		Write:    *notesOpts.write,
+		Config:   c.Config.(*gompkg.Config),
+		Logger:   c.Logger,
+		Writer:   c.Writer,
+	})
+	if err != nil {
+		err = NewErr(ErrCommand, ErrNotes, err)
+		goto end
+	}
+
+	gomcliui.DisplayReleaseNotes(notes, format, c.Writer)
+
+end:
+	return err
+}
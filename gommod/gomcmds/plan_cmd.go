@@ -2,15 +2,25 @@ package gomcmds
 
 import (
 	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/gomion/gommod/gomcliui"
 	"github.com/mikeschinkel/gomion/gommod/gompkg"
 )
 
 var _ cliutil.CommandHandler = (*PlanCmd)(nil)
 
 var planOpts = &struct {
-	dir *string
+	dir       *string
+	workspace *string
 }{
-	dir: new(string),
+	dir:       new(string),
+	workspace: new(string),
+}
+
+var planFlagSet = &cliutil.FlagSet{
+	Name: "plan",
+	FlagDefs: []cliutil.FlagDef{
+		workspaceFlagDef(planOpts.workspace),
+	},
 }
 
 // PlanCmd displays the dependency graph for inspection
@@ -23,6 +33,7 @@ func init() {
 		CmdBase: cliutil.NewCmdBase(cliutil.CmdArgs{
 			Name:        "plan",
 			Description: "Display module dependency graph",
+			FlagSets:    []*cliutil.FlagSet{planFlagSet},
 			ArgDefs: []*cliutil.ArgDef{
 				{
 					Name:     "dir",
@@ -42,10 +53,32 @@ func init() {
 // Handle executes the plan command
 func (c *PlanCmd) Handle() (err error) {
 	var result *gompkg.PlanResult
+	var config *gompkg.Config
+	var ws *gompkg.Workspace
+	var wsStatus *gompkg.WorkspaceStatus
+
+	startDir := *planOpts.dir
+
+	// Scan only the workspace's repos, starting in one of them
+	config, ws, err = c.Config.(*gompkg.Config).ForWorkspace(*planOpts.workspace)
+	if err != nil {
+		goto end
+	}
+	if ws != nil {
+		startDir, err = ws.StartDir(startDir)
+		if err != nil {
+			goto end
+		}
+		wsStatus, err = gompkg.InspectWorkspace(ws)
+		if err != nil {
+			goto end
+		}
+		gomcliui.DisplayWorkspaceWarnings(wsStatus, c.Writer)
+	}
 
 	// Run the plan command (original graph display)
-	result, err = gompkg.Plan(*planOpts.dir, gompkg.PlanArgs{
-		Config: c.Config.(*gompkg.Config),
+	result, err = gompkg.Plan(startDir, gompkg.PlanArgs{
+		Config: config,
 		Logger: c.Logger,
 		Writer: c.Writer,
 	})
@@ -0,0 +1,148 @@
+package gomcmds
+
+import (
+	"context"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/gomion/gommod/gomcliui"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+)
+
+var _ cliutil.CommandHandler = (*ReleaseCmd)(nil)
+
+var releaseOpts = &struct {
+	dir               *string
+	version           *string
+	pre               *string
+	ignorePrereleases *bool
+	dryRun            *bool
+	verifyProxy       *string
+	resume            *bool
+	abort             *bool
+	concurrency       *int
+}{
+	dir:               new(string),
+	version:           new(string),
+	pre:               new(string),
+	ignorePrereleases: new(bool),
+	dryRun:            new(bool),
+	verifyProxy:       new(string),
+	resume:            new(bool),
+	abort:             new(bool),
+	concurrency:       new(int),
+}
+
+var releaseFlagSet = &cliutil.FlagSet{
+	Name: "release",
+	FlagDefs: []cliutil.FlagDef{
+		{
+			Name:     "version",
+			Usage:    "Version to release instead of the one proposed from the verdict",
+			Required: false,
+			Default:  "",
+			String:   releaseOpts.version,
+		},
+		{
+			Name:     "pre",
+			Usage:    "Cut the next prerelease in the named series (e.g. rc, beta) instead of a final release",
+			Required: false,
+			Default:  "",
+			String:   releaseOpts.pre,
+		},
+		{
+			Name:     "ignore-prereleases",
+			Usage:    "Use the latest final release as the baseline, ignoring later prerelease tags (config: ignore_prereleases)",
+			Required: false,
+			Default:  false,
+			Bool:     releaseOpts.ignorePrereleases,
+		},
+		{
+			Name:     "dry-run",
+			Usage:    "Show the tag that would be created and pushed without doing either",
+			Required: false,
+			Default:  false,
+			Bool:     releaseOpts.dryRun,
+		},
+		{
+			Name:     "verify-proxy",
+			Usage:    "GOPROXY the released version must resolve through; 'local' for a proxy built from the repo, 'off' to skip",
+			Required: false,
+			Default:  gompkg.VerifyProxyLocal,
+			String:   releaseOpts.verifyProxy,
+		},
+		{
+			Name:     "resume",
+			Usage:    "Continue an interrupted release from its last completed step",
+			Required: false,
+			Default:  false,
+			Bool:     releaseOpts.resume,
+		},
+		{
+			Name:     "abort",
+			Usage:    "Roll back an interrupted release, deleting its tag and tidy commit if not yet pushed",
+			Required: false,
+			Default:  false,
+			Bool:     releaseOpts.abort,
+		},
+		concurrencyFlagDef(releaseOpts.concurrency),
+	},
+}
+
+// ReleaseCmd tags and pushes the leaf module then shows the next leaf
+type ReleaseCmd struct {
+	*cliutil.CmdBase
+}
+
+func init() {
+	err := cliutil.RegisterCommand(&ReleaseCmd{
+		CmdBase: cliutil.NewCmdBase(cliutil.CmdArgs{
+			Name:        "release",
+			Usage:       "release [<dir>] [--version=<version>] [--pre=<label>] [--ignore-prereleases] [--dry-run] [--verify-proxy=<proxy>] [--resume|--abort] [--concurrency=<n>]",
+			Description: "Tag and push the next Go module to release",
+			FlagSets:    []*cliutil.FlagSet{releaseFlagSet},
+			ArgDefs: []*cliutil.ArgDef{
+				{
+					Name:     "dir",
+					Usage:    "Directory to start from (defaults to current directory)",
+					Required: false,
+					String:   releaseOpts.dir,
+					Example:  "~/Projects/myrepo",
+				},
+			},
+		}),
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Handle executes the release command
+func (c *ReleaseCmd) Handle() (err error) {
+	var result *gompkg.ReleaseResult
+
+	ctx := context.Background()
+
+	result, err = gompkg.Release(ctx, gompkg.ReleaseArgs{
+		StartDir:          *releaseOpts.dir,
+		Version:           *releaseOpts.version,
+		Prerelease:        *releaseOpts.pre,
+		IgnorePrereleases: *releaseOpts.ignorePrereleases,
+		DryRun:            *releaseOpts.dryRun,
+		VerifyProxy:       *releaseOpts.verifyProxy,
+		Resume:            *releaseOpts.resume,
+		Abort:             *releaseOpts.abort,
+		Concurrency:       *releaseOpts.concurrency,
+		Config:            c.Config.(*gompkg.Config),
+		Logger:            c.Logger,
+		Writer:            c.Writer,
+	})
+	if err != nil {
+		err = NewErr(ErrCommand, ErrRelease, err)
+		goto end
+	}
+
+	gomcliui.DisplayReleaseResult(result, c.Writer)
+
+end:
+	return err
+}
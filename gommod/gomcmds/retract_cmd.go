@@ -0,0 +1,94 @@
+package gomcmds
+
+import (
+	"context"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/gomion/gommod/gomcliui"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+)
+
+var _ cliutil.CommandHandler = (*RetractCmd)(nil)
+
+var retractOpts = &struct {
+	module   *string
+	versions *string
+	reason   *string
+}{
+	module:   new(string),
+	versions: new(string),
+	reason:   new(string),
+}
+
+var retractFlagSet = &cliutil.FlagSet{
+	Name: "retract",
+	FlagDefs: []cliutil.FlagDef{
+		{
+			Name:     "reason",
+			Usage:    "Why the versions are retracted; shown by `go list -m -retracted` and in the release notes",
+			Required: true,
+			Default:  "",
+			String:   retractOpts.reason,
+		},
+	},
+}
+
+// RetractCmd adds a retract directive to a module's go.mod
+type RetractCmd struct {
+	*cliutil.CmdBase
+}
+
+func init() {
+	err := cliutil.RegisterCommand(&RetractCmd{
+		CmdBase: cliutil.NewCmdBase(cliutil.CmdArgs{
+			Name:        "retract",
+			Usage:       "retract <module> <version|[low, high]> --reason=<reason>",
+			Description: "Retract published versions of a module in its go.mod",
+			FlagSets:    []*cliutil.FlagSet{retractFlagSet},
+			ArgDefs: []*cliutil.ArgDef{
+				{
+					Name:     "module",
+					Usage:    "Module path, name or directory",
+					Required: true,
+					String:   retractOpts.module,
+					Example:  "gommod",
+				},
+				{
+					Name:     "version",
+					Usage:    "Version or \"[low, high]\" interval to retract",
+					Required: true,
+					String:   retractOpts.versions,
+					Example:  "v1.2.3",
+				},
+			},
+		}),
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Handle executes the retract command
+func (c *RetractCmd) Handle() (err error) {
+	var result *gompkg.RetractResult
+
+	ctx := context.Background()
+
+	result, err = gompkg.Retract(ctx, gompkg.RetractArgs{
+		Module:   *retractOpts.module,
+		Versions: *retractOpts.versions,
+		Reason:   *retractOpts.reason,
+		Config:   c.Config.(*gompkg.Config),
+		Logger:   c.Logger,
+		Writer:   c.Writer,
+	})
+	if err != nil {
+		err = NewErr(ErrCommand, ErrRetract, err)
+		goto end
+	}
+
+	gomcliui.DisplayRetractResult(result, c.Writer)
+
+end:
+	return err
+}
@@ -0,0 +1,155 @@
+package gomcmds
+
+import (
+	"context"
+	"slices"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gomcliui"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+)
+
+var _ cliutil.CommandHandler = (*RunCmd)(nil)
+
+var runOpts = &struct {
+	task   *string
+	dir    *string
+	jobs   *int
+	format *string
+	report *string
+}{
+	task:   new(string),
+	dir:    new(string),
+	jobs:   new(int),
+	format: new(string),
+	report: new(string),
+}
+
+var runFlagSet = &cliutil.FlagSet{
+	Name: "run",
+	FlagDefs: []cliutil.FlagDef{
+		{
+			Name:     "jobs",
+			Usage:    "Most modules to run at once (defaults to the number of CPUs)",
+			Required: false,
+			Default:  0,
+			Int:      runOpts.jobs,
+		},
+		{
+			Name:     "format",
+			Usage:    "Summary format (table, json)",
+			Required: false,
+			Default:  string(gompkg.TableOutputFormat),
+			String:   runOpts.format,
+		},
+		{
+			Name:     "report",
+			Usage:    "File to write the JSON report to",
+			Required: false,
+			Default:  "",
+			String:   runOpts.report,
+		},
+	},
+}
+
+// RunCmd runs a go command across the module dependency tree
+type RunCmd struct {
+	*cliutil.CmdBase
+}
+
+func init() {
+	err := cliutil.RegisterCommand(&RunCmd{
+		CmdBase: cliutil.NewCmdBase(cliutil.CmdArgs{
+			Name:        "run",
+			Usage:       "run <test|vet|build|lint> [<dir>] [--jobs=<n>] [--format=<format>] [--report=<file>]",
+			Description: "Run tests, vet, build or lint in every module of the dependency tree, dependencies first",
+			FlagSets:    []*cliutil.FlagSet{runFlagSet},
+			ArgDefs: []*cliutil.ArgDef{
+				{
+					Name:     "task",
+					Usage:    "What to run: test, vet, build or lint",
+					Required: true,
+					String:   runOpts.task,
+					Example:  "test",
+				},
+				{
+					Name:     "dir",
+					Usage:    "Directory to start from (defaults to current directory)",
+					Required: false,
+					String:   runOpts.dir,
+					Example:  "~/Projects/myrepo",
+				},
+			},
+		}),
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Handle executes the run command
+func (c *RunCmd) Handle() (err error) {
+	var result *gompkg.RunResult
+	var format gompkg.OutputFormat
+	var hook gompkg.StreamingHook
+
+	ctx := context.Background()
+
+	if !slices.Contains(gompkg.RunTasks, gompkg.RunTask(*runOpts.task)) {
+		err = NewErr(ErrCommand, ErrInvalidFlags, "error", "task must be test, vet, build or lint", "task", *runOpts.task)
+		goto end
+	}
+
+	format = gompkg.OutputFormat(*runOpts.format)
+	switch format {
+	case "", gompkg.TableOutputFormat:
+		format = gompkg.TableOutputFormat
+		hook = func(message string) {
+			c.Writer.Printf("%s\n", message)
+		}
+	case gompkg.JSONOutputFormat:
+		// Keep stdout for the report
+		hook = func(message string) {
+			c.Writer.Errorf("%s\n", message)
+		}
+	default:
+		err = NewErr(ErrCommand, ErrInvalidFlags, "error", "invalid format", "format", format)
+		goto end
+	}
+
+	result, err = gompkg.RunModules(ctx, gompkg.RunArgs{
+		StartDir: *runOpts.dir,
+		Task:     gompkg.RunTask(*runOpts.task),
+		Jobs:     *runOpts.jobs,
+		Hook:     hook,
+		Config:   c.Config.(*gompkg.Config),
+		Logger:   c.Logger,
+		Writer:   c.Writer,
+	})
+	if err != nil {
+		err = NewErr(ErrCommand, ErrRun, err)
+		goto end
+	}
+
+	if *runOpts.report != "" {
+		err = dt.Filepath(*runOpts.report).WriteFile([]byte(result.JSON()+"\n"), 0o644)
+		if err != nil {
+			err = NewErr(ErrCommand, ErrRun, ErrFileWrite, "report", *runOpts.report, err)
+			goto end
+		}
+	}
+
+	if format == gompkg.JSONOutputFormat {
+		c.Writer.Printf("%s\n", result.JSON())
+	} else {
+		gomcliui.DisplayRunResult(result, c.Writer)
+	}
+
+	if result.Failed > 0 || result.Skipped > 0 {
+		err = NewErr(ErrCommand, ErrRun, gompkg.ErrRunFailed, "failed", result.Failed, "skipped", result.Skipped)
+	}
+
+end:
+	return err
+}
@@ -0,0 +1,89 @@
+package gomcmds
+
+import (
+	"context"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/gomion/gommod/gomcliui"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+)
+
+var _ cliutil.CommandHandler = (*TestCmd)(nil)
+
+var testOpts = &struct {
+	module   *string
+	affected *bool
+}{
+	module:   new(string),
+	affected: new(bool),
+}
+
+var testFlagSet = &cliutil.FlagSet{
+	Name: "test",
+	FlagDefs: []cliutil.FlagDef{
+		{
+			Name:     "affected",
+			Usage:    "Only test the packages affected by the uncommitted changes",
+			Required: false,
+			Default:  false,
+			Bool:     testOpts.affected,
+		},
+	},
+}
+
+// TestCmd runs the tests of a module and its local dependents against the
+// module's uncommitted changes
+type TestCmd struct {
+	*cliutil.CmdBase
+}
+
+func init() {
+	err := cliutil.RegisterCommand(&TestCmd{
+		CmdBase: cliutil.NewCmdBase(cliutil.CmdArgs{
+			Name:        "test",
+			Usage:       "test [<module-path|dir>] [--affected]",
+			Description: "Run go test in a module and its local dependents through a temporary go.work",
+			FlagSets:    []*cliutil.FlagSet{testFlagSet},
+			ArgDefs: []*cliutil.ArgDef{
+				{
+					Name:     "module",
+					Usage:    "Module path, name or directory (defaults to the module in the current directory)",
+					Required: false,
+					String:   testOpts.module,
+					Example:  "github.com/mikeschinkel/go-dt",
+				},
+			},
+		}),
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Handle executes the test command
+func (c *TestCmd) Handle() (err error) {
+	var result *gompkg.TestResult
+
+	ctx := context.Background()
+
+	result, err = gompkg.RunTests(ctx, gompkg.TestArgs{
+		Module:   *testOpts.module,
+		Affected: *testOpts.affected,
+		Config:   c.Config.(*gompkg.Config),
+		Logger:   c.Logger,
+		Writer:   c.Writer,
+	})
+	if err != nil {
+		err = NewErr(ErrCommand, ErrTest, err)
+		goto end
+	}
+
+	gomcliui.DisplayTestResult(result, c.Writer)
+
+	if len(result.Failed()) > 0 {
+		err = NewErr(ErrCommand, ErrTest, gompkg.ErrTestsFailed, "count", len(result.Failed()))
+	}
+
+end:
+	return err
+}
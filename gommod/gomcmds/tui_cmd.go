@@ -5,15 +5,26 @@ import (
 
 	"github.com/mikeschinkel/go-cliutil"
 	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gomcliui"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
 	"github.com/mikeschinkel/gomion/gommod/gomtui"
 )
 
 var _ cliutil.CommandHandler = (*TUICmd)(nil)
 
 var tuiOpts = &struct {
-	dir *string
+	dir       *string
+	workspace *string
 }{
-	dir: new(string),
+	dir:       new(string),
+	workspace: new(string),
+}
+
+var tuiFlagSet = &cliutil.FlagSet{
+	Name: "tui",
+	FlagDefs: []cliutil.FlagDef{
+		workspaceFlagDef(tuiOpts.workspace),
+	},
 }
 
 // TUICmd handles launching the GRU TUI staging editor
@@ -28,8 +39,9 @@ func init() {
 		CmdBase: cliutil.NewCmdBase(cliutil.CmdArgs{
 			Order:       99,
 			Name:        "tui",
-			Usage:       "tui [directory]",
+			Usage:       "tui [directory] [--workspace=<name>]",
 			Description: "Launch TUI staging editor for interactive commit workflow",
+			FlagSets:    []*cliutil.FlagSet{tuiFlagSet},
 			ArgDefs: []*cliutil.ArgDef{
 				{
 					Name:    "dir",
@@ -49,12 +61,34 @@ func init() {
 // Handle executes the tui command
 func (c *TUICmd) Handle() (err error) {
 	var tui *gomtui.TUI
+	var ws *gompkg.Workspace
+	var wsStatus *gompkg.WorkspaceStatus
+	var modDir dt.DirPath
 
 	// Create TUI instance with writer and logger
 	tui = gomtui.New(c.Writer, c.Logger)
 
-	var modDir dt.DirPath
-	modDir, err = dt.ParseDirPath(*tuiOpts.dir)
+	dir := *tuiOpts.dir
+
+	// Limit the TUI to the workspace's repos, defaulting to its first. The TUI
+	// edits a single module so the workspace's scan config goes unused.
+	_, ws, err = c.Config.(*gompkg.Config).ForWorkspace(*tuiOpts.workspace)
+	if err != nil {
+		goto end
+	}
+	if ws != nil {
+		dir, err = ws.StartDir(dir)
+		if err != nil {
+			goto end
+		}
+		wsStatus, err = gompkg.InspectWorkspace(ws)
+		if err != nil {
+			goto end
+		}
+		gomcliui.DisplayWorkspaceWarnings(wsStatus, c.Writer)
+	}
+
+	modDir, err = dt.ParseDirPath(dir)
 	if errors.Is(err, dt.ErrEmpty) {
 		err = nil
 		modDir = "." // TODO: We need to fix defaults in cliutils.ArgDefs
@@ -0,0 +1,291 @@
+package gomcmds
+
+import (
+	"strings"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/gomion/gommod/gomcfg"
+	"github.com/mikeschinkel/gomion/gommod/gomcliui"
+	"github.com/mikeschinkel/gomion/gommod/gompkg"
+)
+
+var _ cliutil.CommandHandler = (*WorkspaceCmd)(nil)
+var _ cliutil.CommandHandler = (*WorkspaceAddCmd)(nil)
+var _ cliutil.CommandHandler = (*WorkspaceListCmd)(nil)
+var _ cliutil.CommandHandler = (*WorkspaceShowCmd)(nil)
+var _ cliutil.CommandHandler = (*WorkspaceRemoveCmd)(nil)
+
+var workspaceOpts = &struct {
+	name          *string
+	repos         *string
+	moduleSpecs   *string
+	defaultBranch *string
+	goWork        *string
+}{
+	name:          new(string),
+	repos:         new(string),
+	moduleSpecs:   new(string),
+	defaultBranch: new(string),
+	goWork:        new(string),
+}
+
+var workspaceAddFlagSet = &cliutil.FlagSet{
+	Name: "workspace add",
+	FlagDefs: []cliutil.FlagDef{
+		{
+			Name:     "module-specs",
+			Usage:    "Comma-separated module specs to use instead of the root module_specs",
+			Required: false,
+			Default:  "",
+			String:   workspaceOpts.moduleSpecs,
+		},
+		{
+			Name:     "default-branch",
+			Usage:    "Branch the member repos are expected to have checked out",
+			Required: false,
+			Default:  "",
+			String:   workspaceOpts.defaultBranch,
+		},
+		{
+			Name:     "go-work",
+			Usage:    "go.work file covering the workspace's modules, used as GOWORK by commands given --workspace",
+			Required: false,
+			Default:  "",
+			String:   workspaceOpts.goWork,
+		},
+	},
+}
+
+// workspaceNameArgDef is shared by the workspace subcommands taking a name
+var workspaceNameArgDef = &cliutil.ArgDef{
+	Name:     "name",
+	Usage:    "Workspace name",
+	Required: true,
+	String:   workspaceOpts.name,
+	Example:  "go-pkgs",
+}
+
+// workspaceFlagDef returns the --workspace selector shared by the commands
+// that scan for repos
+func workspaceFlagDef(value *string) cliutil.FlagDef {
+	return cliutil.FlagDef{
+		Name:     "workspace",
+		Usage:    "Named workspace whose repos to scan instead of scan_dirs",
+		Required: false,
+		Default:  "",
+		String:   value,
+	}
+}
+
+// WorkspaceCmd is the parent command for managing named workspaces
+type WorkspaceCmd struct {
+	*cliutil.CmdBase
+}
+
+// workspaceCmd is the package-level instance for child commands to reference
+var workspaceCmd = &WorkspaceCmd{
+	CmdBase: cliutil.NewCmdBase(cliutil.CmdArgs{
+		Name:        "workspace",
+		Usage:       "workspace add|list|show|remove",
+		Description: "Manage named workspaces of related repos",
+	}),
+}
+
+// WorkspaceAddCmd adds a named workspace to the user config
+type WorkspaceAddCmd struct {
+	*cliutil.CmdBase
+}
+
+// WorkspaceListCmd lists the configured workspaces
+type WorkspaceListCmd struct {
+	*cliutil.CmdBase
+}
+
+// WorkspaceShowCmd shows a workspace's repos and their state
+type WorkspaceShowCmd struct {
+	*cliutil.CmdBase
+}
+
+// WorkspaceRemoveCmd removes a named workspace from the user config
+type WorkspaceRemoveCmd struct {
+	*cliutil.CmdBase
+}
+
+func init() {
+	err := cliutil.RegisterCommand(workspaceCmd)
+	if err != nil {
+		panic(err)
+	}
+
+	err = cliutil.RegisterCommand(&WorkspaceAddCmd{
+		CmdBase: cliutil.NewCmdBase(cliutil.CmdArgs{
+			Order:       1,
+			Name:        "add",
+			Usage:       "add <name> <repos> [--module-specs=<specs>] [--default-branch=<branch>] [--go-work=<file>]",
+			Description: "Add a named workspace of repos",
+			FlagSets:    []*cliutil.FlagSet{workspaceAddFlagSet},
+			ArgDefs: []*cliutil.ArgDef{
+				workspaceNameArgDef,
+				{
+					Name:     "repos",
+					Usage:    "Comma-separated directories of the member repos",
+					Required: true,
+					String:   workspaceOpts.repos,
+					Example:  "~/Projects/go-dt,~/Projects/go-cliutil",
+				},
+			},
+		}),
+	}, workspaceCmd)
+	if err != nil {
+		panic(err)
+	}
+
+	err = cliutil.RegisterCommand(&WorkspaceListCmd{
+		CmdBase: cliutil.NewCmdBase(cliutil.CmdArgs{
+			Order:       2,
+			Name:        "list",
+			Usage:       "list",
+			Description: "List the configured workspaces",
+		}),
+	}, workspaceCmd)
+	if err != nil {
+		panic(err)
+	}
+
+	err = cliutil.RegisterCommand(&WorkspaceShowCmd{
+		CmdBase: cliutil.NewCmdBase(cliutil.CmdArgs{
+			Order:       3,
+			Name:        "show",
+			Usage:       "show <name>",
+			Description: "Show a workspace's repos, their branches and managed modules",
+			ArgDefs:     []*cliutil.ArgDef{workspaceNameArgDef},
+		}),
+	}, workspaceCmd)
+	if err != nil {
+		panic(err)
+	}
+
+	err = cliutil.RegisterCommand(&WorkspaceRemoveCmd{
+		CmdBase: cliutil.NewCmdBase(cliutil.CmdArgs{
+			Order:       4,
+			Name:        "remove",
+			Usage:       "remove <name>",
+			Description: "Remove a named workspace",
+			ArgDefs:     []*cliutil.ArgDef{workspaceNameArgDef},
+		}),
+	}, workspaceCmd)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Handle executes the workspace command
+// This is a parent command that delegates to subcommands
+func (c *WorkspaceCmd) Handle() (err error) {
+	c.Writer.Printf("Use 'workspace add', 'list', 'show' or 'remove' to manage workspaces\n")
+	return nil
+}
+
+// Handle executes the workspace add command
+func (c *WorkspaceAddCmd) Handle() (err error) {
+	err = updateRootConfig(c.CmdBase, func(rootConfig *gomcfg.RootConfigV1) error {
+		return gompkg.AddWorkspace(rootConfig, gompkg.AddWorkspaceArgs{
+			Name:          *workspaceOpts.name,
+			Repos:         splitCommaList(*workspaceOpts.repos),
+			ModuleSpecs:   splitCommaList(*workspaceOpts.moduleSpecs),
+			DefaultBranch: *workspaceOpts.defaultBranch,
+			GoWork:        *workspaceOpts.goWork,
+		})
+	})
+	if err != nil {
+		goto end
+	}
+	c.Writer.Printf("Added workspace: %s\n", *workspaceOpts.name)
+
+end:
+	return err
+}
+
+// Handle executes the workspace list command
+func (c *WorkspaceListCmd) Handle() (err error) {
+	gomcliui.DisplayWorkspaces(c.Config.(*gompkg.Config).Workspaces, c.Writer)
+	return nil
+}
+
+// Handle executes the workspace show command
+func (c *WorkspaceShowCmd) Handle() (err error) {
+	var ws *gompkg.Workspace
+	var status *gompkg.WorkspaceStatus
+
+	ws, err = c.Config.(*gompkg.Config).Workspace(*workspaceOpts.name)
+	if err != nil {
+		err = NewErr(ErrCommand, ErrWorkspace, err)
+		goto end
+	}
+
+	status, err = gompkg.InspectWorkspace(ws)
+	if err != nil {
+		err = NewErr(ErrCommand, ErrWorkspace, err)
+		goto end
+	}
+
+	gomcliui.DisplayWorkspaceStatus(status, c.Writer)
+
+end:
+	return err
+}
+
+// Handle executes the workspace remove command
+func (c *WorkspaceRemoveCmd) Handle() (err error) {
+	err = updateRootConfig(c.CmdBase, func(rootConfig *gomcfg.RootConfigV1) error {
+		return gompkg.RemoveWorkspace(rootConfig, *workspaceOpts.name)
+	})
+	if err != nil {
+		goto end
+	}
+	c.Writer.Printf("Removed workspace: %s\n", *workspaceOpts.name)
+
+end:
+	return err
+}
+
+// updateRootConfig loads the user config, applies update to it and saves it
+func updateRootConfig(c *cliutil.CmdBase, update func(*gomcfg.RootConfigV1) error) (err error) {
+	var rootConfig *gomcfg.RootConfigV1
+
+	rootConfig, err = gomcfg.LoadRootConfigV1(gomcfg.LoadRootConfigV1Args{
+		AppInfo: c.AppInfo,
+		Options: gomcfg.NewOptions(gomcfg.OptionsArgs{}),
+	})
+	if err != nil {
+		err = NewErr(ErrCommand, ErrWorkspace, ErrConfigLoad, err)
+		goto end
+	}
+
+	err = update(rootConfig)
+	if err != nil {
+		err = NewErr(ErrCommand, ErrWorkspace, err)
+		goto end
+	}
+
+	err = gomcfg.SaveRootConfigV1(rootConfig, gomcfg.SaveRootConfigV1Args{
+		AppInfo: c.AppInfo,
+	})
+	if err != nil {
+		err = NewErr(ErrCommand, ErrWorkspace, ErrConfigSave, err)
+	}
+
+end:
+	return err
+}
+
+// splitCommaList splits a comma-separated argument, dropping empty entries
+func splitCommaList(s string) (items []string) {
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
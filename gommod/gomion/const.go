@@ -29,6 +29,8 @@ const (
 	ConfigFile     dt.RelFilepath = "config.json"
 	GitInfoFile    dt.RelFilepath = "gomion.json"
 	CommitPlanFile dt.RelFilepath = "gomion/commit-plan.json"
+	DevModeFile    dt.RelFilepath = "gomion/dev-mode.json"
+	TagLedgerFile  dt.RelFilepath = "gomion/tags.json"
 	// ExeName is just Gomion not Gomioncli or Gomion-cli as those are redundant, and
 	// Gomion should be the only CLI executable we put on a user's machine; everything
 	// else gets loaded or run by this one executable. Not that the other packages
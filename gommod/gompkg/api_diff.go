@@ -0,0 +1,36 @@
+package gompkg
+
+import (
+	"context"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gitutils"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+)
+
+// apiDiffSinceTag compares the exported API of the module at modRelPath as of
+// baselineTag, checked out in the repo's cached worktree, against currentDir
+func apiDiffSinceTag(ctx context.Context, repo *gitutils.Repo, baselineTag string, modRelPath dt.PathSegments, currentDir dt.DirPath) (report goutils.APIDiffReport, err error) {
+	var cached *gitutils.CachedWorktree
+
+	cached, err = repo.OpenCachedWorktree(ctx)
+	if err != nil {
+		goto end
+	}
+	defer dt.CloseOrLog(cached)
+
+	err = cached.Checkout(baselineTag)
+	if err != nil {
+		goto end
+	}
+
+	report, err = goutils.APIDiffDirs(dt.DirPathJoin(cached.Dir, modRelPath), currentDir, goutils.APIDiffDirsOptions{
+		ExcludeInternalPackages: true,
+	})
+
+end:
+	if err != nil {
+		err = WithErr(err, "baseline_tag", baselineTag)
+	}
+	return report, err
+}
@@ -0,0 +1,286 @@
+package gompkg
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"os"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gitutils"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+	"github.com/mikeschinkel/gomion/gommod/precommit"
+)
+
+// Benchmark defaults used when neither the module's config nor flags set them
+const (
+	DefaultBenchPattern   = "."
+	DefaultBenchCount     = 10
+	DefaultBenchThreshold = 5.0
+	DefaultBenchAlpha     = 0.05
+)
+
+// Bench targets compared against the baseline tag
+const (
+	BenchWorkingTree = "working tree"
+	BenchStaged      = "staged"
+)
+
+// BenchConfig holds a module's benchmark comparison settings, read from the
+// "bench" key of its entry in .gomion/config.json
+type BenchConfig struct {
+	// Benchmarks is the -bench regexp of the benchmarks to compare
+	Benchmarks string `json:"benchmarks,omitempty"`
+
+	// Count is how many times each benchmark runs in each tree
+	Count int `json:"count,omitempty"`
+
+	// Threshold is the smallest significant slowdown, in percent, reported as a
+	// regression
+	Threshold float64 `json:"threshold,omitempty"`
+
+	// Alpha is the p-value below which a difference is significant
+	Alpha float64 `json:"alpha,omitempty"`
+}
+
+// withDefaults returns c with unset fields taken from defaults
+func (c BenchConfig) withDefaults(defaults BenchConfig) BenchConfig {
+	if c.Benchmarks == "" {
+		c.Benchmarks = defaults.Benchmarks
+	}
+	if c.Count <= 0 {
+		c.Count = defaults.Count
+	}
+	if c.Threshold <= 0 {
+		c.Threshold = defaults.Threshold
+	}
+	if c.Alpha <= 0 {
+		c.Alpha = defaults.Alpha
+	}
+	return c
+}
+
+// BenchArgs contains the input parameters for Bench
+type BenchArgs struct {
+	// ModuleDir is a directory in the module to benchmark (defaults to ".")
+	ModuleDir string
+
+	// Settings override the module's configured BenchConfig where set
+	Settings BenchConfig
+
+	// Staged benchmarks the staged tree instead of the working tree
+	Staged bool
+
+	Config *Config
+	Logger *slog.Logger
+	Writer cliutil.Writer
+}
+
+// BenchResult contains the outcome of Bench
+type BenchResult struct {
+	ModulePath  goutils.ModulePath
+	ModuleDir   dt.DirPath
+	BaselineTag string
+
+	// Target is what was compared against the baseline, BenchWorkingTree or
+	// BenchStaged
+	Target string
+
+	// Settings are the benchmark settings used
+	Settings BenchConfig
+
+	// Comparisons lists every metric present at both the baseline and target
+	Comparisons []goutils.BenchComparison
+
+	// Regressions lists the significant comparisons worse by more than
+	// Settings.Threshold
+	Regressions []goutils.BenchComparison
+
+	// Verdict, VerdictReason and VerdictEvidence are the API verdict for the
+	// same baseline and target, as reported by `gomion next`
+	Verdict         VerdictType
+	VerdictReason   string
+	VerdictEvidence []string
+}
+
+// Bench runs the module's benchmarks at its latest tag, checked out in the
+// repo's cached worktree, and in the working tree or the staged tree, and
+// reports the significant regressions along with the API verdict between the
+// two
+func Bench(ctx context.Context, args BenchArgs) (result *BenchResult, err error) {
+	var startDir, moduleDir dt.DirPath
+	var repo *gitutils.Repo
+	var headSHA string
+	var modRelPath dt.PathSegments
+	var cached *gitutils.CachedWorktree
+	var baselineDir, currentDir dt.DirPath
+	var tempDir string
+	var mod *goutils.Module
+	var settings BenchConfig
+	var oldSamples, newSamples goutils.BenchSamples
+	var analysis precommit.Results
+	var warnings []string
+
+	result = &BenchResult{Target: BenchWorkingTree}
+
+	if args.ModuleDir == "" {
+		args.ModuleDir = "."
+	}
+	startDir, err = dt.ParseDirPath(args.ModuleDir)
+	if err != nil {
+		goto end
+	}
+	startDir, err = startDir.Abs()
+	if err != nil {
+		goto end
+	}
+	moduleDir, err = AutoDetectModule(startDir)
+	if err != nil {
+		goto end
+	}
+	result.ModuleDir = moduleDir
+
+	mod = goutils.NewModule(dt.FilepathJoin(moduleDir, "go.mod"))
+	err = mod.Load()
+	if err != nil {
+		goto end
+	}
+	result.ModulePath = mod.Path
+
+	repo, err = gitutils.Open(moduleDir)
+	if err != nil {
+		goto end
+	}
+	modRelPath, err = moduleDir.Rel(repo.Root)
+	if err != nil {
+		goto end
+	}
+
+	settings, err = loadBenchConfig(repo.Root, modRelPath)
+	if err != nil {
+		goto end
+	}
+	result.Settings = args.Settings.withDefaults(settings).withDefaults(BenchConfig{
+		Benchmarks: DefaultBenchPattern,
+		Count:      DefaultBenchCount,
+		Threshold:  DefaultBenchThreshold,
+		Alpha:      DefaultBenchAlpha,
+	})
+
+	headSHA, err = repo.RevParse("HEAD")
+	if err != nil {
+		goto end
+	}
+	result.BaselineTag, err = repo.LatestTag(ctx, headSHA, &gitutils.LatestTagArgs{
+		ModuleRelPath: modRelPath,
+	})
+	if err != nil {
+		goto end
+	}
+
+	cached, err = repo.OpenCachedWorktree(ctx)
+	if err != nil {
+		goto end
+	}
+	defer dt.CloseOrLog(cached)
+
+	err = cached.Checkout(result.BaselineTag)
+	if err != nil {
+		goto end
+	}
+	baselineDir = dt.DirPathJoin(cached.Dir, modRelPath)
+
+	currentDir = moduleDir
+	if args.Staged {
+		result.Target = BenchStaged
+		tempDir, err = os.MkdirTemp("", "gomion-bench-*")
+		if err != nil {
+			goto end
+		}
+		defer func() { _ = os.RemoveAll(tempDir) }()
+		err = gitutils.ExportIndex(ctx, gitutils.ExportStagedArgs{
+			Repo:    repo,
+			DestDir: dt.DirPath(tempDir),
+		})
+		if err != nil {
+			goto end
+		}
+		currentDir = dt.DirPathJoin(dt.DirPath(tempDir), modRelPath)
+	}
+
+	oldSamples, err = goutils.RunBenchmarks(ctx, baselineDir, goutils.RunBenchmarksArgs{
+		Bench: result.Settings.Benchmarks,
+		Count: result.Settings.Count,
+	})
+	if err != nil {
+		err = WithErr(err, "tree", result.BaselineTag)
+		goto end
+	}
+	newSamples, err = goutils.RunBenchmarks(ctx, currentDir, goutils.RunBenchmarksArgs{
+		Bench: result.Settings.Benchmarks,
+		Count: result.Settings.Count,
+	})
+	if err != nil {
+		err = WithErr(err, "tree", result.Target)
+		goto end
+	}
+
+	result.Comparisons = goutils.CompareBenchmarks(oldSamples, newSamples, result.Settings.Alpha)
+	for _, c := range result.Comparisons {
+		if isBenchRegression(c, result.Settings.Threshold) {
+			result.Regressions = append(result.Regressions, c)
+		}
+	}
+
+	analysis, warnings, err = analyzeRelease(ctx, result.BaselineTag, baselineDir, currentDir)
+	if err != nil {
+		result.Verdict = VerdictWithheld
+		result.VerdictReason = "API diff failed: " + err.Error()
+		err = nil
+		goto end
+	}
+	result.Verdict, result.VerdictReason, result.VerdictEvidence = gradeVerdict(analysis)
+	result.VerdictEvidence = append(result.VerdictEvidence, warnings...)
+
+end:
+	if err != nil {
+		err = WithErr(err, "module_dir", args.ModuleDir)
+	}
+	return result, err
+}
+
+// isBenchRegression returns true if c is a significant change for the worse
+// larger than threshold percent
+func isBenchRegression(c goutils.BenchComparison, threshold float64) bool {
+	if !c.Significant || !c.Worse() {
+		return false
+	}
+	return math.Abs(c.Delta) > threshold
+}
+
+// loadBenchConfig returns the BenchConfig of the module at modRelPath from the
+// repo's .gomion/config.json, or a zero BenchConfig if there is none
+func loadBenchConfig(repoRoot dt.DirPath, modRelPath dt.PathSegments) (cfg BenchConfig, err error) {
+	var repoConfig RepoConfig
+
+	store := ProjectConfigStore(repoRoot)
+	if !store.Exists() {
+		goto end
+	}
+	err = store.LoadJSON(&repoConfig)
+	if err != nil {
+		err = NewErr(ErrConfigLoad, "repo_root", repoRoot, err)
+		goto end
+	}
+	for relDir, mc := range repoConfig.Modules {
+		if mc.Bench == nil || relDir.Clean() != dt.DirPathJoin(".", modRelPath).Clean() {
+			continue
+		}
+		cfg = *mc.Bench
+		break
+	}
+
+end:
+	return cfg, err
+}
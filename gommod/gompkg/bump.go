@@ -0,0 +1,213 @@
+package gompkg
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gitutils"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+	"golang.org/x/mod/semver"
+)
+
+// BumpArgs contains the input parameters for Bump
+type BumpArgs struct {
+	// ModuleDir is the directory of the released module (defaults to ".")
+	ModuleDir string
+
+	// Version overrides the module's latest tag as the version to require (optional)
+	Version string
+
+	// NoTidy skips running `go mod tidy` in each bumped dependent
+	NoTidy bool
+
+	// DryRun reports which dependents would be bumped without editing them
+	DryRun bool
+
+	Config *Config
+	Logger *slog.Logger
+	Writer cliutil.Writer
+}
+
+// BumpedDependent describes one dependent whose require was (or would be) bumped
+type BumpedDependent struct {
+	ModulePath  goutils.ModulePath
+	ModuleDir   dt.DirPath
+	FromVersion dt.Version
+	TidyErr     error
+}
+
+// BumpResult contains the outcome of Bump
+type BumpResult struct {
+	// ModulePath is the released module whose dependents were bumped
+	ModulePath goutils.ModulePath
+
+	// Version is the version the dependents now require
+	Version dt.Version
+
+	// Bumped lists dependents whose require was changed
+	Bumped []BumpedDependent
+
+	// Current lists dependents that already required Version or newer
+	Current []BumpedDependent
+
+	DryRun bool
+}
+
+// Bump rewrites the require directive for a released module in every local
+// module that depends on it and runs `go mod tidy` in each. The dependents are
+// left with uncommitted changes so they become the next in-flux leaves.
+func Bump(ctx context.Context, args BumpArgs) (result *BumpResult, err error) {
+	var graph *goutils.ModuleGraph
+	var module *goutils.Module
+	var moduleDir dt.DirPath
+	var errs []error
+
+	result = &BumpResult{
+		DryRun: args.DryRun,
+	}
+
+	if args.ModuleDir == "" {
+		args.ModuleDir = "."
+	}
+
+	moduleDir, err = dt.ParseDirPath(args.ModuleDir)
+	if err != nil {
+		goto end
+	}
+
+	graph, err = LoadModuleGraph(LoadModuleGraphArgs{
+		StartDir: args.ModuleDir,
+		Config:   args.Config,
+		Logger:   args.Logger,
+		Writer:   args.Writer,
+	})
+	if err != nil {
+		goto end
+	}
+
+	module, err = moduleInDir(graph, moduleDir)
+	if err != nil {
+		goto end
+	}
+	result.ModulePath = module.Path
+
+	result.Version, err = bumpVersion(ctx, module, args.Version)
+	if err != nil {
+		goto end
+	}
+
+	for _, dep := range graph.Dependents(module.Path) {
+		var bumped BumpedDependent
+
+		err = ctx.Err()
+		if err != nil {
+			goto end
+		}
+
+		bumped, err = bumpDependent(ctx, dep, module.Path, result.Version, args)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if semver.Compare(string(bumped.FromVersion), string(result.Version)) >= 0 {
+			result.Current = append(result.Current, bumped)
+			continue
+		}
+		result.Bumped = append(result.Bumped, bumped)
+	}
+	err = CombineErrs(errs)
+
+end:
+	if err != nil {
+		err = WithErr(err, "module_dir", args.ModuleDir)
+	}
+	return result, err
+}
+
+// bumpVersion returns the explicitly requested version if provided, otherwise
+// the version of the module's latest tag reachable from HEAD
+func bumpVersion(ctx context.Context, module *goutils.Module, requested string) (version dt.Version, err error) {
+	var repo *gitutils.Repo
+	var headSHA string
+	var modRelPath dt.PathSegments
+	var tag string
+
+	if requested != "" {
+		if !semver.IsValid(requested) {
+			err = NewErr(ErrInvalidVersion, "version", requested)
+			goto end
+		}
+		version = dt.Version(requested)
+		goto end
+	}
+
+	repo, err = gitutils.Open(module.Dir())
+	if err != nil {
+		goto end
+	}
+
+	headSHA, err = repo.RevParse("HEAD")
+	if err != nil {
+		goto end
+	}
+
+	modRelPath, err = module.Dir().Rel(repo.Root)
+	if err != nil {
+		goto end
+	}
+
+	tag, err = repo.LatestTag(ctx, headSHA, &gitutils.LatestTagArgs{
+		ModuleRelPath: modRelPath,
+	})
+	if err != nil {
+		goto end
+	}
+	version = dt.Version(gitutils.ModuleTagVersion(tag))
+
+end:
+	return version, err
+}
+
+// bumpDependent points dep's require of modulePath at version, unless it already
+// requires that version or newer, and tidies it
+func bumpDependent(ctx context.Context, dep *goutils.Module, modulePath goutils.ModulePath, version dt.Version, args BumpArgs) (bumped BumpedDependent, err error) {
+	bumped = BumpedDependent{
+		ModulePath: dep.Path,
+		ModuleDir:  dep.Dir(),
+	}
+
+	for _, req := range dep.Requires {
+		if req.Path == modulePath {
+			bumped.FromVersion = req.Version
+			break
+		}
+	}
+	if semver.Compare(string(bumped.FromVersion), string(version)) >= 0 {
+		goto end
+	}
+	if args.DryRun {
+		goto end
+	}
+
+	_, err = dep.SetRequireVersion(modulePath, version)
+	if err != nil {
+		goto end
+	}
+
+	err = dep.Save()
+	if err != nil {
+		goto end
+	}
+
+	if args.NoTidy {
+		goto end
+	}
+
+	// A failed tidy is reported but leaves the edited go.mod in place to fix by hand
+	_, bumped.TidyErr = goutils.RunGo(ctx, dep.Dir(), "mod", "tidy")
+
+end:
+	return bumped, err
+}
@@ -0,0 +1,77 @@
+package gompkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+)
+
+// writeGoMod writes content to dir/go.mod, creating dir
+func writeGoMod(t *testing.T, dir, content string) dt.Filepath {
+	t.Helper()
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "go.mod")
+	err = os.WriteFile(file, []byte(content), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dt.Filepath(file)
+}
+
+// loadModule loads the go.mod at file
+func loadModule(t *testing.T, file dt.Filepath) *goutils.Module {
+	t.Helper()
+	mod := goutils.NewModule(file)
+	err := mod.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mod
+}
+
+func TestBumpDependent(t *testing.T) {
+	tests := []struct {
+		name     string
+		requires string
+		dryRun   bool
+		wantFrom dt.Version
+		wantReq  string
+	}{
+		{name: "Older", requires: "v1.2.0", wantFrom: "v1.2.0", wantReq: "v1.3.0"},
+		{name: "Current", requires: "v1.3.0", wantFrom: "v1.3.0", wantReq: "v1.3.0"},
+		{name: "Newer", requires: "v1.4.0", wantFrom: "v1.4.0", wantReq: "v1.4.0"},
+		{name: "DryRun", requires: "v1.2.0", dryRun: true, wantFrom: "v1.2.0", wantReq: "v1.2.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := writeGoMod(t, t.TempDir(),
+				"module example.com/app\n\ngo 1.25\n\nrequire example.com/lib "+tt.requires+"\n")
+			dep := loadModule(t, file)
+
+			bumped, err := bumpDependent(t.Context(), dep, "example.com/lib", "v1.3.0", BumpArgs{
+				NoTidy: true,
+				DryRun: tt.dryRun,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bumped.FromVersion != tt.wantFrom {
+				t.Errorf("got from version %q, want %q", bumped.FromVersion, tt.wantFrom)
+			}
+			content, err := os.ReadFile(string(file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(content), "example.com/lib "+tt.wantReq) {
+				t.Errorf("got go.mod\n%s\nwant it to require example.com/lib %s", content, tt.wantReq)
+			}
+		})
+	}
+}
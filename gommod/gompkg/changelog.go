@@ -0,0 +1,122 @@
+package gompkg
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// ChangelogFile is the per-module changelog written by `gomion notes --write`
+const ChangelogFile dt.RelFilepath = "CHANGELOG.md"
+
+// changelogHeader starts a CHANGELOG.md created by Gomion
+const changelogHeader = `# Changelog
+
+All notable changes to this module will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/),
+and this module adheres to [Semantic Versioning](https://semver.org/spec/v2.0.0.html).
+`
+
+// UpdateChangelog writes notes into a Keep a Changelog style file, replacing
+// the section for the same version if present, otherwise inserting it above the
+// newest released section and below any Unreleased section. The file is
+// created if it does not exist.
+func UpdateChangelog(file dt.Filepath, notes *ReleaseNotes) (err error) {
+	var content []byte
+	var lines []string
+	var out []string
+	var start, stop int
+
+	content, err = file.ReadFile()
+	switch {
+	case errors.Is(err, dt.ErrFileNotExist):
+		content = []byte(changelogHeader)
+		err = nil
+	case err != nil:
+		goto end
+	}
+
+	lines = strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	start, stop = changelogSectionBounds(lines, notes.Version)
+
+	out = make([]string, 0, len(lines)+32)
+	out = append(out, trimTrailingBlankLines(lines[:start])...)
+	out = append(out, "")
+	out = append(out, strings.Split(strings.TrimRight(notes.Markdown(), "\n"), "\n")...)
+	if stop < len(lines) {
+		out = append(out, "")
+		out = append(out, lines[stop:]...)
+	}
+	content = []byte(strings.Join(out, "\n") + "\n")
+
+	err = file.WriteFile(content, 0o644)
+
+end:
+	if err != nil {
+		err = WithErr(err, "changelog", file)
+	}
+	return err
+}
+
+// changelogSectionBounds returns the line range of the section for version,
+// wherever it is in the file. If there is none it returns an empty range above
+// the newest released section, below any Unreleased section, or at the end of
+// the sections if there are none yet.
+func changelogSectionBounds(lines []string, version string) (start, stop int) {
+	var unreleased = -1
+
+	heading := "## [" + version + "]"
+	for i, line := range lines {
+		if strings.HasPrefix(line, heading) {
+			start = i
+			stop = changelogSectionEnd(lines, i+1)
+			goto end
+		}
+	}
+
+	// No section for version yet; it goes above the newest released one
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "## [") {
+			continue
+		}
+		if strings.HasPrefix(strings.ToLower(line), "## [unreleased]") {
+			unreleased = i
+			continue
+		}
+		start, stop = i, i
+		goto end
+	}
+	start = changelogSectionEnd(lines, unreleased+1)
+	stop = start
+
+end:
+	return start, stop
+}
+
+// changelogSectionEnd returns the index of the first line at or after from
+// that starts another section or the link reference definitions that end a
+// Keep a Changelog file, or len(lines) if there is none
+func changelogSectionEnd(lines []string, from int) (end int) {
+	for end = from; end < len(lines); end++ {
+		line := lines[end]
+		if strings.HasPrefix(line, "## ") || changelogLinkRef.MatchString(line) {
+			break
+		}
+	}
+	return end
+}
+
+// changelogLinkRef matches a link reference definition such as
+// "[1.0.0]: https://github.com/owner/repo/releases/tag/v1.0.0"
+var changelogLinkRef = regexp.MustCompile(`^\[[^\]]+\]:\s`)
+
+// trimTrailingBlankLines returns lines without any trailing empty lines
+func trimTrailingBlankLines(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,291 @@
+package gompkg
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/go-dt/dtx"
+	"github.com/mikeschinkel/gomion/gommod/gitutils"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// TagProblemKind classifies a tag that `go get` cannot resolve as intended
+type TagProblemKind string
+
+const (
+	// TagOrphaned is a semver tag whose prefix names no managed module
+	TagOrphaned TagProblemKind = "orphaned"
+
+	// TagNonSemver is a tag whose version portion is not valid semver, which
+	// the go command ignores
+	TagNonSemver TagProblemKind = "non-semver"
+
+	// TagWrongModule is a tag whose go.mod at the tagged commit is missing or
+	// declares a module path not ending in the tag's prefix
+	TagWrongModule TagProblemKind = "wrong-module"
+
+	// TagMajorMismatch is a tag whose major version disagrees with the /vN
+	// suffix of the module path declared at the tagged commit
+	TagMajorMismatch TagProblemKind = "major-mismatch"
+)
+
+// TagProblem describes one inconsistent tag
+type TagProblem struct {
+	Kind     TagProblemKind
+	RepoRoot dt.DirPath
+	Tag      string
+
+	// ModuleDir is the repo-relative directory the tag's prefix resolves to,
+	// "" for the repo root
+	ModuleDir string
+
+	// ModulePath is the module path declared in go.mod at the tagged commit, if
+	// one was found
+	ModulePath ModulePath
+
+	// Detail explains the problem
+	Detail string
+}
+
+// CheckTagsArgs contains the input parameters for CheckTags
+type CheckTagsArgs struct {
+	// StartDir is any directory inside the starting repo (defaults to ".")
+	StartDir string
+
+	// RepoDirs overrides the config's scan_dirs when non-empty
+	RepoDirs []string
+
+	Config *Config
+	Logger *slog.Logger
+	Writer cliutil.Writer
+}
+
+// CheckTagsResult contains the outcome of CheckTags
+type CheckTagsResult struct {
+	// Repos lists the managed repos whose tags were checked
+	Repos []dt.DirPath
+
+	// TagCount is the number of tags checked across all repos
+	TagCount int
+
+	// Problems lists the inconsistent tags, ordered by repo then tag
+	Problems []TagProblem
+}
+
+// CheckTags validates every tag of the managed repos in the starting repo and
+// the scan dirs against the modules in each repo's .gomion/config.json. Tags
+// are resolved the way the go command resolves them: "<dir>/vX.Y.Z" belongs to
+// the module in <dir> (or <dir>/vX for X >= 2), whose go.mod at the tagged
+// commit must declare a path ending in <dir> with a /vN suffix matching X.
+func CheckTags(ctx context.Context, args CheckTagsArgs) (result *CheckTagsResult, err error) {
+	var startDir dt.DirPath
+	var scanDirs []dt.DirPath
+	var goModDirs []dt.DirPath
+	var repoRoot dt.DirPath
+	var problems []TagProblem
+	var count int
+
+	result = &CheckTagsResult{}
+
+	if args.StartDir == "" {
+		args.StartDir = "."
+	}
+	startDir, err = dt.ParseDirPath(args.StartDir)
+	if err != nil {
+		goto end
+	}
+	startDir, err = startDir.Clean().Abs()
+	if err != nil {
+		goto end
+	}
+	startDir, err = FindRepoRoot(startDir)
+	if err != nil {
+		goto end
+	}
+
+	scanDirs, err = getRepoDirsToScan(PlanArgs{
+		RepoDirs: args.RepoDirs,
+		Config:   args.Config,
+	})
+	if err != nil {
+		goto end
+	}
+	scanDirs = append([]dt.DirPath{startDir}, scanDirs...)
+
+	goModDirs, err = FindGoModFiles[dt.DirPath](FindGoModFilesArgs{
+		DirPaths:       scanDirs,
+		Config:         args.Config,
+		ContinueOnErr:  false,
+		SilenceErrs:    false,
+		SkipBehavior:   SkipUnmanaged,
+		MatchBehavior:  dtx.CollectOnMatch,
+		ParseEntryFunc: nil,
+		Logger:         args.Logger,
+		Writer:         args.Writer,
+	})
+	if err != nil {
+		goto end
+	}
+
+	for _, dir := range goModDirs {
+		repoRoot, err = FindRepoRoot(dir)
+		if err != nil {
+			goto end
+		}
+		if !slices.Contains(result.Repos, repoRoot) {
+			result.Repos = append(result.Repos, repoRoot)
+		}
+	}
+	slices.Sort(result.Repos)
+
+	for _, repoRoot = range result.Repos {
+		problems, count, err = checkRepoTags(ctx, repoRoot)
+		if err != nil {
+			goto end
+		}
+		result.TagCount += count
+		result.Problems = append(result.Problems, problems...)
+	}
+
+end:
+	if err != nil {
+		err = WithErr(err, "start_dir", args.StartDir)
+	}
+	return result, err
+}
+
+// checkRepoTags checks each tag of the managed repo at repoRoot against the
+// modules its config lists
+func checkRepoTags(ctx context.Context, repoRoot dt.DirPath) (problems []TagProblem, count int, err error) {
+	var ms *ModuleSet
+	var repo *gitutils.Repo
+	var tags []string
+	var moduleDirs map[string]*Module
+	var problem *TagProblem
+
+	ms = NewModuleSet()
+	err = discoverSingleRepoModules(repoRoot, ms)
+	if err != nil {
+		goto end
+	}
+	moduleDirs = make(map[string]*Module, len(ms.Modules))
+	for _, m := range ms.Modules {
+		moduleDirs[tagPrefixDir(string(m.RelDir))] = m
+	}
+
+	repo, err = gitutils.Open(repoRoot)
+	if err != nil {
+		goto end
+	}
+	tags, err = repo.AllTags(ctx)
+	if err != nil {
+		goto end
+	}
+	count = len(tags)
+
+	for _, tag := range tags {
+		problem = checkModuleTag(ctx, repo, moduleDirs, tag)
+		if problem == nil {
+			continue
+		}
+		problem.RepoRoot = repoRoot
+		problems = append(problems, *problem)
+	}
+
+end:
+	if err != nil {
+		err = WithErr(err, "repo_root", repoRoot)
+	}
+	return problems, count, err
+}
+
+// checkModuleTag returns the problem with tag, or nil if the go command would
+// resolve it to the module it is named for
+func checkModuleTag(ctx context.Context, repo *gitutils.Repo, moduleDirs map[string]*Module, tag string) *TagProblem {
+	var content []byte
+	var mf *modfile.File
+	var prefixBase, pathMajor string
+	var err error
+
+	prefix := gitutils.ModuleTagPrefix(tag)
+	version := gitutils.ModuleTagVersion(tag)
+	problem := &TagProblem{Tag: tag, ModuleDir: prefix}
+
+	// The go command ignores tags like "v1.0" or "v1.0.0+build" whose version
+	// is valid but not canonical
+	if semver.Canonical(version) != version {
+		problem.Kind = TagNonSemver
+		problem.Detail = "\"" + version + "\" is not a valid semantic version"
+		goto end
+	}
+
+	// For v2+ the go command looks in the major version subdirectory first
+	switch semver.Major(version) {
+	case "v0", "v1":
+	default:
+		if _, ok := moduleDirs[tagPrefixDir(path.Join(prefix, semver.Major(version)))]; ok {
+			problem.ModuleDir = tagPrefixDir(path.Join(prefix, semver.Major(version)))
+		}
+	}
+	if _, ok := moduleDirs[problem.ModuleDir]; !ok {
+		problem.Kind = TagOrphaned
+		problem.Detail = "no managed module in " + displayTagDir(prefix)
+		goto end
+	}
+
+	content, err = repo.ShowFile(ctx, tag, dt.RelFilepath(path.Join(problem.ModuleDir, "go.mod")))
+	if err != nil {
+		problem.Kind = TagWrongModule
+		problem.Detail = "no go.mod in " + displayTagDir(problem.ModuleDir) + " at the tagged commit"
+		goto end
+	}
+	mf, err = modfile.ParseLax("go.mod", content, nil)
+	if err != nil || mf.Module == nil {
+		problem.Kind = TagWrongModule
+		problem.Detail = "go.mod in " + displayTagDir(problem.ModuleDir) + " at the tagged commit has no module directive"
+		goto end
+	}
+	problem.ModulePath = ModulePath(mf.Module.Mod.Path)
+
+	prefixBase, pathMajor, _ = module.SplitPathVersion(mf.Module.Mod.Path)
+	if prefix != "" && !strings.HasSuffix(prefixBase, "/"+prefix) {
+		problem.Kind = TagWrongModule
+		problem.Detail = "module path does not end in the tag prefix \"" + prefix + "\""
+		goto end
+	}
+	err = module.CheckPathMajor(version, pathMajor)
+	if err != nil {
+		problem.Kind = TagMajorMismatch
+		problem.Detail = err.Error()
+		goto end
+	}
+	problem = nil
+
+end:
+	return problem
+}
+
+// tagPrefixDir normalizes a repo-relative module directory to the form used
+// as a tag prefix, e.g. "cmd" for "./cmd" and "" for "./"
+func tagPrefixDir(relDir string) string {
+	dir := path.Clean(strings.TrimPrefix(relDir, "./"))
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
+
+// displayTagDir returns a tag prefix directory for messages
+func displayTagDir(dir string) string {
+	if dir == "" {
+		return "the repo root"
+	}
+	return dir + "/"
+}
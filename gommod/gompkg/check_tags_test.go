@@ -0,0 +1,70 @@
+package gompkg
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gitutils"
+)
+
+func TestCheckModuleTag(t *testing.T) {
+	root := t.TempDir()
+	remoteDir := filepath.Join(root, "remote.git")
+	repoDir := filepath.Join(root, "repo")
+	git(t, root, "init", "--quiet", "--bare", remoteDir)
+	git(t, root, "init", "--quiet", "--initial-branch=main", repoDir)
+	git(t, repoDir, "remote", "add", "origin", remoteDir)
+	writeGoMod(t, repoDir, "module github.com/example/app\n\ngo 1.25\n")
+	writeGoMod(t, filepath.Join(repoDir, "cmd"), "module github.com/example/app/cmd\n\ngo 1.25\n")
+	writeGoMod(t, filepath.Join(repoDir, "v2"), "module github.com/example/app/v2\n\ngo 1.25\n")
+	writeGoMod(t, filepath.Join(repoDir, "tool"), "module github.com/example/other\n\ngo 1.25\n")
+	git(t, repoDir, "add", ".")
+	git(t, repoDir, "commit", "--quiet", "--message", "Initial")
+	git(t, repoDir, "push", "--quiet", "--set-upstream", "origin", "main")
+
+	repo, err := gitutils.Open(dt.DirPath(repoDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	moduleDirs := map[string]*Module{"": {}, "cmd": {}, "v2": {}, "tool": {}}
+
+	tests := []struct {
+		tag           string
+		wantKind      TagProblemKind
+		wantModuleDir string
+	}{
+		{tag: "v1.0.0"},
+		{tag: "cmd/v1.2.0"},
+		// The go command resolves v2+ tags to a major version subdirectory
+		{tag: "v2.0.0", wantModuleDir: "v2"},
+		{tag: "lib/v1.0.0", wantKind: TagOrphaned, wantModuleDir: "lib"},
+		{tag: "v1.0", wantKind: TagNonSemver},
+		{tag: "release-1", wantKind: TagNonSemver},
+		{tag: "v1.0.0+build", wantKind: TagNonSemver},
+		{tag: "tool/v1.0.0", wantKind: TagWrongModule, wantModuleDir: "tool"},
+		{tag: "v3.0.0", wantKind: TagMajorMismatch},
+		{tag: "cmd/v2.0.0", wantKind: TagMajorMismatch, wantModuleDir: "cmd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			git(t, repoDir, "tag", tt.tag)
+			problem := checkModuleTag(t.Context(), repo, moduleDirs, tt.tag)
+			if tt.wantKind == "" {
+				if problem != nil {
+					t.Errorf("got %s problem: %s, want none", problem.Kind, problem.Detail)
+				}
+				return
+			}
+			if problem == nil {
+				t.Fatalf("got no problem, want %s", tt.wantKind)
+			}
+			if problem.Kind != tt.wantKind {
+				t.Errorf("got %s problem: %s, want %s", problem.Kind, problem.Detail, tt.wantKind)
+			}
+			if problem.ModuleDir != tt.wantModuleDir {
+				t.Errorf("got module dir %q, want %q", problem.ModuleDir, tt.wantModuleDir)
+			}
+		})
+	}
+}
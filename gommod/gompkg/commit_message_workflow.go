@@ -18,6 +18,9 @@ type GenerateWithAnalysisArgs struct {
 	Logger    *slog.Logger
 	Writer    io.Writer
 	Agent     *askai.Agent
+
+	// Coverage adds the slower test coverage comparison to the analysis
+	Coverage bool
 }
 
 // GenerateWithAnalysis generates a commit message with pre-commit analysis
@@ -51,7 +54,11 @@ func GenerateWithAnalysis(ctx context.Context, args GenerateWithAnalysisArgs) (m
 
 	// Compute cache key and run analysis
 	cacheKey = precommit.ComputeCacheKey(args.ModuleDir, stagedFiles)
-	analysisResults, err = precommit.AnalyzeWithCache(ctx, args.ModuleDir, cacheKey, args.Writer)
+	analysisResults, err = precommit.AnalyzeWithCache(ctx, precommit.AnalyzeArgs{
+		ModuleDir: args.ModuleDir,
+		CacheKey:  cacheKey,
+		Coverage:  args.Coverage,
+	}, args.Writer)
 	if err != nil {
 		// Not fatal - warn and continue without analysis
 		args.Logger.Warn("Pre-commit analysis failed", "error", err)
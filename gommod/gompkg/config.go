@@ -13,13 +13,45 @@ var _ cliutil.Config = (*Config)(nil)
 type Config struct {
 	ScanDirs    []dt.DirPath
 	ModuleSpecs []ModuleSpec
-	Options     *gomion.Options
-	Logger      *slog.Logger
-	Writer      cliutil.Writer
+
+	// ToolDepsInFlux lets modules required only for tool directives make their
+	// requirers in-flux
+	ToolDepsInFlux bool
+
+	// IgnorePrereleases makes the latest final release the baseline rather than
+	// a later prerelease unless a command overrides it
+	IgnorePrereleases bool
+
+	// Concurrency bounds how many repos are checked for in-flux status at once
+	// when a command does not set it (zero for GOMAXPROCS)
+	Concurrency int
+
+	// Workspaces are the named workspaces selectable with --workspace
+	Workspaces []Workspace
+
+	// GoEnv is added to the environment of the go commands run with the
+	// config, e.g. GOWORK for a workspace's go.work
+	GoEnv []string
+
+	// LintCommand is the golangci-lint `run lint` runs in modules without a
+	// golangci-lint tool directive (defaults to the one in PATH)
+	LintCommand string
+
+	Options *gomion.Options
+	Logger  *slog.Logger
+	Writer  cliutil.Writer
 }
 
 func (c *Config) Config() {}
 
+// goEnv returns the environment to add to go commands, allowing a nil config
+func (c *Config) goEnv() []string {
+	if c == nil {
+		return nil
+	}
+	return c.GoEnv
+}
+
 type ConfigArgs struct {
 	Options *gomion.Options
 	Logger  *slog.Logger
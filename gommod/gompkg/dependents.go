@@ -0,0 +1,159 @@
+package gompkg
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gitutils"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+	"golang.org/x/mod/semver"
+)
+
+// DependentsArgs contains the input parameters for Dependents
+type DependentsArgs struct {
+	// StartDir is the directory to start scanning from (defaults to ".")
+	StartDir string
+
+	// Module selects the module by module path, last path element or directory
+	// (defaults to the module in StartDir)
+	Module string
+
+	Config *Config
+	Logger *slog.Logger
+	Writer cliutil.Writer
+}
+
+// Dependent describes one local module that depends on the queried module
+type Dependent struct {
+	ModulePath goutils.ModulePath
+	ModuleDir  dt.DirPath
+
+	// Depth is 1 for modules whose go.mod requires the queried module and one
+	// more than the closest module they require otherwise
+	Depth int
+
+	// Via is the module this one requires on its shortest path to the queried
+	// module; the queried module itself at depth 1
+	Via goutils.ModulePath
+
+	// Requires is the version of the queried module this module's go.mod
+	// requires, empty if it does not list it
+	Requires dt.Version
+
+	// Indirect is true if the require of the queried module is marked indirect
+	Indirect bool
+
+	// Outdated is true if Requires is older than the queried module's latest tag
+	Outdated bool
+}
+
+// DependentsResult contains the outcome of Dependents
+type DependentsResult struct {
+	ModulePath goutils.ModulePath
+	ModuleDir  dt.DirPath
+
+	// LatestVersion is the version of the module's latest tag, empty if it has
+	// never been tagged
+	LatestVersion dt.Version
+
+	// Dependents lists the direct then transitive dependents in order of depth
+	Dependents []Dependent
+}
+
+// Dependents finds every module across the scan dirs that depends on a module,
+// directly or transitively, by walking the module graph's require edges in
+// reverse. Dependents whose go.mod requires a version older than the module's
+// latest tag are flagged as outdated.
+func Dependents(ctx context.Context, args DependentsArgs) (result *DependentsResult, err error) {
+	var graph *goutils.ModuleGraph
+	var module *goutils.Module
+
+	result = &DependentsResult{}
+
+	if args.StartDir == "" {
+		args.StartDir = "."
+	}
+
+	graph, err = LoadModuleGraph(LoadModuleGraphArgs{
+		StartDir: args.StartDir,
+		Config:   args.Config,
+		Logger:   args.Logger,
+		Writer:   args.Writer,
+	})
+	if err != nil {
+		goto end
+	}
+
+	module, err = selectModule(graph, args.StartDir, args.Module)
+	if err != nil {
+		goto end
+	}
+	result.ModulePath = module.Path
+	result.ModuleDir = module.Dir()
+
+	result.LatestVersion, err = bumpVersion(ctx, module, "")
+	switch {
+	case errors.Is(err, gitutils.ErrNoSemverTags), errors.Is(err, gitutils.ErrNoReachableSemverTags):
+		// Never tagged so no dependent can be behind
+		err = nil
+	case err != nil:
+		goto end
+	}
+
+	walkDependents(graph, module, func(dep, via *goutils.Module, depth int) {
+		result.Dependents = append(result.Dependents, newDependent(dep, via, module.Path, depth, result.LatestVersion))
+	})
+
+end:
+	if err != nil {
+		err = WithErr(err, "module", args.Module)
+	}
+	return result, err
+}
+
+// walkDependents calls fn for each module in graph that depends on module,
+// breadth-first over the reversed require edges so each dependent is reached
+// at its shortest depth, through via
+func walkDependents(graph *goutils.ModuleGraph, module *goutils.Module, fn func(dep, via *goutils.Module, depth int)) {
+	reverse := graph.ReverseRequires()
+	depths := map[goutils.ModulePath]int{module.Path: 0}
+	queue := []*goutils.Module{module}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dep := range reverse[current.Path] {
+			if _, seen := depths[dep.Path]; seen {
+				continue
+			}
+			depths[dep.Path] = depths[current.Path] + 1
+			fn(dep, current, depths[dep.Path])
+			queue = append(queue, dep)
+		}
+	}
+}
+
+// newDependent describes dep, reached at depth through via, along with the
+// version of modulePath its go.mod requires
+func newDependent(dep, via *goutils.Module, modulePath goutils.ModulePath, depth int, latest dt.Version) (d Dependent) {
+	d = Dependent{
+		ModulePath: dep.Path,
+		ModuleDir:  dep.Dir(),
+		Depth:      depth,
+		Via:        via.Path,
+	}
+	for _, req := range dep.Requires {
+		if req.Path != modulePath {
+			continue
+		}
+		d.Requires = req.Version
+		d.Indirect = req.Indirect
+		break
+	}
+	if d.Requires != "" && latest != "" {
+		d.Outdated = semver.Compare(string(d.Requires), string(latest)) < 0
+	}
+	return d
+}
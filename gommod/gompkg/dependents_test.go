@@ -0,0 +1,74 @@
+package gompkg
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// git runs git in dir and returns its trimmed output
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestDependents(t *testing.T) {
+	repoDir := t.TempDir()
+	git(t, repoDir, "init", "--quiet", "--initial-branch=main")
+	writeGoMod(t, filepath.Join(repoDir, "lib"), "module example.com/lib\n\ngo 1.25\n")
+	writeGoMod(t, filepath.Join(repoDir, "app"), "module example.com/app\n\ngo 1.25\n\nrequire example.com/lib v1.1.0\n")
+	writeGoMod(t, filepath.Join(repoDir, "tool"), "module example.com/tool\n\ngo 1.25\n\nrequire example.com/lib v1.2.0 // indirect\n")
+	writeGoMod(t, filepath.Join(repoDir, "cli"), "module example.com/cli\n\ngo 1.25\n\nrequire example.com/app v0.1.0\n")
+	writeGoMod(t, filepath.Join(repoDir, "other"), "module example.com/other\n\ngo 1.25\n")
+	git(t, repoDir, "add", ".")
+	git(t, repoDir, "commit", "--quiet", "--message", "Initial")
+	git(t, repoDir, "tag", "lib/v1.2.0")
+	remoteDir := t.TempDir()
+	git(t, remoteDir, "init", "--quiet", "--bare")
+	git(t, repoDir, "remote", "add", "origin", remoteDir)
+	git(t, repoDir, "push", "--quiet", "--set-upstream", "origin", "main")
+
+	result, err := Dependents(t.Context(), DependentsArgs{
+		StartDir: filepath.Join(repoDir, "lib"),
+		// Scan nothing beyond the start repo
+		Config: &Config{ScanDirs: []dt.DirPath{dt.DirPath(t.TempDir())}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ModulePath != "example.com/lib" || result.LatestVersion != "v1.2.0" {
+		t.Errorf("got module %q at %q, want example.com/lib at v1.2.0", result.ModulePath, result.LatestVersion)
+	}
+
+	want := []Dependent{
+		{ModulePath: "example.com/app", Depth: 1, Via: "example.com/lib", Requires: "v1.1.0", Outdated: true},
+		{ModulePath: "example.com/tool", Depth: 1, Via: "example.com/lib", Requires: "v1.2.0", Indirect: true},
+		{ModulePath: "example.com/cli", Depth: 2, Via: "example.com/app"},
+	}
+	if len(result.Dependents) != len(want) {
+		t.Fatalf("got dependents %+v, want %+v", result.Dependents, want)
+	}
+	for i, got := range result.Dependents {
+		got.ModuleDir = ""
+		if got != want[i] {
+			t.Errorf("got dependent %+v, want %+v", got, want[i])
+		}
+	}
+	if result.Dependents[0].ModuleDir == "" {
+		t.Error("got no module dir, want the dependent's directory")
+	}
+}
@@ -0,0 +1,528 @@
+package gompkg
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gitutils"
+	"github.com/mikeschinkel/gomion/gommod/gomion"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+)
+
+// GoWorkFile is the name of the Go workspace file written by `dev on --work`
+const GoWorkFile dt.RelFilepath = "go.work"
+
+// DevReplace records a replace directive added to a go.mod by `gomion dev on`
+type DevReplace struct {
+	// GoMod is the go.mod file containing the replace, relative to the repo root
+	GoMod dt.RelFilepath `json:"go_mod"`
+
+	// ModulePath is the replaced module
+	ModulePath goutils.ModulePath `json:"module_path"`
+
+	// Dir is the replacement directory exactly as written in go.mod
+	Dir string `json:"dir"`
+}
+
+// DevModeState records what `gomion dev on` changed in a repo so that
+// `gomion dev off` only removes what Gomion added. It is stored in
+// .git/info/gomion/dev-mode.json so it is never committed.
+type DevModeState struct {
+	Replaces []DevReplace `json:"replaces"`
+
+	// WorkCreated is true if Gomion created go.work rather than editing one
+	WorkCreated bool `json:"work_created"`
+
+	// WorkUses lists the `use` directories Gomion added to go.work
+	WorkUses []string `json:"work_uses"`
+}
+
+// IsActive returns true if Gomion has dev-mode changes in place
+func (s *DevModeState) IsActive() bool {
+	return len(s.Replaces) > 0 || len(s.WorkUses) > 0 || s.WorkCreated
+}
+
+// LoadDevModeState loads the dev-mode state for a repo. A repo that has never
+// been put in dev mode returns an empty state.
+func LoadDevModeState(repoRoot dt.DirPath) (state *DevModeState, err error) {
+	var store *gitutils.InfoStore
+
+	state = &DevModeState{}
+	store = gitutils.NewInfoStore(repoRoot, gomion.DevModeFile)
+	err = store.LoadJSON(state)
+	if errors.Is(err, dt.ErrFileNotExist) {
+		err = nil
+	}
+	return state, err
+}
+
+// Save persists the dev-mode state for a repo
+func (s *DevModeState) Save(repoRoot dt.DirPath) (err error) {
+	return gitutils.NewInfoStore(repoRoot, gomion.DevModeFile).SaveJSON(s)
+}
+
+// DevModeArgs contains the input parameters for DevOn and DevOff
+type DevModeArgs struct {
+	// StartDir is any directory inside the repo to toggle (defaults to ".")
+	StartDir string
+
+	// Modules restricts which local dependencies are affected; each entry may be a
+	// module path, the last element of a module path, or a module directory
+	Modules []string
+
+	// UseWork manages go.work `use` entries instead of replace directives
+	UseWork bool
+
+	Config *Config
+	Logger *slog.Logger
+	Writer cliutil.Writer
+}
+
+// DevSkip describes a dependency dev mode left alone, and why
+type DevSkip struct {
+	GoMod      dt.RelFilepath
+	ModulePath goutils.ModulePath
+	Reason     string
+}
+
+// DevModeResult contains the outcome of DevOn or DevOff
+type DevModeResult struct {
+	RepoDir  dt.DirPath
+	Replaces []DevReplace
+	WorkUses []string
+	Skipped  []DevSkip
+}
+
+// DevOn points every module in the repo at the local checkouts of its
+// dependencies found in the scan dirs, either with replace directives or with a
+// go.work file, and records what it added.
+func DevOn(ctx context.Context, args DevModeArgs) (result *DevModeResult, err error) {
+	var graph *goutils.ModuleGraph
+	var state *DevModeState
+	var modules []*goutils.Module
+
+	result = &DevModeResult{}
+
+	graph, err = LoadModuleGraph(LoadModuleGraphArgs{
+		StartDir: args.StartDir,
+		Config:   args.Config,
+		Logger:   args.Logger,
+		Writer:   args.Writer,
+	})
+	if err != nil {
+		goto end
+	}
+	result.RepoDir = graph.RepoDir
+
+	state, err = LoadDevModeState(result.RepoDir)
+	if err != nil {
+		goto end
+	}
+
+	modules = repoModules(graph, result.RepoDir)
+	if args.UseWork {
+		err = devWorkOn(ctx, graph, modules, state, args, result)
+	} else {
+		err = devReplacesOn(graph, modules, state, args, result)
+	}
+	if err != nil {
+		goto end
+	}
+
+	err = state.Save(result.RepoDir)
+
+end:
+	return result, err
+}
+
+// DevOff removes the replace directives and go.work entries recorded by DevOn,
+// leaving any the user added by hand in place.
+func DevOff(ctx context.Context, args DevModeArgs) (result *DevModeResult, err error) {
+	var repoDir dt.DirPath
+	var state *DevModeState
+	var kept []DevReplace
+	var errs []error
+
+	result = &DevModeResult{}
+
+	if args.StartDir == "" {
+		args.StartDir = "."
+	}
+	repoDir, err = dt.ParseDirPath(args.StartDir)
+	if err != nil {
+		goto end
+	}
+	repoDir, err = repoDir.Clean().Abs()
+	if err != nil {
+		goto end
+	}
+	result.RepoDir, err = FindRepoRoot(repoDir)
+	if err != nil {
+		goto end
+	}
+
+	state, err = LoadDevModeState(result.RepoDir)
+	if err != nil {
+		goto end
+	}
+
+	for _, rep := range state.Replaces {
+		var removed bool
+		if !matchesModules(args.Modules, rep.ModulePath, "") {
+			kept = append(kept, rep)
+			continue
+		}
+		removed, err = dropDevReplace(result.RepoDir, rep)
+		if err != nil {
+			errs = append(errs, err)
+			kept = append(kept, rep)
+			continue
+		}
+		if !removed {
+			result.Skipped = append(result.Skipped, DevSkip{
+				GoMod:      rep.GoMod,
+				ModulePath: rep.ModulePath,
+				Reason:     "replace was changed after dev on; left in place",
+			})
+			continue
+		}
+		result.Replaces = append(result.Replaces, rep)
+	}
+	state.Replaces = kept
+
+	err = devWorkOff(ctx, state, args, result)
+	errs = AppendErr(errs, err)
+
+	err = state.Save(result.RepoDir)
+	errs = AppendErr(errs, err)
+
+	err = CombineErrs(errs)
+
+end:
+	return result, err
+}
+
+// devReplacesOn adds a replace directive to each repo module for each of its
+// requires that has a local checkout
+func devReplacesOn(graph *goutils.ModuleGraph, modules []*goutils.Module, state *DevModeState, args DevModeArgs, result *DevModeResult) (err error) {
+	var errs []error
+
+	for _, module := range modules {
+		var goMod dt.RelFilepath
+		var changed bool
+
+		goMod, err = module.Filepath.Rel(result.RepoDir)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, req := range module.Requires {
+			var depDir dt.DirPath
+			var relDir string
+
+			depDirs, ok := graph.ModuleDirByModulePath[req.Path]
+			if !ok {
+				// No local checkout
+				continue
+			}
+			depDir = depDirs.DirPath()
+			if !matchesModules(args.Modules, req.Path, depDir) {
+				continue
+			}
+
+			recorded := slices.ContainsFunc(state.Replaces, func(r DevReplace) bool {
+				return r.GoMod == goMod && r.ModulePath == req.Path
+			})
+			_, ok = module.ReplaceFor(req.Path)
+			if ok {
+				if !recorded {
+					result.Skipped = append(result.Skipped, DevSkip{
+						GoMod:      goMod,
+						ModulePath: req.Path,
+						Reason:     "already has a replace not added by gomion",
+					})
+				}
+				continue
+			}
+
+			relDir, err = localReplaceDir(module.Dir(), depDir)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			err = module.AddReplace(req.Path, relDir)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			changed = true
+
+			rep := DevReplace{
+				GoMod:      goMod,
+				ModulePath: req.Path,
+				Dir:        relDir,
+			}
+			// A recorded replace removed by hand is re-added; record it afresh
+			state.Replaces = slices.DeleteFunc(state.Replaces, func(r DevReplace) bool {
+				return r.GoMod == goMod && r.ModulePath == req.Path
+			})
+			state.Replaces = append(state.Replaces, rep)
+			result.Replaces = append(result.Replaces, rep)
+		}
+
+		if !changed {
+			continue
+		}
+		err = module.Save()
+		errs = AppendErr(errs, err)
+	}
+
+	return CombineErrs(errs)
+}
+
+// devWorkOn adds each repo module and each of its local dependencies to the
+// repo's go.work, creating it with `go work init` if needed. Only the `use`
+// entries it adds are recorded; those already in go.work belong to the user.
+func devWorkOn(ctx context.Context, graph *goutils.ModuleGraph, modules []*goutils.Module, state *DevModeState, args DevModeArgs, result *DevModeResult) (err error) {
+	var workFile dt.Filepath
+	var exists bool
+	var uses []string
+	var existing []string
+
+	workFile = dt.FilepathJoin(result.RepoDir, GoWorkFile)
+	exists, err = workFile.Exists()
+	if err != nil {
+		goto end
+	}
+	if exists {
+		existing, err = existingWorkUses(result.RepoDir, workFile)
+		if err != nil {
+			goto end
+		}
+	} else {
+		_, err = runGoWork(ctx, result.RepoDir, "init")
+		if err != nil {
+			goto end
+		}
+		state.WorkCreated = true
+	}
+
+	for _, module := range modules {
+		uses = appendWorkUse(uses, result.RepoDir, module.Dir())
+		for _, req := range module.Requires {
+			depDirs, ok := graph.ModuleDirByModulePath[req.Path]
+			if !ok {
+				continue
+			}
+			if !matchesModules(args.Modules, req.Path, depDirs.DirPath()) {
+				continue
+			}
+			uses = appendWorkUse(uses, result.RepoDir, depDirs.DirPath())
+		}
+	}
+
+	for _, use := range uses {
+		if slices.Contains(state.WorkUses, use) || slices.Contains(existing, use) {
+			continue
+		}
+		_, err = runGoWork(ctx, result.RepoDir, "use", use)
+		if err != nil {
+			goto end
+		}
+		state.WorkUses = append(state.WorkUses, use)
+		result.WorkUses = append(result.WorkUses, use)
+	}
+
+end:
+	return err
+}
+
+// devWorkOff removes the go.work `use` entries recorded by devWorkOn, and
+// go.work itself if Gomion created it and nothing else remains
+func devWorkOff(ctx context.Context, state *DevModeState, args DevModeArgs, result *DevModeResult) (err error) {
+	var kept []string
+	var workFile dt.Filepath
+	var sumFile dt.Filepath
+	var exists bool
+
+	for _, use := range state.WorkUses {
+		if !matchesModules(args.Modules, "", dt.DirPathJoin(result.RepoDir, use)) {
+			kept = append(kept, use)
+			continue
+		}
+		_, err = runGoWork(ctx, result.RepoDir, "edit", "-dropuse="+use)
+		if err != nil {
+			goto end
+		}
+		result.WorkUses = append(result.WorkUses, use)
+	}
+	state.WorkUses = kept
+
+	if !state.WorkCreated || len(state.WorkUses) > 0 {
+		goto end
+	}
+
+	workFile = dt.FilepathJoin(result.RepoDir, GoWorkFile)
+	err = workFile.Remove()
+	if err != nil {
+		goto end
+	}
+
+	// go.work.sum is only meaningful alongside the go.work Gomion created
+	sumFile = dt.FilepathJoin(result.RepoDir, GoWorkFile+".sum")
+	exists, err = sumFile.Exists()
+	if err != nil {
+		goto end
+	}
+	if exists {
+		err = sumFile.Remove()
+		if err != nil {
+			goto end
+		}
+	}
+	state.WorkCreated = false
+
+end:
+	return err
+}
+
+// existingWorkUses returns the `use` entries of workFile in the form
+// appendWorkUse produces so they can be compared with the ones devWorkOn adds
+func existingWorkUses(repoDir dt.DirPath, workFile dt.Filepath) (uses []string, err error) {
+	var written []string
+
+	written, err = goutils.WorkUses(workFile)
+	if err != nil {
+		goto end
+	}
+	for _, use := range written {
+		dir := dt.DirPath(use)
+		if !dir.IsAbs() {
+			dir = dt.DirPathJoin(repoDir, dir)
+		}
+		uses = appendWorkUse(uses, repoDir, dir.Clean())
+	}
+
+end:
+	return uses, err
+}
+
+// runGoWork runs `go work` with args in repoDir against the repo's go.work,
+// even if GOWORK in the environment names another file
+func runGoWork(ctx context.Context, repoDir dt.DirPath, args ...string) (string, error) {
+	return goutils.RunGoEnv(ctx, repoDir,
+		[]string{"GOWORK=" + string(dt.FilepathJoin(repoDir, GoWorkFile))},
+		append([]string{"work"}, args...)...,
+	)
+}
+
+// dropDevReplace removes rep from its go.mod if it still points where gomion
+// pointed it. Returns false if the replace was since changed by hand.
+func dropDevReplace(repoDir dt.DirPath, rep DevReplace) (removed bool, err error) {
+	var module *goutils.Module
+	var current goutils.Replace
+	var ok bool
+
+	module = goutils.NewModule(dt.FilepathJoin(repoDir, rep.GoMod))
+	err = module.Load()
+	if err != nil {
+		goto end
+	}
+
+	current, ok = module.ReplaceFor(rep.ModulePath)
+	if !ok {
+		// Already gone; nothing to do but forget it
+		removed = true
+		goto end
+	}
+	if string(current.New.Path) != rep.Dir {
+		goto end
+	}
+
+	err = module.DropReplace(rep.ModulePath)
+	if err != nil {
+		goto end
+	}
+	err = module.Save()
+	if err != nil {
+		goto end
+	}
+	removed = true
+
+end:
+	return removed, err
+}
+
+// repoModules returns the modules of the repo at repoDir in graph order
+func repoModules(graph *goutils.ModuleGraph, repoDir dt.DirPath) (modules []*goutils.Module) {
+	mods, ok := graph.ModulesMapByModulePathByRepoDir[repoDir]
+	if !ok {
+		goto end
+	}
+	for module := range mods.Values() {
+		modules = append(modules, module)
+	}
+end:
+	return modules
+}
+
+// localReplaceDir returns depDir relative to moduleDir in the "./" or "../"
+// form go.mod requires for local replacements
+func localReplaceDir(moduleDir, depDir dt.DirPath) (relDir string, err error) {
+	relDir, err = filepath.Rel(string(moduleDir), string(depDir))
+	if err != nil {
+		goto end
+	}
+	relDir = filepath.ToSlash(relDir)
+	if relDir != "." && relDir != ".." && !strings.HasPrefix(relDir, "../") {
+		relDir = "./" + relDir
+	}
+end:
+	return relDir, err
+}
+
+// appendWorkUse appends dir, relative to repoDir, to uses if not already present
+func appendWorkUse(uses []string, repoDir, dir dt.DirPath) []string {
+	use, err := localReplaceDir(repoDir, dir)
+	if err != nil || slices.Contains(uses, use) {
+		return uses
+	}
+	return append(uses, use)
+}
+
+// matchesModules reports whether a module is selected by the modules
+// given on the command line; no modules selects every dependency
+func matchesModules(selected []string, modulePath goutils.ModulePath, dir dt.DirPath) (matches bool) {
+	if len(selected) == 0 {
+		matches = true
+		goto end
+	}
+	for _, sel := range selected {
+		var selDir dt.DirPath
+		var err error
+
+		switch {
+		case modulePath != "" && sel == string(modulePath):
+			matches = true
+		case modulePath != "" && sel == path.Base(string(modulePath)):
+			matches = true
+		case dir != "":
+			selDir, err = dt.DirPath(sel).Abs()
+			matches = err == nil && selDir.Clean() == dir.Clean()
+		}
+		if matches {
+			goto end
+		}
+	}
+end:
+	return matches
+}
@@ -0,0 +1,61 @@
+package gompkg
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+)
+
+func TestLocalReplaceDir(t *testing.T) {
+	tests := []struct {
+		name      string
+		moduleDir string
+		depDir    string
+		want      string
+	}{
+		{name: "Sibling", moduleDir: "/src/app", depDir: "/src/lib", want: "../lib"},
+		{name: "Subdir", moduleDir: "/src/app", depDir: "/src/app/internal/lib", want: "./internal/lib"},
+		{name: "Parent", moduleDir: "/src/app/cmd", depDir: "/src/app", want: ".."},
+		{name: "Same", moduleDir: "/src/app", depDir: "/src/app", want: "."},
+		{name: "DotPrefixedName", moduleDir: "/src/app", depDir: "/src/app/..lib", want: "./..lib"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := localReplaceDir(dt.DirPath(tt.moduleDir), dt.DirPath(tt.depDir))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesModules(t *testing.T) {
+	dir := dt.DirPath(filepath.Join(t.TempDir(), "lib"))
+
+	tests := []struct {
+		name       string
+		selected   []string
+		modulePath goutils.ModulePath
+		want       bool
+	}{
+		{name: "NoneSelectsAll", modulePath: "example.com/lib", want: true},
+		{name: "ModulePath", selected: []string{"example.com/lib"}, modulePath: "example.com/lib", want: true},
+		{name: "ModuleName", selected: []string{"other", "lib"}, modulePath: "example.com/lib", want: true},
+		{name: "Dir", selected: []string{string(dir)}, modulePath: "example.com/lib", want: true},
+		{name: "DirWithTrailingSlash", selected: []string{string(dir) + "/"}, want: true},
+		{name: "Other", selected: []string{"example.com/other", "other"}, modulePath: "example.com/lib", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchesModules(tt.selected, tt.modulePath, dir)
+			if got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
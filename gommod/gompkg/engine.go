@@ -2,13 +2,16 @@ package gompkg
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"strings"
 
 	"github.com/mikeschinkel/go-cliutil"
 	"github.com/mikeschinkel/go-dt"
 	"github.com/mikeschinkel/go-dt/dtx"
 	"github.com/mikeschinkel/gomion/gommod/gitutils"
 	"github.com/mikeschinkel/gomion/gommod/goutils"
+	"github.com/mikeschinkel/gomion/gommod/precommit"
 )
 
 // VerdictType represents the confidence level of a breaking change assessment
@@ -42,12 +45,46 @@ type EngineResult struct {
 	// MissingRemoteTags lists tags that exist on remote but not locally (e.g., created by GitHub Actions)
 	MissingRemoteTags []string
 
+	// UnpushedTags lists the leaf module's local tags missing on the remote
+	UnpushedTags []string
+
+	// MismatchedTags lists the leaf module's tags that point at different
+	// commits locally and on the remote
+	MismatchedTags []gitutils.TagMismatch
+
+	// MovedTags lists the leaf module's tags that no longer resolve to the
+	// commit and tree Gomion recorded when it first saw them
+	MovedTags []MovedTag
+
 	// Verdict is the assessment of whether this release contains breaking changes
 	Verdict VerdictType
 
 	// VerdictReason explains why this verdict was reached
 	VerdictReason string
 
+	// VerdictEvidence itemizes the analysis signals behind Verdict, e.g.
+	// "exported func body changed: Foo" or "tests modified: TestFoo"
+	VerdictEvidence []string
+
+	// APIAdded is true if the exported API gained declarations since the
+	// baseline, making a compatible release a minor rather than a patch bump
+	APIAdded bool
+
+	// ReleaseBlocked indicates the leaf cannot be released until it is cleaned up
+	// (dirty working tree, replace directives, or in-flux dependencies)
+	ReleaseBlocked bool
+
+	// ReleaseBlockedReason explains why ReleaseBlocked is true
+	ReleaseBlockedReason string
+
+	// LocalReplaces lists the leaf's committed local-path replace directives,
+	// each of which must be removed before it can be released
+	LocalReplaces []LocalReplace
+
+	// Waves lists every in-flux module grouped into waves that can be released in
+	// parallel (only populated when EngineArgs.AllWaves is true)
+	Waves ReleaseWaves
+
 	// InFluxDependencies lists any dependencies that are in-flux (for debugging/info)
 	InFluxDependencies []ModulePath
 
@@ -81,6 +118,18 @@ type EngineArgs struct {
 
 	// Writer for progress output (optional, for streaming)
 	Writer cliutil.Writer
+
+	// AllWaves computes the complete release ordering instead of selecting a
+	// leaf and computing its verdict
+	AllWaves bool
+
+	// Concurrency bounds how many repos are checked for in-flux status at once
+	// (defaults to Config.Concurrency, then GOMAXPROCS)
+	Concurrency int
+
+	// IgnorePrereleases uses the latest final release as the verdict's baseline
+	// rather than a later prerelease; Config.IgnorePrereleases sets it by default
+	IgnorePrereleases bool
 }
 
 // StreamingHook is an optional callback for progress updates during long operations
@@ -88,9 +137,10 @@ type StreamingHook func(message string)
 
 // ReleaseEngine is the core engine that selects a leaf module and computes verdict
 type ReleaseEngine struct {
-	args  EngineArgs
-	graph *goutils.ModuleGraph
-	hook  StreamingHook
+	args   EngineArgs
+	graph  *goutils.ModuleGraph
+	hook   StreamingHook
+	inFlux *inFluxCache
 }
 
 // NewReleaseEngine creates a new release planning engine
@@ -187,15 +237,36 @@ func (e *ReleaseEngine) Run(ctx context.Context) (result *EngineResult, err erro
 	// Step 4: Build the module dependency graph
 	e.stream("Building module dependency graph...")
 	e.graph = goutils.NewGraph(repoDir, goModFiles, goutils.ModuleGraphArgs{
-		Logger: e.args.Logger,
-		Writer: e.args.Writer,
+		ToolDepsInFlux: e.args.Config != nil && e.args.Config.ToolDepsInFlux,
+		Logger:         e.args.Logger,
+		Writer:         e.args.Writer,
 	})
 	err = e.graph.Build()
 	if err != nil {
 		goto end
 	}
 
-	// Step 5: Find leaf module with no in-flux dependencies
+	// Step 4.5: Evaluate in-flux status of every module we will visit, in
+	// parallel, reusing what an earlier run found for repos that are unchanged
+	e.stream("Evaluating in-flux status...")
+	if e.inFlux == nil {
+		e.inFlux = newInFluxCache(e.args.Logger)
+	}
+	e.inFlux.Refresh(e.graph)
+	err = e.prewarmInFlux(ctx)
+	if err != nil {
+		goto end
+	}
+
+	// Step 5: Group all in-flux modules into release waves if requested; the
+	// waves are the whole result, so no leaf is needed and no verdict computed
+	if e.args.AllWaves {
+		e.stream("Computing release waves...")
+		result.Waves, err = e.computeReleaseWaves(ctx)
+		goto end
+	}
+
+	// Step 6: Find leaf module with no in-flux dependencies
 	e.stream("Finding leaf module...")
 	leafModuleDir, err = e.findLeafModule(ctx)
 	if err != nil {
@@ -212,21 +283,21 @@ func (e *ReleaseEngine) Run(ctx context.Context) (result *EngineResult, err erro
 	// Get all modules in the leaf repo for informational purposes
 	result.LeafRepoModules = e.getRepoModules(result.LeafRepoDir)
 
-	// Step 6: Gather git status information
+	// Step 7: Gather git status information
 	e.stream("Gathering git status...")
 	err = e.gatherGitStatus(ctx, result)
 	if err != nil {
 		goto end
 	}
 
-	// Step 7: Check for tagged-but-not-pushed
-	e.stream("Checking tag/push status...")
+	// Step 8: Check for tagged-but-not-pushed and moved tags
+	e.stream("Checking tag/push status and integrity...")
 	err = e.checkTaggedButNotPushed(ctx, result)
 	if err != nil {
 		goto end
 	}
 
-	// Step 8: Compute verdict using apidiffr
+	// Step 9: Compute verdict using apidiffr
 	e.stream("Computing breaking change verdict...")
 	err = e.computeVerdict(ctx, result)
 	if err != nil {
@@ -287,7 +358,6 @@ func (e *ReleaseEngine) gatherGitStatus(ctx context.Context, result *EngineResul
 	var counts gitutils.StatusCounts
 	var modRelPath dt.PathSegments
 	var excludePaths []dt.PathSegments
-	var missingTags []string
 
 	// Open the git repository
 	repo, err = gitutils.Open(result.LeafRepoDir)
@@ -314,28 +384,6 @@ func (e *ReleaseEngine) gatherGitStatus(ctx context.Context, result *EngineResul
 		}
 	}
 
-	// Check for tags on remote that don't exist locally, and fetch them if found
-	modRelPath, err = result.LeafModuleDir.Rel(result.LeafRepoDir)
-	if err == nil {
-		missingTags, err = repo.CompareRemoteTags(ctx, string(modRelPath))
-		if err == nil && len(missingTags) > 0 {
-			// Auto-fetch missing tags
-			e.stream("Fetching missing tags from remote...")
-			fetchErr := repo.FetchTags(ctx)
-			if fetchErr == nil {
-				// Successfully fetched - record what was fetched
-				result.MissingRemoteTags = missingTags
-			} else {
-				// Fetch failed - log but don't fail the whole operation
-				if e.args.Logger != nil {
-					e.args.Logger.Warn("Failed to fetch tags", "error", fetchErr)
-				}
-			}
-		}
-		// Don't fail on tag comparison errors
-		err = nil
-	}
-
 	// Calculate module relative path within repo
 	modRelPath, err = result.LeafModuleDir.Rel(result.LeafRepoDir)
 	if err != nil {
@@ -415,13 +463,18 @@ func (e *ReleaseEngine) findLeafModule(ctx context.Context) (leafDir goutils.Mod
 			var inFlux bool
 			var depsInFlux bool
 
+			err = ctx.Err()
+			if err != nil {
+				goto end
+			}
+
 			module, ok := e.graph.ModulesByModuleDir[modDir]
 			if !ok {
 				continue
 			}
 
 			// Check if this module is in-flux
-			inFlux, err = e.isModuleInFlux(ctx, module)
+			inFlux, _, err = e.isModuleInFlux(ctx, module)
 			if err != nil {
 				// Log error but continue searching
 				if e.args.Logger != nil {
@@ -468,33 +521,47 @@ end:
 	return leafDir, err
 }
 
-// isModuleInFlux checks if a module's dependencies are in-flux
-func (e *ReleaseEngine) isModuleInFlux(ctx context.Context, module *goutils.Module) (inFlux bool, err error) {
-	var reason string
-	var moduleExt *ModuleExt
+// isModuleInFlux checks if a module is in-flux and returns the reason if so
+func (e *ReleaseEngine) isModuleInFlux(ctx context.Context, module *goutils.Module) (inFlux bool, reason string, err error) {
+	var status InFluxStatus
 
-	// Wrap base module in ModuleExt to access IsInFlux
-	moduleExt = &ModuleExt{Module: module}
-	err = moduleExt.SetGraph(e.graph)
-	if err != nil {
-		goto end
+	status, err = e.inFlux.Status(ctx, module)
+	return status.InFlux(), status.Reason, err
+}
+
+// releaseBlockedStatus checks if a module is dirty, has replace directives or
+// has in-flux dependencies, any of which prevent it from being released
+func (e *ReleaseEngine) releaseBlockedStatus(ctx context.Context, module *goutils.Module) (status InFluxStatus, err error) {
+	status, err = e.inFlux.Status(ctx, module)
+	if !status.Blocked {
+		status.Reason = ""
 	}
+	return status, err
+}
 
-	// Use ModuleExt.IsInFlux() method
-	inFlux, reason, err = moduleExt.IsInFlux(ctx)
+// prewarmInFlux evaluates the in-flux status of every module reachable from the
+// start repo so later lookups are served from the cache
+func (e *ReleaseEngine) prewarmInFlux(ctx context.Context) (err error) {
+	var traverseResult *goutils.TraverseResult
+	var moduleDirs []goutils.ModuleDir
+	var concurrency int
+
+	traverseResult, err = e.graph.Traverse()
 	if err != nil {
 		goto end
 	}
+	for _, dirs := range traverseResult.RepoModules.Iterator() {
+		moduleDirs = append(moduleDirs, dirs...)
+	}
 
-	// Log reason if in-flux and logger available
-	if inFlux && e.args.Logger != nil {
-		e.args.Logger.Debug("Module is in-flux",
-			"module", module.Dir(),
-			"reason", reason)
+	concurrency = e.args.Concurrency
+	if concurrency <= 0 && e.args.Config != nil {
+		concurrency = e.args.Config.Concurrency
 	}
+	err = e.inFlux.Prewarm(ctx, moduleDirs, concurrency)
 
 end:
-	return inFlux, err
+	return err
 }
 
 // hasDependenciesInFlux checks if any of this module's dependencies are in-flux
@@ -516,7 +583,7 @@ func (e *ReleaseEngine) hasDependenciesInFlux(ctx context.Context, module *gouti
 		}
 
 		// Check if this dependency is in-flux
-		inFlux, err = e.isModuleInFlux(ctx, depModule)
+		inFlux, _, err = e.isModuleInFlux(ctx, depModule)
 		if err != nil {
 			goto end
 		}
@@ -534,37 +601,72 @@ end:
 	return hasInFluxDeps, err
 }
 
-// checkTaggedButNotPushed checks if there's a local tag that hasn't been pushed
+// checkTaggedButNotPushed compares the leaf module's local tags with the
+// remote's, fetching tags only found on the remote and recording tags not yet
+// pushed or pointing at different commits locally and remotely. It also checks
+// the local tags against those recorded in the repo's tag ledger, recording
+// the pushed ones not yet in it.
 func (e *ReleaseEngine) checkTaggedButNotPushed(ctx context.Context, result *EngineResult) (err error) {
 	var repo *gitutils.Repo
-	var upstreamState gitutils.UpstreamState
+	var modRelPath dt.PathSegments
+	var cmp gitutils.TagComparison
 
 	// Open the git repository
 	repo, err = gitutils.Open(result.LeafRepoDir)
 	if err != nil {
 		// Not a git repo or can't access - just skip the check
-		result.LocalTagNotPushed = false
 		err = nil
 		goto end
 	}
 
-	// Check upstream state
-	upstreamState, err = repo.UpstreamState()
+	modRelPath, err = result.LeafModuleDir.Rel(result.LeafRepoDir)
+	if err != nil {
+		goto end
+	}
+
+	cmp, err = repo.CompareTags(ctx, string(modRelPath))
+	if err != nil {
+		// No remote or unreachable - not fatal, but only the ledger is checked
+		if e.args.Logger != nil {
+			e.args.Logger.Warn("Failed to compare tags with remote", "error", err)
+		}
+		result.MovedTags, err = CheckTagIntegrity(ctx, repo, string(modRelPath), nil)
+		goto end
+	}
+
+	result.MovedTags, err = CheckTagIntegrity(ctx, repo, string(modRelPath), &cmp)
 	if err != nil {
-		// No upstream configured - skip check
-		result.LocalTagNotPushed = false
-		err = nil
 		goto end
 	}
 
-	// If we're ahead of upstream, we might have local commits/tags not pushed
-	if upstreamState.Ahead() > 0 {
-		// TODO: More sophisticated check - look for tags at HEAD that don't exist on remote
-		// For now, just set warning but don't block (this check is too aggressive)
-		result.LocalTagNotPushed = false // Disabled for now - needs proper tag checking
-		result.LocalTagNotPushedWarning = "Local branch is ahead of upstream. Didn't you mean to push?"
+	result.UnpushedTags = cmp.LocalOnly
+	result.MismatchedTags = cmp.Mismatched
+	result.LocalTagNotPushed = len(cmp.LocalOnly) > 0
+	if result.LocalTagNotPushed {
+		result.LocalTagNotPushedWarning = fmt.Sprintf("%d local tag(s) not pushed to %s: %s",
+			len(cmp.LocalOnly),
+			repo.Remote.NameOrDefault(),
+			strings.Join(cmp.LocalOnly, ", "),
+		)
 	}
 
+	if len(cmp.RemoteOnly) == 0 {
+		goto end
+	}
+
+	// Auto-fetch tags only found on the remote, e.g. created by GitHub Actions
+	e.stream("Fetching missing tags from remote...")
+	err = repo.FetchTags(ctx)
+	if err != nil {
+		// Fetch failed - log but don't fail the whole operation
+		if e.args.Logger != nil {
+			e.args.Logger.Warn("Failed to fetch tags", "error", err)
+		}
+		err = nil
+		goto end
+	}
+	result.MissingRemoteTags = cmp.RemoteOnly
+
 end:
 	return err
 }
@@ -575,13 +677,13 @@ func (e *ReleaseEngine) computeVerdict(ctx context.Context, result *EngineResult
 	var headSHA string
 	var baselineTag string
 	var modRelPath dt.PathSegments
-	var report goutils.APIDiffReport
+	var analysis precommit.Results
+	var warnings []string
 	var cached *gitutils.CachedWorktree
 	var baselineModuleDir dt.DirPath
-	var currentModuleDir dt.DirPath
-	var inFlux bool
+	var status InFluxStatus
 
-	// Check if module has in-flux dependencies - if so, withhold verdict
+	// Check if module is dirty or has in-flux dependencies - if so, withhold verdict
 	module, ok := e.graph.ModulesByModuleDir[result.LeafModuleDir]
 	if !ok {
 		result.Verdict = VerdictWithheld
@@ -589,7 +691,7 @@ func (e *ReleaseEngine) computeVerdict(ctx context.Context, result *EngineResult
 		goto end
 	}
 
-	inFlux, err = e.isModuleInFlux(ctx, module)
+	status, err = e.releaseBlockedStatus(ctx, module)
 	if err != nil {
 		result.Verdict = VerdictWithheld
 		result.VerdictReason = "error checking in-flux status: " + err.Error()
@@ -597,7 +699,10 @@ func (e *ReleaseEngine) computeVerdict(ctx context.Context, result *EngineResult
 		goto end
 	}
 
-	if inFlux {
+	result.LocalReplaces = status.LocalReplaces
+	if status.Blocked {
+		result.ReleaseBlocked = true
+		result.ReleaseBlockedReason = status.Reason
 		result.Verdict = VerdictWithheld
 		result.VerdictReason = "module is in-flux (clean it up before verdict can be assessed)"
 		goto end
@@ -632,7 +737,8 @@ func (e *ReleaseEngine) computeVerdict(ctx context.Context, result *EngineResult
 
 	// Find the latest reachable semver tag for this module
 	baselineTag, err = repo.LatestTag(ctx, headSHA, &gitutils.LatestTagArgs{
-		ModuleRelPath: modRelPath,
+		ModuleRelPath:      modRelPath,
+		ExcludePrereleases: ignorePrereleases(e.args.IgnorePrereleases, e.args.Config),
 	})
 	if err != nil {
 		// No baseline tag - this might be the first release
@@ -652,7 +758,8 @@ func (e *ReleaseEngine) computeVerdict(ctx context.Context, result *EngineResult
 	}
 	defer dt.CloseOrLog(cached)
 
-	// Checkout baseline tag
+	// Checkout baseline tag; HEAD is the module directory itself, as a verdict is
+	// only computed once the module is clean
 	err = cached.Checkout(baselineTag)
 	if err != nil {
 		result.Verdict = VerdictWithheld
@@ -664,22 +771,8 @@ func (e *ReleaseEngine) computeVerdict(ctx context.Context, result *EngineResult
 	// Get module directory in cached worktree
 	baselineModuleDir = dt.DirPathJoin(cached.Dir, modRelPath)
 
-	// Checkout HEAD
-	err = cached.Checkout(headSHA)
-	if err != nil {
-		result.Verdict = VerdictWithheld
-		result.VerdictReason = "cannot checkout HEAD: " + err.Error()
-		err = nil
-		goto end
-	}
-
-	// Get module directory at HEAD
-	currentModuleDir = dt.DirPathJoin(cached.Dir, modRelPath)
-
-	// Run API diff
-	report, err = goutils.APIDiffDirs(baselineModuleDir, currentModuleDir, goutils.APIDiffDirsOptions{
-		ExcludeInternalPackages: true,
-	})
+	// Run the API, AST and test signal analyzers
+	analysis, warnings, err = analyzeRelease(ctx, baselineTag, baselineModuleDir, dt.DirPath(result.LeafModuleDir))
 	if err != nil {
 		result.Verdict = VerdictWithheld
 		result.VerdictReason = "API diff failed: " + err.Error()
@@ -687,14 +780,10 @@ func (e *ReleaseEngine) computeVerdict(ctx context.Context, result *EngineResult
 		goto end
 	}
 
-	// Determine verdict based on breaking changes
-	if report.HasBreakingChanges() {
-		result.Verdict = VerdictBreaking
-		result.VerdictReason = "API analysis detected breaking changes"
-	} else {
-		result.Verdict = VerdictMaybeNotBreaking
-		result.VerdictReason = "no breaking changes detected in exported API"
-	}
+	// Grade the verdict from the combined analyses
+	result.Verdict, result.VerdictReason, result.VerdictEvidence = gradeVerdict(analysis)
+	result.VerdictEvidence = append(result.VerdictEvidence, warnings...)
+	result.APIAdded = len(analysis.API.Additions) > 0
 
 end:
 	return err
@@ -55,3 +55,94 @@ var (
 	// ErrFailedToLoadCommitPlan indicates failure to load commit plan
 	ErrFailedToLoadCommitPlan = errors.New("failed to load commit plan")
 )
+
+// Release errors
+var (
+	// ErrModuleInFlux indicates a module cannot be released until it is cleaned up
+	ErrModuleInFlux = errors.New("module is in-flux")
+
+	// ErrVerdictWithheld indicates no version could be proposed from the verdict
+	ErrVerdictWithheld = errors.New("verdict withheld; specify a version explicitly")
+
+	// ErrInvalidVersion indicates a version is not a valid semver version
+	ErrInvalidVersion = errors.New("invalid version")
+
+	// ErrVersionNotAfterBaseline indicates a version would sort at or below the
+	// baseline tag, e.g. a beta prerelease after a release candidate
+	ErrVersionNotAfterBaseline = errors.New("version does not sort after the baseline tag")
+
+	// ErrMajorVersionMismatch indicates a version does not match the module path's /vN suffix
+	ErrMajorVersionMismatch = errors.New("version does not match module path major version")
+
+	// ErrTagAlreadyExists indicates the release tag already exists
+	ErrTagAlreadyExists = errors.New("tag already exists")
+
+	// ErrAmbiguousModule indicates a module selector matched more than one module
+	ErrAmbiguousModule = errors.New("module selector matches more than one module")
+
+	// ErrUnsupportedModulePath indicates a module path cannot be given a /vN suffix
+	ErrUnsupportedModulePath = errors.New("unsupported module path for major version migration")
+
+	// ErrInvalidMajorVersion indicates a requested major version is not above the current one
+	ErrInvalidMajorVersion = errors.New("major version must be greater than the current major version")
+
+	// ErrLocalReplace indicates go.mod has a replace directive pointing at a local directory
+	ErrLocalReplace = errors.New("go.mod has a local path replace directive")
+
+	// ErrDevModeActive indicates `gomion dev on` changes must be removed before releasing
+	ErrDevModeActive = errors.New("dev mode is on; run `gomion dev off` first")
+
+	// ErrReleaseInProgress indicates an earlier release must be resumed or aborted first
+	ErrReleaseInProgress = errors.New("release in progress; run `gomion release --resume` or `gomion release --abort`")
+
+	// ErrNoReleaseInProgress indicates there is no release journal to resume or abort
+	ErrNoReleaseInProgress = errors.New("no release in progress")
+
+	// ErrReleaseJournal indicates a failure reading or writing a release journal
+	ErrReleaseJournal = errors.New("release journal error")
+
+	// ErrReleaseStep indicates a step of a release failed; the release can be resumed
+	ErrReleaseStep = errors.New("release step failed")
+
+	// ErrReleaseVerify indicates a released version did not resolve through the module proxy
+	ErrReleaseVerify = errors.New("released version does not resolve through the module proxy")
+
+	// ErrConflictingReleaseFlags indicates --resume and --abort were both requested
+	ErrConflictingReleaseFlags = errors.New("cannot both resume and abort a release")
+
+	// ErrVersionNotTagged indicates none of the versions to retract were ever tagged
+	ErrVersionNotTagged = errors.New("no tag found for the versions to retract")
+
+	// ErrAlreadyRetracted indicates go.mod already retracts the versions
+	ErrAlreadyRetracted = errors.New("versions are already retracted")
+
+	// ErrGoModChanged indicates go.mod has uncommitted changes a commit of its own would sweep up
+	ErrGoModChanged = errors.New("go.mod has uncommitted changes")
+
+	// ErrInvalidOutputFormat indicates an output format the command does not support
+	ErrInvalidOutputFormat = errors.New("invalid output format")
+
+	// ErrInconsistentTags indicates `check tags` found tags go get cannot resolve as intended
+	ErrInconsistentTags = errors.New("tags inconsistent with module paths")
+
+	// ErrTestsFailed indicates `go test` failed in at least one module
+	ErrTestsFailed = errors.New("tests failed")
+
+	// ErrInvalidRunTask indicates `run` was given a task other than test, vet, build or lint
+	ErrInvalidRunTask = errors.New("invalid run task")
+
+	// ErrLinterNotFound indicates `run lint` found no golangci-lint to run
+	ErrLinterNotFound = errors.New("golangci-lint not found")
+
+	// ErrRunFailed indicates `run` failed in at least one module
+	ErrRunFailed = errors.New("run failed")
+
+	// ErrWorkspaceNotFound indicates the root config has no workspace by the given name
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+
+	// ErrWorkspaceExists indicates a workspace by the given name is already configured
+	ErrWorkspaceExists = errors.New("workspace already exists")
+
+	// ErrNotInWorkspace indicates a directory is outside every repo of the selected workspace
+	ErrNotInWorkspace = errors.New("directory is not in the workspace")
+)
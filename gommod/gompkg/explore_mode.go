@@ -6,6 +6,7 @@ import (
 	"github.com/mikeschinkel/go-cliutil"
 	"github.com/mikeschinkel/go-cliutil/climenu"
 	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
 )
 
 // NewExploreMode creates the Explore mode (F3)
@@ -87,8 +88,8 @@ func (m *exploreMode) handleTests(args *climenu.OptionHandlerArgs) (err error) {
 		goto end
 	}
 
-	// TODO: Display test-related changes from m.AnalysisResults
-	m.Writer.Printf("Test changes display not yet implemented.\n")
+	m.Writer.Printf("%s", m.AnalysisResults.Tests.AnalysisSummary(goutils.ANSIEscapedFormat))
+	m.Writer.Printf("%s", m.AnalysisResults.Coverage.AnalysisSummary(goutils.ANSIEscapedFormat))
 
 end:
 	return err
@@ -0,0 +1,281 @@
+package gompkg
+
+import (
+	"context"
+	"encoding/json/jsontext"
+	jsonv2 "encoding/json/v2"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+)
+
+// GraphArgs contains the input parameters for Graph
+type GraphArgs struct {
+	// StartDir is any directory inside the starting repo (defaults to ".")
+	StartDir string
+
+	// Format is AsciiOutputFormat (the default), DotOutputFormat,
+	// MermaidOutputFormat or JSONOutputFormat
+	Format OutputFormat
+
+	// Tree controls how modules are labeled
+	Tree TreeOptions
+
+	Config *Config
+	Logger *slog.Logger
+	Writer cliutil.Writer
+}
+
+// GraphNode is a module in a DependencyGraph
+type GraphNode struct {
+	ModulePath   ModulePath      `json:"module_path"`
+	Label        string          `json:"label"`
+	RepoRoot     dt.DirPath      `json:"repo_root"`
+	RelDir       dt.PathSegments `json:"rel_dir"`
+	Kind         string          `json:"kind"`
+	InFlux       bool            `json:"in_flux"`
+	InFluxReason string          `json:"in_flux_reason,omitempty"`
+}
+
+// GraphEdge is a require from one module in a DependencyGraph to another
+type GraphEdge struct {
+	From ModulePath `json:"from"`
+	To   ModulePath `json:"to"`
+
+	// Tool is true if From requires To only to provide tools
+	Tool bool `json:"tool,omitempty"`
+}
+
+// DependencyGraph is the exportable form of the managed modules and the
+// requires between them
+type DependencyGraph struct {
+	Modules []GraphNode `json:"modules"`
+	Edges   []GraphEdge `json:"edges"`
+}
+
+// GraphResult contains the outcome of Graph
+type GraphResult struct {
+	Graph *DependencyGraph
+
+	// Output is Graph rendered in the requested format
+	Output string
+}
+
+// Graph discovers the managed modules of the repo containing StartDir and the
+// repos it requires, evaluates which are in-flux and renders their dependency
+// graph in the requested format
+func Graph(ctx context.Context, args GraphArgs) (result *GraphResult, err error) {
+	var ms *ModuleSet
+	var inFlux map[ModulePath]string
+
+	result = &GraphResult{}
+
+	if args.StartDir == "" {
+		args.StartDir = "."
+	}
+	if args.Format == "" {
+		args.Format = AsciiOutputFormat
+	}
+
+	ms, err = DiscoverModules(args.StartDir)
+	if err != nil {
+		goto end
+	}
+
+	inFlux, err = moduleSetInFlux(ctx, ms, args)
+	if err != nil {
+		goto end
+	}
+	args.Tree.InFlux = inFlux
+
+	result.Graph = newDependencyGraph(ms, args.Tree)
+
+	switch args.Format {
+	case AsciiOutputFormat:
+		result.Output, err = ms.RenderTree(args.Tree)
+	case DotOutputFormat:
+		result.Output = result.Graph.DOT()
+	case MermaidOutputFormat:
+		result.Output = result.Graph.Mermaid()
+	case JSONOutputFormat:
+		result.Output = result.Graph.JSON() + "\n"
+	default:
+		err = NewErr(ErrInvalidOutputFormat, "format", args.Format)
+	}
+
+end:
+	if err != nil {
+		err = WithErr(err, "start_dir", args.StartDir)
+	}
+	return result, err
+}
+
+// moduleSetInFlux returns the in-flux reason of each module in ms that is
+// in-flux, evaluated the same way the ReleaseEngine does
+func moduleSetInFlux(ctx context.Context, ms *ModuleSet, args GraphArgs) (inFlux map[ModulePath]string, err error) {
+	var goModFiles []dt.Filepath
+	var graph *goutils.ModuleGraph
+	var cache *inFluxCache
+	var moduleDirs []goutils.ModuleDir
+	var status InFluxStatus
+	var concurrency int
+
+	inFlux = make(map[ModulePath]string)
+	if len(ms.Modules) == 0 {
+		goto end
+	}
+
+	for _, m := range ms.Modules {
+		moduleDirs = append(moduleDirs, m.Dir())
+		goModFiles = append(goModFiles, dt.FilepathJoin(m.Dir(), "go.mod"))
+	}
+
+	graph = goutils.NewGraph(ms.Modules[0].RepoRoot, goModFiles, goutils.ModuleGraphArgs{
+		ToolDepsInFlux: args.Config != nil && args.Config.ToolDepsInFlux,
+		Logger:         args.Logger,
+		Writer:         args.Writer,
+	})
+	err = graph.Build()
+	if err != nil {
+		goto end
+	}
+
+	if args.Config != nil {
+		concurrency = args.Config.Concurrency
+	}
+	cache = newInFluxCache(args.Logger)
+	cache.Refresh(graph)
+	err = cache.Prewarm(ctx, moduleDirs, concurrency)
+	if err != nil {
+		goto end
+	}
+	for _, m := range ms.Modules {
+		module, ok := graph.ModulesByModuleDir[m.Dir()]
+		if !ok {
+			continue
+		}
+		status, err = cache.Status(ctx, module)
+		if err != nil {
+			goto end
+		}
+		if status.InFlux() {
+			inFlux[m.ModulePath] = status.Reason
+		}
+	}
+
+end:
+	return inFlux, err
+}
+
+// newDependencyGraph builds the exportable graph of ms, ordered by module path
+func newDependencyGraph(ms *ModuleSet, opts TreeOptions) (g *DependencyGraph) {
+	var modules []*Module
+
+	g = &DependencyGraph{
+		Modules: make([]GraphNode, 0, len(ms.Modules)),
+		Edges:   make([]GraphEdge, 0),
+	}
+
+	modules = slices.Clone(ms.Modules)
+	slices.SortFunc(modules, func(a, b *Module) int {
+		return strings.Compare(string(a.ModulePath), string(b.ModulePath))
+	})
+
+	for _, m := range modules {
+		reason, inFlux := opts.InFlux[m.ModulePath]
+		g.Modules = append(g.Modules, GraphNode{
+			ModulePath:   m.ModulePath,
+			Label:        ms.buildNodeLabel(m, opts),
+			RepoRoot:     m.RepoRoot,
+			RelDir:       m.RelDir,
+			Kind:         m.Kind.String(),
+			InFlux:       inFlux,
+			InFluxReason: reason,
+		})
+		for _, dep := range m.Requires {
+			g.Edges = append(g.Edges, GraphEdge{From: m.ModulePath, To: dep})
+		}
+		for _, dep := range m.Tools {
+			g.Edges = append(g.Edges, GraphEdge{From: m.ModulePath, To: dep, Tool: true})
+		}
+	}
+	return g
+}
+
+// DOT renders the graph in Graphviz DOT, filling in-flux modules and dashing
+// tool edges
+func (g *DependencyGraph) DOT() string {
+	var sb strings.Builder
+
+	sb.WriteString("digraph modules {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=box];\n")
+	for _, n := range g.Modules {
+		attrs := fmt.Sprintf("label=%q", n.Label)
+		if n.InFlux {
+			attrs += `, style=filled, fillcolor="#ffd966"`
+		}
+		fmt.Fprintf(&sb, "  %q [%s];\n", n.ModulePath, attrs)
+	}
+	for _, e := range g.Edges {
+		if e.Tool {
+			fmt.Fprintf(&sb, "  %q -> %q [style=dashed, label=\"tool\"];\n", e.From, e.To)
+			continue
+		}
+		fmt.Fprintf(&sb, "  %q -> %q;\n", e.From, e.To)
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// Mermaid renders the graph as a Mermaid flowchart, styling in-flux modules
+// with the "influx" class and drawing tool edges dotted
+func (g *DependencyGraph) Mermaid() string {
+	var sb strings.Builder
+	var inFlux []string
+
+	ids := make(map[ModulePath]string, len(g.Modules))
+	sb.WriteString("graph LR\n")
+	for i, n := range g.Modules {
+		id := fmt.Sprintf("m%d", i)
+		ids[n.ModulePath] = id
+		fmt.Fprintf(&sb, "  %s[\"%s\"]\n", id, strings.ReplaceAll(n.Label, `"`, "#quot;"))
+		if n.InFlux {
+			inFlux = append(inFlux, id)
+		}
+	}
+	for _, e := range g.Edges {
+		if e.Tool {
+			fmt.Fprintf(&sb, "  %s -.->|tool| %s\n", ids[e.From], ids[e.To])
+			continue
+		}
+		fmt.Fprintf(&sb, "  %s --> %s\n", ids[e.From], ids[e.To])
+	}
+	if len(inFlux) > 0 {
+		sb.WriteString("  classDef influx fill:#ffd966,stroke:#b8860b\n")
+		fmt.Fprintf(&sb, "  class %s influx\n", strings.Join(inFlux, ","))
+	}
+	return sb.String()
+}
+
+// JSON returns the graph as indented JSON
+func (g *DependencyGraph) JSON() (jsonText string) {
+	var data []byte
+	var err error
+
+	data, err = jsonv2.Marshal(g, jsontext.WithIndent("  "))
+	if err != nil {
+		jsonText = "{}"
+		goto end
+	}
+
+	jsonText = string(data)
+
+end:
+	return jsonText
+}
@@ -0,0 +1,263 @@
+package gompkg
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gitutils"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+)
+
+// ImpactArgs contains the input parameters for AnalyzeImpact
+type ImpactArgs struct {
+	// StartDir is the directory to start scanning from (defaults to ".")
+	StartDir string
+
+	// Module selects the changed module by module path, last path element or
+	// directory (defaults to the module in StartDir)
+	Module string
+
+	Config *Config
+	Logger *slog.Logger
+	Writer cliutil.Writer
+}
+
+// AffectedModule lists the packages of one module affected by the changes
+type AffectedModule struct {
+	ModulePath goutils.ModulePath
+	ModuleDir  dt.DirPath
+
+	// Depth is 0 for the changed module and its distance as a dependent
+	// otherwise
+	Depth int
+
+	// Packages are the module's packages that contain a change or import a
+	// changed package, directly or transitively; empty if none are affected
+	Packages []goutils.PackagePath
+}
+
+// ImpactResult contains the outcome of AnalyzeImpact
+type ImpactResult struct {
+	ModulePath goutils.ModulePath
+	ModuleDir  dt.DirPath
+
+	// ChangedFiles are the module's uncommitted files, relative to its repo
+	ChangedFiles []dt.RelFilepath
+
+	// ChangedPackages are the module's packages containing changed files
+	ChangedPackages []goutils.PackagePath
+
+	// Modules lists the changed module then its local dependents in order of
+	// depth
+	Modules []AffectedModule
+
+	// WorkFile is a temporary go.work using every module in Modules so each
+	// sees the local changes; remove it with Cleanup
+	WorkFile dt.Filepath
+}
+
+// Env returns the environment entries that make the go command use WorkFile
+func (r *ImpactResult) Env() []string {
+	return []string{"GOWORK=" + string(r.WorkFile)}
+}
+
+// Cleanup removes the temporary workspace
+func (r *ImpactResult) Cleanup() {
+	if r.WorkFile == "" {
+		return
+	}
+	_ = r.WorkFile.Dir().RemoveAll()
+	r.WorkFile = ""
+}
+
+// AnalyzeImpact finds the packages affected by the uncommitted changes to a
+// module, in the module itself and in every local module that depends on it.
+// The changed files come from git status and are mapped to the packages they
+// belong to; a changed go.mod or go.sum affects every package of the module.
+// Affected packages are those importing a changed package, directly or
+// transitively, through the package import graph of all the modules loaded
+// against a temporary workspace. The caller must call Cleanup on the result.
+func AnalyzeImpact(ctx context.Context, args ImpactArgs) (result *ImpactResult, err error) {
+	var graph *goutils.ModuleGraph
+	var module *goutils.Module
+	var modules []*goutils.Module
+	var imports, moduleImports goutils.ImportGraph
+	var changedFiles []dt.Filepath
+	var modChanged bool
+	var affected []goutils.PackagePath
+
+	result = &ImpactResult{}
+
+	if args.StartDir == "" {
+		args.StartDir = "."
+	}
+
+	graph, err = LoadModuleGraph(LoadModuleGraphArgs{
+		StartDir: args.StartDir,
+		Config:   args.Config,
+		Logger:   args.Logger,
+		Writer:   args.Writer,
+	})
+	if err != nil {
+		goto end
+	}
+
+	module, err = selectModule(graph, args.StartDir, args.Module)
+	if err != nil {
+		goto end
+	}
+	result.ModulePath = module.Path
+	result.ModuleDir = module.Dir()
+
+	result.ChangedFiles, changedFiles, modChanged, err = changedModuleFiles(ctx, module)
+	if err != nil {
+		goto end
+	}
+
+	modules = []*goutils.Module{module}
+	result.Modules = []AffectedModule{{ModulePath: module.Path, ModuleDir: module.Dir()}}
+	walkDependents(graph, module, func(dep, _ *goutils.Module, depth int) {
+		modules = append(modules, dep)
+		result.Modules = append(result.Modules, AffectedModule{
+			ModulePath: dep.Path,
+			ModuleDir:  dep.Dir(),
+			Depth:      depth,
+		})
+	})
+
+	result.WorkFile, err = writeImpactWorkFile(ctx, modules)
+	if err != nil {
+		goto end
+	}
+	if len(changedFiles) == 0 {
+		goto end
+	}
+
+	imports = make(goutils.ImportGraph)
+	for _, m := range modules {
+		moduleImports, err = goutils.LoadImportGraph(ctx, m.Dir(), result.Env())
+		if err != nil {
+			goto end
+		}
+		if m == module {
+			// Only the changed module's own packages can hold its changed files
+			result.ChangedPackages = moduleImports.PackagesForFiles(changedFiles)
+			if modChanged {
+				result.ChangedPackages = moduleImports.ModulePackages(module.Path)
+			}
+		}
+		maps.Copy(imports, moduleImports)
+	}
+
+	affected = imports.Importers(result.ChangedPackages)
+	for i, am := range result.Modules {
+		for _, pkgPath := range affected {
+			node := imports[pkgPath]
+			if node != nil && node.Module == am.ModulePath {
+				result.Modules[i].Packages = append(result.Modules[i].Packages, pkgPath)
+			}
+		}
+	}
+
+end:
+	if err != nil {
+		result.Cleanup()
+		err = WithErr(err, "module", args.Module)
+	}
+	return result, err
+}
+
+// changedModuleFiles returns the uncommitted files in module's directory, both
+// relative to its repo and absolute, with untracked directories expanded to
+// their files, and whether its go.mod or go.sum changed
+func changedModuleFiles(ctx context.Context, module *goutils.Module) (relFiles []dt.RelFilepath, files []dt.Filepath, modChanged bool, err error) {
+	var repoDir dt.DirPath
+	var modRelPath dt.PathSegments
+	var repo *gitutils.Repo
+	var statusFiles []dt.RelFilepath
+
+	repoDir = module.Repo().DirPath
+	modRelPath, err = module.Dir().Rel(repoDir)
+	if err != nil {
+		goto end
+	}
+	repo, err = gitutils.Open(repoDir)
+	if err != nil {
+		goto end
+	}
+	statusFiles, err = repo.GetChangedFiles(ctx, &gitutils.StatusArgs{Path: modRelPath})
+	if err != nil {
+		goto end
+	}
+
+	for _, rel := range statusFiles {
+		if rel == "" {
+			continue
+		}
+		// Renames are reported as "old -> new"
+		if _, renamed, ok := strings.Cut(string(rel), " -> "); ok {
+			rel = dt.RelFilepath(renamed)
+		}
+		relFiles = append(relFiles, rel)
+		if !strings.HasSuffix(string(rel), "/") {
+			files = append(files, dt.FilepathJoin(repoDir, rel))
+			continue
+		}
+		// Git reports new directories rather than the files in them
+		err = filepath.WalkDir(filepath.Join(string(repoDir), string(rel)), func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				files = append(files, dt.Filepath(path))
+			}
+			return err
+		})
+		if err != nil {
+			goto end
+		}
+	}
+
+	for _, file := range files {
+		if file.Dir() == module.Dir() && (file.Base() == "go.mod" || file.Base() == "go.sum") {
+			modChanged = true
+		}
+	}
+	slices.Sort(relFiles)
+
+end:
+	return relFiles, files, modChanged, err
+}
+
+// writeImpactWorkFile writes a go.work using modules to a new temporary
+// directory
+func writeImpactWorkFile(ctx context.Context, modules []*goutils.Module) (workFile dt.Filepath, err error) {
+	var tempDir string
+	var args []string
+
+	tempDir, err = os.MkdirTemp("", "gomion-impact-*")
+	if err != nil {
+		goto end
+	}
+
+	workFile = dt.FilepathJoin(dt.DirPath(tempDir), GoWorkFile)
+
+	// GOWORK names the file so an inherited GOWORK cannot redirect the write
+	args = []string{"work", "init"}
+	for _, m := range modules {
+		args = append(args, string(m.Dir()))
+	}
+	_, err = goutils.RunGoEnv(ctx, dt.DirPath(tempDir), []string{"GOWORK=" + string(workFile)}, args...)
+
+end:
+	if err != nil && tempDir != "" {
+		_ = os.RemoveAll(tempDir)
+		workFile = ""
+	}
+	return workFile, err
+}
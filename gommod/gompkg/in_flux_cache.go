@@ -0,0 +1,250 @@
+package gompkg
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/mikeschinkel/gomion/gommod/gitutils"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+	"golang.org/x/sync/errgroup"
+)
+
+// inFluxKey identifies a module's in-flux status for a given repo state
+type inFluxKey struct {
+	moduleDir goutils.ModuleDir
+	repoState string
+}
+
+// inFluxEntry memoizes the in-flux evaluation of one inFluxKey
+type inFluxEntry struct {
+	once   sync.Once
+	status InFluxStatus
+	err    error
+}
+
+// repoStateEntry memoizes the opened git repo and its state for one repo dir
+type repoStateEntry struct {
+	once  sync.Once
+	repo  *gitutils.Repo
+	state string
+}
+
+// inFluxCache memoizes in-flux evaluation so each module is checked once per
+// engine run no matter how many dependents ask about it. Entries are keyed by
+// the module dir plus its repo's HEAD, porcelain status and tags, observed once
+// per run, so a later run of the same engine reuses the entries of every repo
+// that has not changed since, e.g. when Release looks for the next leaf.
+type inFluxCache struct {
+	graph   *goutils.ModuleGraph
+	logger  *slog.Logger
+	mu      sync.Mutex
+	repos   map[goutils.RepoDir]*repoStateEntry
+	entries map[inFluxKey]*inFluxEntry
+}
+
+// newInFluxCache creates an empty cache; call Refresh before using it
+func newInFluxCache(logger *slog.Logger) *inFluxCache {
+	return &inFluxCache{
+		logger:  logger,
+		repos:   make(map[goutils.RepoDir]*repoStateEntry),
+		entries: make(map[inFluxKey]*inFluxEntry),
+	}
+}
+
+// Refresh starts a new run against graph, forgetting the observed repo states
+// so each is read again while keeping the entries they key
+func (c *inFluxCache) Refresh(graph *goutils.ModuleGraph) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.graph = graph
+	c.repos = make(map[goutils.RepoDir]*repoStateEntry)
+
+	// Without an observed state nothing shows the repo is unchanged
+	for key := range c.entries {
+		if key.repoState == "" {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// Status returns the in-flux status of module, evaluating it on first request
+func (c *inFluxCache) Status(ctx context.Context, module *goutils.Module) (status InFluxStatus, err error) {
+	var rs *repoStateEntry
+	var entry *inFluxEntry
+	var repoDir goutils.RepoDir
+
+	repo, ok := c.graph.ReposByModuleDir[module.Dir()]
+	if ok {
+		repoDir = repo.DirPath
+	}
+	rs = c.repoState(ctx, repoDir)
+
+	c.mu.Lock()
+	key := inFluxKey{moduleDir: module.Dir(), repoState: rs.state}
+	entry, ok = c.entries[key]
+	if !ok {
+		entry = &inFluxEntry{}
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+
+	entry.once.Do(func() {
+		entry.status, entry.err = c.evaluate(ctx, module, rs.repo)
+	})
+	status, err = entry.status, entry.err
+
+	// A cancelled evaluation says nothing about the module, so forget it and let
+	// the next request evaluate again
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		c.mu.Lock()
+		if c.entries[key] == entry {
+			delete(c.entries, key)
+		}
+		c.mu.Unlock()
+	}
+
+	return status, err
+}
+
+// Prewarm evaluates the in-flux status of moduleDirs using a bounded pool of
+// workers, one repo per worker at a time. Evaluation errors are cached for the
+// caller to report; only cancellation of ctx is returned.
+func (c *inFluxCache) Prewarm(ctx context.Context, moduleDirs []goutils.ModuleDir, concurrency int) (err error) {
+	var group *errgroup.Group
+	var repoOrder []goutils.RepoDir
+
+	dirsByRepo := make(map[goutils.RepoDir][]goutils.ModuleDir)
+
+	if concurrency <= 0 {
+		concurrency = runtime.GOMAXPROCS(0)
+	}
+
+	for _, modDir := range moduleDirs {
+		repo, ok := c.graph.ReposByModuleDir[modDir]
+		if !ok {
+			continue
+		}
+		_, ok = dirsByRepo[repo.DirPath]
+		if !ok {
+			repoOrder = append(repoOrder, repo.DirPath)
+		}
+		dirsByRepo[repo.DirPath] = append(dirsByRepo[repo.DirPath], modDir)
+	}
+
+	group, ctx = errgroup.WithContext(ctx)
+	group.SetLimit(concurrency)
+	for _, repoDir := range repoOrder {
+		group.Go(func() (err error) {
+			for _, modDir := range dirsByRepo[repoDir] {
+				err = ctx.Err()
+				if err != nil {
+					goto end
+				}
+				module, ok := c.graph.ModulesByModuleDir[modDir]
+				if !ok {
+					continue
+				}
+				_, err = c.Status(ctx, module)
+				if err != nil && c.logger != nil {
+					c.logger.Warn("Error checking in-flux status", "module", modDir, "error", err)
+				}
+				err = nil
+			}
+		end:
+			return err
+		})
+	}
+	err = group.Wait()
+
+	return err
+}
+
+// evaluate performs the uncached in-flux checks for module
+func (c *inFluxCache) evaluate(ctx context.Context, module *goutils.Module, repo *gitutils.Repo) (status InFluxStatus, err error) {
+	var moduleExt *ModuleExt
+
+	err = ctx.Err()
+	if err != nil {
+		goto end
+	}
+
+	// Wrap base module in ModuleExt to access InFluxStatus
+	moduleExt = &ModuleExt{Module: module}
+	err = moduleExt.SetGraph(c.graph)
+	if err != nil {
+		goto end
+	}
+	if repo != nil {
+		moduleExt.SetGitRepo(repo)
+	}
+
+	status, err = moduleExt.InFluxStatus(ctx)
+	if err != nil {
+		goto end
+	}
+
+	// Log reason if in-flux and logger available
+	if status.InFlux() && c.logger != nil {
+		c.logger.Debug("Module is in-flux",
+			"module", module.Dir(),
+			"reason", status.Reason)
+	}
+
+end:
+	return status, err
+}
+
+// repoState opens the repo at repoDir once per run and records its HEAD,
+// porcelain status and tags, the last since tagging a module changes whether it
+// has untagged commits. Repos that cannot be opened get an empty state and no
+// repo, leaving ModuleExt to skip its git checks as usual.
+func (c *inFluxCache) repoState(ctx context.Context, repoDir goutils.RepoDir) (rs *repoStateEntry) {
+	var ok bool
+
+	c.mu.Lock()
+	rs, ok = c.repos[repoDir]
+	if !ok {
+		rs = &repoStateEntry{}
+		c.repos[repoDir] = rs
+	}
+	c.mu.Unlock()
+
+	rs.once.Do(func() {
+		var repo *gitutils.Repo
+		var head string
+		var status string
+		var tags []string
+		var err error
+
+		if repoDir == "" {
+			return
+		}
+		repo, err = gitutils.Open(repoDir)
+		if err != nil {
+			return
+		}
+		rs.repo = repo
+		head, err = repo.RevParse("HEAD")
+		if err != nil {
+			return
+		}
+		status, err = repo.Status(ctx, nil)
+		if err != nil {
+			return
+		}
+		tags, err = repo.AllTags(ctx)
+		if err != nil {
+			return
+		}
+		sum := sha256.Sum256([]byte(head + "\n" + status + "\n" + strings.Join(tags, "\n")))
+		rs.state = hex.EncodeToString(sum[:])
+	})
+
+	return rs
+}
@@ -0,0 +1,41 @@
+package gompkg
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+)
+
+func TestInFluxCacheForgetsCancelledEvaluations(t *testing.T) {
+	dir := t.TempDir()
+	git(t, dir, "init", "--quiet", "--initial-branch=main")
+	file := writeGoMod(t, filepath.Join(dir, "lib"), "module example.com/lib\n\ngo 1.25\n")
+	graph := goutils.NewGraph(dt.DirPath(dir), []dt.Filepath{file}, goutils.ModuleGraphArgs{})
+	err := graph.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var module *goutils.Module
+	for _, m := range graph.ModulesByModuleDir {
+		module = m
+	}
+
+	cache := newInFluxCache(nil)
+	cache.Refresh(graph)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	_, err = cache.Status(ctx, module)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, want context.Canceled", err)
+	}
+
+	_, err = cache.Status(t.Context(), module)
+	if err != nil {
+		t.Errorf("got error %v after cancellation, want the module evaluated again", err)
+	}
+}
@@ -0,0 +1,98 @@
+package gompkg
+
+import (
+	"fmt"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+)
+
+// LocalReplace is a committed replace directive that points at a relative or
+// absolute directory, which makes its module unreleasable
+type LocalReplace struct {
+	// GoMod is the go.mod file containing the directive
+	GoMod dt.Filepath `json:"go_mod"`
+
+	// Line is the 1-based line of the directive in GoMod
+	Line int `json:"line"`
+
+	// Directive is the replace directive as written, e.g. "replace x => ../x"
+	Directive string `json:"directive"`
+
+	// TargetDir is the absolute directory the replace points at
+	TargetDir dt.DirPath `json:"target_dir"`
+
+	// TargetModule is the module found in TargetDir, or empty if there is none
+	TargetModule goutils.ModulePath `json:"target_module"`
+}
+
+// String returns the location and text of the directive along with its target
+func (r LocalReplace) String() string {
+	target := string(r.TargetModule)
+	if target == "" {
+		target = "no module"
+	}
+	return fmt.Sprintf("%s:%d: %s (points to %s)", r.GoMod, r.Line, r.Directive, target)
+}
+
+// LocalReplaces returns the local-path replace directives in this module's
+// go.mod, resolving the module each one points to
+func (m *ModuleExt) LocalReplaces() (replaces []LocalReplace) {
+	for _, rep := range m.Module.LocalReplaces() {
+		lr := LocalReplace{
+			GoMod:     m.Filepath,
+			Line:      rep.Line,
+			Directive: rep.String(),
+			TargetDir: localReplaceTargetDir(m.Dir(), rep.New.Path),
+		}
+		lr.TargetModule = m.localReplaceTargetModule(lr.TargetDir)
+		replaces = append(replaces, lr)
+	}
+	return replaces
+}
+
+// localReplaceTargetModule returns the path of the module in dir, preferring the
+// graph and falling back to reading dir/go.mod
+func (m *ModuleExt) localReplaceTargetModule(dir dt.DirPath) (modulePath goutils.ModulePath) {
+	var target *goutils.Module
+
+	if m.graph != nil {
+		mod, ok := m.graph.ModulesByModuleDir[dir]
+		if ok {
+			modulePath = mod.Path
+			goto end
+		}
+	}
+
+	target = goutils.NewModule(dt.FilepathJoin(dir, "go.mod"))
+	if target.Load() != nil {
+		goto end
+	}
+	modulePath = target.Path
+
+end:
+	return modulePath
+}
+
+// localReplaceTargetDir resolves a replace directory relative to moduleDir
+func localReplaceTargetDir(moduleDir dt.DirPath, path goutils.ModulePath) (dir dt.DirPath) {
+	var err error
+
+	dir, err = dt.ParseDirPath(string(path))
+	if err != nil {
+		dir = dt.DirPath(path)
+	}
+	if !dir.IsAbs() {
+		dir = dt.DirPathJoin(moduleDir, dir)
+	}
+	return dir.Clean()
+}
+
+// localReplacesReason summarizes local replaces as an in-flux reason
+func localReplacesReason(replaces []LocalReplace) (reason string) {
+	reason = "local replace directive at " + replaces[0].String()
+	if len(replaces) > 1 {
+		reason += fmt.Sprintf(" and %d more", len(replaces)-1)
+	}
+	return reason
+}
@@ -0,0 +1,64 @@
+package gompkg
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckLocalReplaces(t *testing.T) {
+	root := t.TempDir()
+	writeGoMod(t, filepath.Join(root, "go-dt"), "module github.com/example/go-dt\n\ngo 1.25\n")
+
+	t.Run("RelativeReplaceBlocksRelease", func(t *testing.T) {
+		mod := loadModule(t, writeGoMod(t, filepath.Join(root, "app"), `module github.com/example/app
+
+go 1.25
+
+require github.com/example/go-dt v0.5.0
+
+replace github.com/example/go-dt => ../go-dt
+`))
+
+		replaces := (&ModuleExt{Module: mod}).LocalReplaces()
+		if len(replaces) != 1 {
+			t.Fatalf("got %d local replaces, want 1", len(replaces))
+		}
+		if replaces[0].Line != 7 {
+			t.Errorf("got line %d, want 7", replaces[0].Line)
+		}
+		if replaces[0].TargetModule != "github.com/example/go-dt" {
+			t.Errorf("got target module %q, want github.com/example/go-dt", replaces[0].TargetModule)
+		}
+
+		err := checkLocalReplaces(mod)
+		if !errors.Is(err, ErrLocalReplace) {
+			t.Fatalf("got error %v, want ErrLocalReplace", err)
+		}
+
+		blocked, reason, err := (&ModuleExt{Module: mod}).IsReleaseBlocked(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !blocked || !strings.HasPrefix(reason, "local replace directive at ") {
+			t.Errorf("got blocked=%t reason=%q, want blocked by the local replace", blocked, reason)
+		}
+	})
+
+	t.Run("VersionReplaceDoesNotBlockRelease", func(t *testing.T) {
+		mod := loadModule(t, writeGoMod(t, filepath.Join(root, "lib"), `module github.com/example/lib
+
+go 1.25
+
+require github.com/example/go-dt v0.5.0
+
+replace github.com/example/go-dt => github.com/fork/go-dt v0.5.1
+`))
+
+		err := checkLocalReplaces(mod)
+		if err != nil {
+			t.Fatalf("got error %v, want none", err)
+		}
+	})
+}
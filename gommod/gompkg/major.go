@@ -0,0 +1,426 @@
+package gompkg
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+	"golang.org/x/mod/module"
+)
+
+// MajorArgs contains the input parameters for Major
+type MajorArgs struct {
+	// ModuleDir is the directory of the module to migrate (defaults to ".")
+	ModuleDir string
+
+	// Major is the major version to migrate to; 0 means one more than the
+	// module's current major version
+	Major int
+
+	// Write applies the edits; otherwise Major only previews them
+	Write bool
+
+	Config *Config
+	Logger *slog.Logger
+	Writer cliutil.Writer
+}
+
+// MajorFileEdit is the new content planned for one file
+type MajorFileEdit struct {
+	File dt.Filepath
+	Old  []byte
+	New  []byte
+}
+
+// Diff returns a unified diff of the edit labelled relative to baseDir
+func (e MajorFileEdit) Diff(baseDir dt.DirPath) string {
+	name, err := filepath.Rel(string(baseDir), string(e.File))
+	if err != nil {
+		name = string(e.File)
+	}
+	return unifiedDiff(filepath.ToSlash(name), string(e.Old), string(e.New))
+}
+
+// MajorResult contains the outcome of Major
+type MajorResult struct {
+	// OldPath is the module path before migration, e.g. "github.com/x/foo"
+	OldPath goutils.ModulePath
+
+	// NewPath is the module path after migration, e.g. "github.com/x/foo/v2"
+	NewPath goutils.ModulePath
+
+	// Version is the first version of NewPath dependents are pointed at
+	Version dt.Version
+
+	// ModuleDir is the migrated module's directory
+	ModuleDir dt.DirPath
+
+	// Dependents lists the local modules whose requires and imports are rewritten
+	Dependents []goutils.ModulePath
+
+	// Edits lists every file changed, module files first
+	Edits []MajorFileEdit
+
+	// Written is true if Edits were applied
+	Written bool
+}
+
+// Major migrates a module to a new /vN major version path: it rewrites the
+// module directive, every import of the module inside it, and for each local
+// dependent its require, replace and imports. All edits are planned in memory
+// and only written if args.Write is set so they can be previewed first; if any
+// write fails the files already written are restored.
+func Major(ctx context.Context, args MajorArgs) (result *MajorResult, err error) {
+	var graph *goutils.ModuleGraph
+	var mod *goutils.Module
+	var moduleDir dt.DirPath
+	var edits []MajorFileEdit
+	var errs []error
+
+	result = &MajorResult{}
+
+	if args.ModuleDir == "" {
+		args.ModuleDir = "."
+	}
+
+	moduleDir, err = dt.ParseDirPath(args.ModuleDir)
+	if err != nil {
+		goto end
+	}
+
+	graph, err = LoadModuleGraph(LoadModuleGraphArgs{
+		StartDir: args.ModuleDir,
+		Config:   args.Config,
+		Logger:   args.Logger,
+		Writer:   args.Writer,
+	})
+	if err != nil {
+		goto end
+	}
+
+	mod, err = moduleInDir(graph, moduleDir)
+	if err != nil {
+		goto end
+	}
+	result.OldPath = mod.Path
+	result.ModuleDir = mod.Dir()
+
+	result.NewPath, result.Version, err = majorModulePath(mod.Path, args.Major)
+	if err != nil {
+		goto end
+	}
+
+	edits, err = planModuleMajor(mod, result.OldPath, result.NewPath)
+	if err != nil {
+		goto end
+	}
+	result.Edits = append(result.Edits, edits...)
+
+	for _, dep := range majorDependents(graph, result.OldPath, result.ModuleDir) {
+		err = ctx.Err()
+		if err != nil {
+			goto end
+		}
+		edits, err = planDependentMajor(dep, result.OldPath, result.NewPath, result.Version)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		result.Dependents = append(result.Dependents, dep.Path)
+		result.Edits = append(result.Edits, edits...)
+	}
+	err = CombineErrs(errs)
+	if err != nil {
+		goto end
+	}
+
+	if !args.Write {
+		goto end
+	}
+
+	err = writeMajorEdits(result.Edits)
+	result.Written = err == nil
+
+end:
+	if err != nil {
+		err = WithErr(err, "module_dir", args.ModuleDir)
+	}
+	return result, err
+}
+
+// writeMajorEdits writes each edit in order. If a write fails every file
+// written so far, and the one that failed, is restored to its old content so
+// the migration is never left half applied.
+func writeMajorEdits(edits []MajorFileEdit) (err error) {
+	var errs []error
+	var written int
+
+	for _, edit := range edits {
+		err = edit.File.WriteFile(edit.New, 0o644)
+		if err != nil {
+			err = WithErr(err, "file", edit.File)
+			break
+		}
+		written++
+	}
+	if err == nil {
+		goto end
+	}
+
+	errs = append(errs, err)
+	for _, edit := range edits[:min(written+1, len(edits))] {
+		rbErr := edit.File.WriteFile(edit.Old, 0o644)
+		if rbErr != nil {
+			errs = append(errs, NewErr(ErrFileWrite, "rollback_file", edit.File, rbErr))
+		}
+	}
+	err = CombineErrs(errs)
+
+end:
+	return err
+}
+
+// majorModulePath returns oldPath with its /vN suffix set to major, or to the
+// next major version if major is 0, along with the first version of that path
+func majorModulePath(oldPath goutils.ModulePath, major int) (newPath goutils.ModulePath, version dt.Version, err error) {
+	var prefix, pathMajor string
+	var current int
+	var ok bool
+
+	prefix, pathMajor, ok = module.SplitPathVersion(string(oldPath))
+	if !ok || strings.HasPrefix(string(oldPath), "gopkg.in/") {
+		err = NewErr(ErrUnsupportedModulePath, "module", oldPath)
+		goto end
+	}
+
+	current = 1
+	if pathMajor != "" {
+		current, err = strconv.Atoi(strings.TrimPrefix(pathMajor, "/v"))
+		if err != nil {
+			err = NewErr(ErrUnsupportedModulePath, "module", oldPath, err)
+			goto end
+		}
+	}
+	if major == 0 {
+		major = current + 1
+	}
+	if major <= current || major < 2 {
+		err = NewErr(ErrInvalidMajorVersion, "major", major, "current", current)
+		goto end
+	}
+
+	newPath = goutils.ModulePath(fmt.Sprintf("%s/v%d", prefix, major))
+	version = dt.Version(fmt.Sprintf("v%d.0.0", major))
+
+	err = module.CheckPath(string(newPath))
+	if err != nil {
+		err = NewErr(ErrUnsupportedModulePath, "module", newPath, err)
+	}
+
+end:
+	return newPath, version, err
+}
+
+// planModuleMajor plans the module directive change and import rewrites for the
+// migrated module itself
+func planModuleMajor(mod *goutils.Module, oldPath, newPath goutils.ModulePath) (edits []MajorFileEdit, err error) {
+	var edit MajorFileEdit
+
+	edit, err = planGoModEdit(mod, func() error {
+		return mod.SetModulePath(newPath)
+	})
+	if err != nil {
+		goto end
+	}
+	edits = append(edits, edit)
+
+	edits, err = appendImportEdits(edits, mod.Dir(), oldPath, newPath)
+
+end:
+	return edits, err
+}
+
+// planDependentMajor plans the require, replace and import rewrites for one
+// dependent of the migrated module
+func planDependentMajor(dep *goutils.Module, oldPath, newPath goutils.ModulePath, version dt.Version) (edits []MajorFileEdit, err error) {
+	var edit MajorFileEdit
+
+	edit, err = planGoModEdit(dep, func() (err error) {
+		err = dep.RenameRequire(oldPath, newPath, version)
+		if err != nil {
+			return err
+		}
+		return dep.RenameReplace(oldPath, newPath)
+	})
+	if err != nil {
+		goto end
+	}
+	edits = append(edits, edit)
+
+	edits, err = appendImportEdits(edits, dep.Dir(), oldPath, newPath)
+
+end:
+	if err != nil {
+		err = WithErr(err, "dependent", dep.Path)
+	}
+	return edits, err
+}
+
+// planGoModEdit applies change to mod in memory and returns the resulting
+// go.mod edit without writing it
+func planGoModEdit(mod *goutils.Module, change func() error) (edit MajorFileEdit, err error) {
+	edit.File = mod.Filepath
+
+	edit.Old, err = mod.Filepath.ReadFile()
+	if err != nil {
+		goto end
+	}
+
+	err = change()
+	if err != nil {
+		goto end
+	}
+
+	edit.New, err = mod.Format()
+
+end:
+	return edit, err
+}
+
+// appendImportEdits appends an edit for each Go file of the module in dir that
+// imports oldPath or one of its packages
+func appendImportEdits(edits []MajorFileEdit, dir dt.DirPath, oldPath, newPath goutils.ModulePath) (_ []MajorFileEdit, err error) {
+	err = goutils.WalkGoFiles(dir, func(_ string, file dt.Filepath) error {
+		edit, changed, err := rewriteImports(file, oldPath, newPath)
+		if err != nil {
+			return err
+		}
+		if changed {
+			edits = append(edits, edit)
+		}
+		return nil
+	})
+	return edits, err
+}
+
+// rewriteImports replaces imports of oldPath and its packages in file with
+// newPath, editing only the import path literals so formatting is preserved
+func rewriteImports(file dt.Filepath, oldPath, newPath goutils.ModulePath) (edit MajorFileEdit, changed bool, err error) {
+	var imports []importLiteral
+	var sb strings.Builder
+	var last int
+
+	edit.File = file
+	edit.Old, err = file.ReadFile()
+	if err != nil {
+		goto end
+	}
+
+	imports, err = parseImports(file, edit.Old)
+	if err != nil {
+		goto end
+	}
+
+	for _, imp := range imports {
+		importPath, uqErr := strconv.Unquote(imp.literal)
+		if uqErr != nil {
+			continue
+		}
+		rest, ok := strings.CutPrefix(importPath, string(oldPath))
+		if !ok || (rest != "" && !strings.HasPrefix(rest, "/")) {
+			continue
+		}
+		sb.Write(edit.Old[last:imp.offset])
+		sb.WriteString(strconv.Quote(string(newPath) + rest))
+		last = imp.offset + len(imp.literal)
+		changed = true
+	}
+	if !changed {
+		goto end
+	}
+	sb.Write(edit.Old[last:])
+	edit.New = []byte(sb.String())
+
+end:
+	return edit, changed, err
+}
+
+// importLiteral is an import path literal and its byte offset in the file
+type importLiteral struct {
+	literal string
+	offset  int
+}
+
+// parseImports parses only the import declarations of src and returns their
+// path literals in file order
+func parseImports(file dt.Filepath, src []byte) (imports []importLiteral, err error) {
+	var f *ast.File
+
+	fset := token.NewFileSet()
+	f, err = parser.ParseFile(fset, string(file), src, parser.ImportsOnly)
+	if err != nil {
+		goto end
+	}
+
+	for _, spec := range f.Imports {
+		imports = append(imports, importLiteral{
+			literal: spec.Path.Value,
+			offset:  fset.Position(spec.Path.Pos()).Offset,
+		})
+	}
+	sort.Slice(imports, func(i, j int) bool {
+		return imports[i].offset < imports[j].offset
+	})
+
+end:
+	return imports, err
+}
+
+// majorDependents returns the local modules that require modulePath, from both
+// the scan-dir module graph and the repos discovered through .gomion requires
+// starting from moduleDir
+func majorDependents(graph *goutils.ModuleGraph, modulePath goutils.ModulePath, moduleDir dt.DirPath) (deps []*goutils.Module) {
+	var ms *ModuleSet
+	var err error
+
+	seen := make(map[dt.DirPath]bool)
+	for _, dep := range graph.Dependents(modulePath) {
+		seen[dep.Dir()] = true
+		deps = append(deps, dep)
+	}
+
+	ms, err = DiscoverModules(string(moduleDir))
+	if err != nil {
+		// Repos without .gomion config rely on the scan dirs alone
+		goto end
+	}
+	for _, m := range ms.Modules {
+		if !slices.Contains(m.Requires, ModulePath(modulePath)) {
+			continue
+		}
+		dir := dt.DirPathJoin(m.RepoRoot, m.RelDir.TrimPrefix("./"))
+		if seen[dir] {
+			continue
+		}
+		dep := goutils.NewModule(dt.FilepathJoin(dir, "go.mod"))
+		if dep.Load() != nil {
+			continue
+		}
+		seen[dir] = true
+		deps = append(deps, dep)
+	}
+
+end:
+	return deps
+}
@@ -0,0 +1,42 @@
+package gompkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+func TestWriteMajorEditsRollsBack(t *testing.T) {
+	dir := t.TempDir()
+	goMod := dt.Filepath(filepath.Join(dir, "go.mod"))
+	err := os.WriteFile(string(goMod), []byte("module github.com/example/foo\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = writeMajorEdits([]MajorFileEdit{
+		{
+			File: goMod,
+			Old:  []byte("module github.com/example/foo\n"),
+			New:  []byte("module github.com/example/foo/v2\n"),
+		},
+		{
+			// The missing directory makes this write fail
+			File: dt.Filepath(filepath.Join(dir, "missing", "foo.go")),
+			New:  []byte("package foo\n"),
+		},
+	})
+	if err == nil {
+		t.Fatal("got no error writing into a missing directory")
+	}
+
+	content, err := os.ReadFile(string(goMod))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "module github.com/example/foo\n" {
+		t.Errorf("got go.mod %q, want it restored", content)
+	}
+}
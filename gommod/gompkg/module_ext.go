@@ -2,6 +2,7 @@ package gompkg
 
 import (
 	"context"
+	"errors"
 
 	"github.com/mikeschinkel/go-dt"
 	"github.com/mikeschinkel/gomion/gommod/gitutils"
@@ -11,8 +12,9 @@ import (
 // ModuleExt wraps goutils.Module with Gomion-specific functionality
 type ModuleExt struct {
 	*goutils.Module
-	graph *goutils.ModuleGraph // Base graph from goutils
-	repo  *goutils.Repo        // Base repo from goutils
+	graph   *goutils.ModuleGraph // Base graph from goutils
+	repo    *goutils.Repo        // Base repo from goutils
+	gitRepo *gitutils.Repo       // Opened lazily unless shared via SetGitRepo
 }
 
 // NewModuleExt creates a new ModuleExt wrapping a goutils.Module
@@ -44,34 +46,120 @@ func (m *ModuleExt) Repo() *goutils.Repo {
 	return m.repo
 }
 
+// SetGitRepo shares an already opened git repo so checks on sibling modules
+// don't each re-open it
+func (m *ModuleExt) SetGitRepo(repo *gitutils.Repo) {
+	m.gitRepo = repo
+}
+
+// openGitRepo returns the git repo containing this module, opening it on first use
+func (m *ModuleExt) openGitRepo() (repo *gitutils.Repo, err error) {
+	if m.gitRepo != nil {
+		repo = m.gitRepo
+		goto end
+	}
+	repo, err = gitutils.Open(m.Repo().DirPath)
+	if err != nil {
+		goto end
+	}
+	m.gitRepo = repo
+end:
+	return repo, err
+}
+
 func (m *ModuleExt) chkSetGraph(funcName string) {
 	if m.repo == nil {
 		panic("ERROR: Must call ModuleExt.SetGraph() before calling ModuleExt." + funcName + "()")
 	}
 }
 
+// InFluxStatus describes whether and why a module is in-flux
+type InFluxStatus struct {
+	// Blocked is true if the module cannot be released (see IsReleaseBlocked)
+	Blocked bool
+
+	// Untagged is true if the module has commits not yet tagged
+	Untagged bool
+
+	// LocalReplaces lists committed local-path replace directives, which block
+	// release regardless of any other state
+	LocalReplaces []LocalReplace
+
+	// Reason explains why the module is in-flux
+	Reason string
+}
+
+// InFlux returns true if the module is not ready to be depended upon
+func (s InFluxStatus) InFlux() bool {
+	return s.Blocked || s.Untagged
+}
+
 // IsInFlux checks if this module is in-flux (not ready for release)
 // Returns: inFlux bool, reason string, error
 // A module is in-flux if:
+// - It is release-blocked (see IsReleaseBlocked)
+// - Has commits since its latest tag, or has never been tagged
+// - Tagged but not pushed (handled separately in engine)
+func (m *ModuleExt) IsInFlux(ctx context.Context) (inFlux bool, reason string, err error) {
+	var status InFluxStatus
+
+	status, err = m.InFluxStatus(ctx)
+	return status.InFlux(), status.Reason, err
+}
+
+// InFluxStatus performs the checks behind IsInFlux and reports which of them
+// made the module in-flux
+func (m *ModuleExt) InFluxStatus(ctx context.Context) (status InFluxStatus, err error) {
+	status.LocalReplaces = m.LocalReplaces()
+	status.Blocked, status.Reason, err = m.IsReleaseBlocked(ctx)
+	if err != nil || status.Blocked {
+		goto end
+	}
+
+	status.Untagged, err = m.HasUntaggedCommits(ctx)
+	if err != nil {
+		goto end
+	}
+	if status.Untagged {
+		status.Reason = "has commits not yet tagged"
+	}
+
+end:
+	return status, err
+}
+
+// IsReleaseBlocked checks if this module is in a state that cannot be released
+// Returns: blocked bool, reason string, error
+// A module is release-blocked if:
+// - Has relative or absolute path replace directives in go.mod
 // - Has in-flux dependencies (pseudo-versions, local replaces)
 // - Working tree is dirty (untracked/staged/unstaged files)
 // - Has replace directives in go.mod
-// - Not tagged or tagged but not pushed (handled separately in engine)
-func (m *ModuleExt) IsInFlux(ctx context.Context) (inFlux bool, reason string, err error) {
+func (m *ModuleExt) IsReleaseBlocked(ctx context.Context) (blocked bool, reason string, err error) {
 	var status goutils.Status
 	var isDirty bool
 	var repo *gitutils.Repo
+	var localReplaces []LocalReplace
+
+	// Local path replaces are checked first so the offending line is reported
+	// rather than the generic in-flux dependency they also cause
+	localReplaces = m.LocalReplaces()
+	if len(localReplaces) > 0 {
+		blocked = true
+		reason = localReplacesReason(localReplaces)
+		goto end
+	}
 
 	// Check dependency status via goutils
 	status = m.Module.AnalyzeStatus()
 	if status.InFlux {
-		inFlux = true
+		blocked = true
 		reason = "has in-flux dependencies"
 		goto end
 	}
 
 	// Check git dirty state for this specific module (excluding submodules)
-	repo, err = gitutils.Open(m.Repo().DirPath)
+	repo, err = m.openGitRepo()
 	if err != nil {
 		// If not a git repo, skip this check
 		err = nil
@@ -101,7 +189,7 @@ func (m *ModuleExt) IsInFlux(ctx context.Context) (inFlux bool, reason string, e
 		}
 
 		if isDirty {
-			inFlux = true
+			blocked = true
 			reason = "dirty working tree"
 			goto end
 		}
@@ -109,13 +197,64 @@ func (m *ModuleExt) IsInFlux(ctx context.Context) (inFlux bool, reason string, e
 
 	// Check for replace directives
 	if m.HasReplaceDirectives() {
-		inFlux = true
+		blocked = true
 		reason = "has replace directives"
 		goto end
 	}
 
 end:
-	return inFlux, reason, err
+	return blocked, reason, err
+}
+
+// HasUntaggedCommits checks if any commits touching this module have been made
+// since its latest reachable semver tag. A module that has never been tagged is
+// considered to have untagged commits.
+func (m *ModuleExt) HasUntaggedCommits(ctx context.Context) (untagged bool, err error) {
+	var repo *gitutils.Repo
+	var modRelPath dt.PathSegments
+	var headSHA string
+	var latestTag string
+	var count int
+
+	repo, err = m.openGitRepo()
+	if err != nil {
+		// If not a git repo, there is nothing to tag
+		err = nil
+		goto end
+	}
+
+	headSHA, err = repo.RevParse("HEAD")
+	if err != nil {
+		// No commits yet, so nothing to tag
+		err = nil
+		goto end
+	}
+
+	modRelPath, err = m.Dir().Rel(m.Repo().DirPath)
+	if err != nil {
+		goto end
+	}
+
+	latestTag, err = repo.LatestTag(ctx, headSHA, &gitutils.LatestTagArgs{
+		ModuleRelPath: modRelPath,
+	})
+	switch {
+	case errors.Is(err, gitutils.ErrNoSemverTags), errors.Is(err, gitutils.ErrNoReachableSemverTags):
+		untagged = true
+		err = nil
+		goto end
+	case err != nil:
+		goto end
+	}
+
+	count, err = repo.CommitCountSince(ctx, latestTag, modRelPath, m.getSubmodulePathsToExclude())
+	if err != nil {
+		goto end
+	}
+	untagged = count > 0
+
+end:
+	return untagged, err
 }
 
 // getSubmodulePathsToExclude returns paths of other modules in the same repo that should be excluded
@@ -0,0 +1,113 @@
+package gompkg
+
+import (
+	"log/slog"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/go-dt/dtx"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+)
+
+// LoadModuleGraphArgs contains the input parameters for LoadModuleGraph
+type LoadModuleGraphArgs struct {
+	// StartDir is any directory inside the starting repo (defaults to ".")
+	StartDir string
+
+	// RepoDirs overrides the config's scan_dirs when non-empty
+	RepoDirs []string
+
+	Config *Config
+	Logger *slog.Logger
+	Writer cliutil.Writer
+}
+
+// LoadModuleGraph builds the module graph for the repo containing StartDir and
+// every managed repo in the scan dirs, the same way the ReleaseEngine does
+func LoadModuleGraph(args LoadModuleGraphArgs) (graph *goutils.ModuleGraph, err error) {
+	var repoDir dt.DirPath
+	var repoDirsToScan []dt.DirPath
+	var goModFiles []dt.Filepath
+
+	if args.StartDir == "" {
+		args.StartDir = "."
+	}
+
+	repoDir, err = dt.ParseDirPath(args.StartDir)
+	if err != nil {
+		goto end
+	}
+
+	repoDir, err = repoDir.Clean().Abs()
+	if err != nil {
+		goto end
+	}
+
+	repoDir, err = FindRepoRoot(repoDir)
+	if err != nil {
+		goto end
+	}
+
+	repoDirsToScan, err = getRepoDirsToScan(PlanArgs{
+		RepoDirs: args.RepoDirs,
+		Config:   args.Config,
+	})
+	if err != nil {
+		goto end
+	}
+
+	// Always include the start repo in the scan
+	repoDirsToScan = append([]dt.DirPath{repoDir}, repoDirsToScan...)
+
+	goModFiles, err = FindGoModFiles[dt.Filepath](FindGoModFilesArgs{
+		DirPaths:       repoDirsToScan,
+		Config:         args.Config,
+		ContinueOnErr:  false,
+		SilenceErrs:    false,
+		SkipBehavior:   SkipUnmanaged,
+		MatchBehavior:  dtx.CollectOnMatch,
+		ParseEntryFunc: nil,
+		Logger:         args.Logger,
+		Writer:         args.Writer,
+	})
+	if err != nil {
+		goto end
+	}
+
+	graph = goutils.NewGraph(repoDir, goModFiles, goutils.ModuleGraphArgs{
+		ToolDepsInFlux: args.Config != nil && args.Config.ToolDepsInFlux,
+		Logger:         args.Logger,
+		Writer:         args.Writer,
+	})
+	err = graph.Build()
+
+end:
+	return graph, err
+}
+
+// moduleInDir returns the module in graph whose go.mod is in dir, or the module
+// containing dir if dir is a package directory below a module root
+func moduleInDir(graph *goutils.ModuleGraph, startDir dt.DirPath) (module *goutils.Module, err error) {
+	var dir dt.DirPath
+	var ok bool
+
+	dir, err = startDir.Clean().Abs()
+	if err != nil {
+		goto end
+	}
+
+	for {
+		module, ok = graph.ModulesByModuleDir[dir]
+		if ok {
+			goto end
+		}
+		if dir == dir.Dir() {
+			break
+		}
+		dir = dir.Dir()
+	}
+	err = NewErr(ErrGoModuleNotFound, "dir", startDir)
+
+end:
+	return module, err
+}
@@ -7,6 +7,15 @@ const (
 	TableOutputFormat OutputFormat = "table"
 	JSONOutputFormat  OutputFormat = "json"
 	CSVOutputFormat   OutputFormat = "csv"
+
+	// MarkdownOutputFormat is used for release notes rather than module listings
+	MarkdownOutputFormat OutputFormat = "markdown"
+
+	// AsciiOutputFormat, DotOutputFormat and MermaidOutputFormat are used for
+	// dependency graphs
+	AsciiOutputFormat   OutputFormat = "ascii"
+	DotOutputFormat     OutputFormat = "dot"
+	MermaidOutputFormat OutputFormat = "mermaid"
 )
 
 // String returns the string representation of OutputFormat
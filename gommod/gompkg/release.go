@@ -0,0 +1,545 @@
+package gompkg
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gitutils"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// FirstReleaseVersion is the version proposed for a module that has never been tagged
+const FirstReleaseVersion = "v0.1.0"
+
+// ReleaseArgs contains the input parameters for Release
+type ReleaseArgs struct {
+	// StartDir is the directory to start scanning from (typically current directory)
+	StartDir string
+
+	// Version overrides the version proposed from the verdict (optional)
+	Version string
+
+	// Prerelease cuts the next prerelease in the named series, e.g. "rc", instead
+	// of a final release (optional)
+	Prerelease string
+
+	// IgnorePrereleases uses the latest final release as the baseline rather
+	// than a later prerelease; Config.IgnorePrereleases sets it by default
+	IgnorePrereleases bool
+
+	// DryRun reports what would be tagged and pushed without doing either
+	DryRun bool
+
+	// VerifyProxy is the GOPROXY the released version must resolve through,
+	// VerifyProxyLocal (the default) for a file:// proxy built from the local
+	// repo or VerifyProxyOff to skip the check
+	VerifyProxy string
+
+	// Resume continues the release recorded in the repo's release journal from
+	// its last completed step
+	Resume bool
+
+	// Abort rolls back the release recorded in the repo's release journal,
+	// deleting its tag and undoing its tidy commit if they were not pushed yet
+	Abort bool
+
+	// Concurrency bounds how many repos are checked for in-flux status at once
+	// (defaults to Config.Concurrency, then GOMAXPROCS)
+	Concurrency int
+
+	Config *Config
+	Logger *slog.Logger
+	Writer cliutil.Writer
+}
+
+// ReleaseResult contains the outcome of releasing the leaf module
+type ReleaseResult struct {
+	// Leaf is the engine result used to select the module and its verdict
+	Leaf *EngineResult
+
+	// ModulePath is the Go module path of the released module
+	ModulePath goutils.ModulePath
+
+	// BaselineTag is the latest tag prior to this release (empty for a first release)
+	BaselineTag string
+
+	// Suggestions are the versions proposed from BaselineTag
+	Suggestions goutils.VersionSuggestions
+
+	// Version is the version being released, e.g. "v0.4.2"
+	Version string
+
+	// VersionWarning explains why Version may understate the changes, e.g. a
+	// likely_breaking verdict released as a compatible bump
+	VersionWarning string
+
+	// Tag is the module-prefixed tag name, e.g. "gommod/v0.4.2"
+	Tag string
+
+	// Commit is the SHA the tag points at
+	Commit string
+
+	// ReleaseCommit is the SHA of the commit of go mod tidy changes made before
+	// tagging, or empty if tidying changed nothing
+	ReleaseCommit string
+
+	// DryRun is true if nothing was actually tagged or pushed
+	DryRun bool
+
+	// VerifyProxy is the proxy the released version was verified to resolve
+	// through, or VerifyProxyOff
+	VerifyProxy string
+
+	// Resumed is true if an interrupted release was continued from its journal
+	Resumed bool
+
+	// Aborted is true if an interrupted release was rolled back
+	Aborted bool
+
+	// AbortNotes describe what rolling back undid or left in place
+	AbortNotes []string
+
+	// Bump is the outcome of bumping the released module's dependents
+	Bump *BumpResult
+
+	// Next is the engine result after releasing, or nil if nothing is left in-flux
+	Next *EngineResult
+}
+
+// Release tidies and tests the leaf module selected by the ReleaseEngine,
+// commits any tidy changes, tags it with the next version suggested by its
+// verdict, pushes the tag, bumps its dependents and then re-runs the engine to
+// find the next leaf. Each step is recorded in a journal in the module's
+// .gomion directory until the release completes so an interrupted release can
+// be resumed or aborted.
+func Release(ctx context.Context, args ReleaseArgs) (result *ReleaseResult, err error) {
+	var engine *ReleaseEngine
+	var leaf *EngineResult
+	var mod *goutils.Module
+	var pathMajor string
+	var repo *gitutils.Repo
+	var modRelPath dt.PathSegments
+	var existing []string
+	var devState *DevModeState
+	var journalStore *ReleaseJournalStore
+	var journal *ReleaseJournal
+
+	if args.VerifyProxy == "" {
+		args.VerifyProxy = VerifyProxyLocal
+	}
+	result = &ReleaseResult{
+		DryRun:      args.DryRun,
+		VerifyProxy: args.VerifyProxy,
+	}
+
+	// One engine serves both runs so the second reuses the in-flux status of
+	// every repo the release did not change
+	engine = NewReleaseEngine(EngineArgs{
+		StartDir:          args.StartDir,
+		RepoDirs:          []string{}, // Use config scan_dirs
+		Config:            args.Config,
+		Logger:            args.Logger,
+		Writer:            args.Writer,
+		Concurrency:       args.Concurrency,
+		IgnorePrereleases: args.IgnorePrereleases,
+	})
+
+	if args.Resume && args.Abort {
+		err = NewErr(ErrConflictingReleaseFlags)
+		goto end
+	}
+	if args.Resume || args.Abort {
+		result, err = continueRelease(ctx, args, engine)
+		goto end
+	}
+
+	leaf, mod, err = runReleaseEngine(ctx, engine)
+	if err != nil {
+		goto end
+	}
+	result.Leaf = leaf
+	result.ModulePath = mod.Path
+
+	// Replaces added by `gomion dev on` must not leak into a release
+	devState, err = LoadDevModeState(leaf.LeafRepoDir)
+	if err != nil {
+		goto end
+	}
+	if devState.IsActive() {
+		err = NewErr(ErrDevModeActive, "repo_dir", leaf.LeafRepoDir)
+		goto end
+	}
+
+	// Local path replaces are checked directly rather than relying on the verdict
+	// so no engine path can let one through
+	err = checkLocalReplaces(mod)
+	if err != nil {
+		goto end
+	}
+
+	if leaf.ReleaseBlocked {
+		err = NewErr(ErrModuleInFlux,
+			"module_dir", leaf.LeafModuleDir,
+			"reason", leaf.ReleaseBlockedReason,
+		)
+		goto end
+	}
+
+	repo, err = gitutils.Open(leaf.LeafRepoDir)
+	if err != nil {
+		goto end
+	}
+
+	journalStore, err = FindReleaseJournal(repo.Root)
+	if err != nil {
+		goto end
+	}
+	if journalStore != nil {
+		err = NewErr(ErrReleaseInProgress, "module_dir", journalStore.ModuleDir)
+		goto end
+	}
+
+	result.Commit, err = repo.RevParse("HEAD")
+	if err != nil {
+		goto end
+	}
+
+	modRelPath, err = leaf.LeafModuleDir.Rel(leaf.LeafRepoDir)
+	if err != nil {
+		goto end
+	}
+
+	result.BaselineTag, err = repo.LatestTag(ctx, result.Commit, &gitutils.LatestTagArgs{
+		ModuleRelPath:      modRelPath,
+		ExcludePrereleases: ignorePrereleases(args.IgnorePrereleases, args.Config),
+	})
+	switch {
+	case errors.Is(err, gitutils.ErrNoSemverTags), errors.Is(err, gitutils.ErrNoReachableSemverTags):
+		// First release of this module
+		err = nil
+	case err != nil:
+		goto end
+	}
+
+	result.Version, err = proposeReleaseVersion(args.Version, args.Prerelease, result)
+	if err != nil {
+		goto end
+	}
+
+	// Tags for modules with a /vN suffix must match that major version
+	_, pathMajor, _ = module.SplitPathVersion(string(mod.Path))
+	err = module.CheckPathMajor(result.Version, pathMajor)
+	if err != nil {
+		err = NewErr(ErrMajorVersionMismatch, "hint", "run `gomion major` to migrate to a /vN module path", err)
+		goto end
+	}
+
+	result.Tag = gitutils.ModuleTagName(modRelPath, result.Version)
+
+	existing, err = repo.Tags(ctx, string(modRelPath))
+	if err != nil {
+		goto end
+	}
+	for _, tag := range existing {
+		if tag == result.Tag {
+			err = NewErr(ErrTagAlreadyExists)
+			goto end
+		}
+	}
+
+	if args.DryRun {
+		goto end
+	}
+
+	journal = &ReleaseJournal{
+		ModulePath:    mod.Path,
+		ModuleDir:     leaf.LeafModuleDir,
+		RepoDir:       repo.Root,
+		BaselineTag:   result.BaselineTag,
+		Version:       result.Version,
+		Tag:           result.Tag,
+		BaseCommit:    result.Commit,
+		Commit:        result.Commit,
+		Verdict:       leaf.Verdict,
+		VerdictReason: leaf.VerdictReason,
+		VerifyProxy:   args.VerifyProxy,
+		Started:       time.Now(),
+	}
+	journalStore = NewReleaseJournalStore(leaf.LeafModuleDir)
+	err = journalStore.Save(journal)
+	if err != nil {
+		goto end
+	}
+
+	result.Bump, err = finishRelease(ctx, repo, journalStore, journal, args)
+	result.Commit = journal.Commit
+	result.ReleaseCommit = journal.ReleaseCommit
+	if err != nil {
+		goto end
+	}
+
+	result.Next, err = nextReleaseLeaf(ctx, engine)
+
+end:
+	if err != nil && result.Tag != "" {
+		err = WithErr(err, "tag", result.Tag)
+	}
+	return result, err
+}
+
+// continueRelease resumes or aborts the release recorded in the journal of
+// the repo containing args.StartDir
+func continueRelease(ctx context.Context, args ReleaseArgs, engine *ReleaseEngine) (result *ReleaseResult, err error) {
+	var startDir dt.DirPath
+	var repo *gitutils.Repo
+	var store *ReleaseJournalStore
+	var journal *ReleaseJournal
+
+	result = &ReleaseResult{
+		Resumed: args.Resume,
+		Aborted: args.Abort,
+	}
+
+	startDir = dt.DirPath(args.StartDir)
+	if startDir == "" {
+		startDir = "."
+	}
+	repo, err = gitutils.Open(startDir)
+	if err != nil {
+		goto end
+	}
+
+	store, err = FindReleaseJournal(repo.Root)
+	if err != nil {
+		goto end
+	}
+	if store == nil {
+		err = NewErr(ErrNoReleaseInProgress, "repo_dir", repo.Root)
+		goto end
+	}
+	journal, err = store.Load()
+	if err != nil {
+		goto end
+	}
+
+	result.Leaf = &EngineResult{
+		LeafModuleDir: journal.ModuleDir,
+		LeafRepoDir:   journal.RepoDir,
+		Verdict:       journal.Verdict,
+		VerdictReason: journal.VerdictReason,
+		Branch:        repo.Branch,
+		Remote:        repo.Remote,
+	}
+	result.ModulePath = journal.ModulePath
+	result.BaselineTag = journal.BaselineTag
+	result.Version = journal.Version
+	result.Tag = journal.Tag
+	result.Commit = journal.Commit
+	result.VerifyProxy = journal.VerifyProxy
+
+	if args.Abort {
+		result.AbortNotes, err = abortRelease(ctx, repo, store, journal)
+		goto end
+	}
+
+	result.Bump, err = finishRelease(ctx, repo, store, journal, args)
+	result.Commit = journal.Commit
+	result.ReleaseCommit = journal.ReleaseCommit
+	if err != nil {
+		goto end
+	}
+
+	result.Next, err = nextReleaseLeaf(ctx, engine)
+
+end:
+	return result, err
+}
+
+// finishRelease performs the journaled release's remaining steps, including
+// verifying the version resolves through a module proxy, records what the new
+// tag resolves to in the tag ledger so it can never silently move and then
+// deletes the journal. On failure the journal is kept for `--resume` or
+// `--abort`.
+func finishRelease(ctx context.Context, repo *gitutils.Repo, store *ReleaseJournalStore, journal *ReleaseJournal, args ReleaseArgs) (bump *BumpResult, err error) {
+	var modRelPath dt.PathSegments
+	var cmp gitutils.TagComparison
+
+	bump, err = runReleaseSteps(ctx, repo, store, journal, args)
+	if err != nil {
+		goto end
+	}
+	modRelPath, err = journal.ModuleDir.Rel(journal.RepoDir)
+	if err != nil {
+		goto end
+	}
+	cmp, err = repo.CompareTags(ctx, string(modRelPath))
+	if err != nil {
+		goto end
+	}
+	_, err = CheckTagIntegrity(ctx, repo, string(modRelPath), &cmp)
+	if err != nil {
+		goto end
+	}
+	err = store.Delete()
+
+end:
+	return bump, err
+}
+
+// nextReleaseLeaf re-runs the engine so the caller can show what to release
+// next, returning nil if nothing is left in-flux
+func nextReleaseLeaf(ctx context.Context, engine *ReleaseEngine) (next *EngineResult, err error) {
+	next, _, err = runReleaseEngine(ctx, engine)
+	if errors.Is(err, goutils.ErrNoGoModuleFound) {
+		next = nil
+		err = nil
+	}
+	return next, err
+}
+
+// checkLocalReplaces returns an ErrLocalReplace for each local-path replace
+// directive in mod's go.mod
+func checkLocalReplaces(mod *goutils.Module) (err error) {
+	var errs []error
+
+	for _, rep := range (&ModuleExt{Module: mod}).LocalReplaces() {
+		errs = append(errs, NewErr(ErrLocalReplace,
+			"go_mod", rep.GoMod,
+			"line", rep.Line,
+			"directive", rep.Directive,
+			"target_dir", rep.TargetDir,
+			"target_module", rep.TargetModule,
+		))
+	}
+	return CombineErrs(errs)
+}
+
+// runReleaseEngine runs the ReleaseEngine and returns its result along with the
+// leaf module it selected
+func runReleaseEngine(ctx context.Context, engine *ReleaseEngine) (result *EngineResult, mod *goutils.Module, err error) {
+	var ok bool
+
+	result, err = engine.Run(ctx)
+	if err != nil {
+		goto end
+	}
+
+	if engine.graph != nil {
+		mod, ok = engine.graph.ModulesByModuleDir[result.LeafModuleDir]
+	}
+	if !ok {
+		err = NewErr(ErrGoModuleNotFound, "module_dir", result.LeafModuleDir)
+		goto end
+	}
+
+end:
+	return result, mod, err
+}
+
+// ignorePrereleases returns whether prerelease tags are skipped when choosing
+// a baseline, either because the command asked or the config does
+func ignorePrereleases(requested bool, config *Config) bool {
+	return requested || (config != nil && config.IgnorePrereleases)
+}
+
+// proposeReleaseVersion returns the explicitly requested version if provided,
+// otherwise the version suggested by the verdict relative to the baseline tag:
+// a breaking bump for a breaking verdict, else a minor bump if the exported API
+// grew and a patch bump if not. A likely_breaking verdict is released as a
+// compatible bump with a warning since behavior changes behind an unchanged API
+// are mostly fixes, and a major bump needs a /vN module path; pass the version
+// to release it as breaking. With a prerelease label the result is instead the
+// next prerelease leading to that version.
+func proposeReleaseVersion(requested, prerelease string, result *ReleaseResult) (version string, err error) {
+	var breaking bool
+
+	if requested != "" {
+		version = requested
+		if !semver.IsValid(version) || semver.Build(version) != "" {
+			err = NewErr(ErrInvalidVersion, "version", version)
+			goto end
+		}
+		version, err = prereleaseVersion(version, prerelease, result.BaselineTag)
+		goto end
+	}
+
+	if result.BaselineTag == "" {
+		version, err = prereleaseVersion(FirstReleaseVersion, prerelease, "")
+		goto end
+	}
+
+	switch result.Leaf.Verdict {
+	case VerdictBreaking:
+		breaking = true
+	case VerdictLikelyBreaking:
+		result.VersionWarning = "verdict is " + string(VerdictLikelyBreaking) +
+			" but proposing a compatible bump; review the evidence and pass --version to release a breaking change"
+	case VerdictMaybeNotBreaking:
+	default:
+		err = NewErr(ErrVerdictWithheld,
+			"verdict", result.Leaf.Verdict,
+			"reason", result.Leaf.VerdictReason,
+		)
+		goto end
+	}
+
+	result.Suggestions, err = goutils.SuggestNextVersions(gitutils.ModuleTagVersion(result.BaselineTag), breaking)
+	if err != nil {
+		goto end
+	}
+
+	switch {
+	case breaking:
+		version = result.Suggestions.Breaking
+	case result.Leaf.APIAdded:
+		version = result.Suggestions.Minor
+	default:
+		version = result.Suggestions.Compatible
+	}
+	version, err = prereleaseVersion(version, prerelease, result.BaselineTag)
+
+end:
+	return version, err
+}
+
+// prereleaseVersion returns the next prerelease in the series named label
+// leading to version, continuing the baseline's series when the baseline is a
+// prerelease of the same version. Returns version unchanged if label is empty.
+// Switching to a series that sorts below the baseline's, e.g. beta after rc,
+// is rejected since Go would never select the result.
+func prereleaseVersion(version, label, baselineTag string) (next string, err error) {
+	var baseline string
+
+	next = version
+	if label == "" || goutils.IsPrerelease(version) {
+		goto end
+	}
+
+	baseline = gitutils.ModuleTagVersion(baselineTag)
+	if strings.HasPrefix(semver.Canonical(baseline), version+"-") {
+		next, err = goutils.NextPrerelease(baseline, label)
+	} else {
+		next, err = goutils.NextPrerelease(version, label)
+	}
+	if err != nil {
+		err = NewErr(ErrInvalidVersion, "version", version, "prerelease", label, err)
+		goto end
+	}
+	if baseline != "" && semver.Compare(next, baseline) <= 0 {
+		err = NewErr(ErrVersionNotAfterBaseline,
+			"version", next,
+			"baseline", baseline,
+			"hint", "use a series that sorts after the baseline's or release "+version,
+		)
+	}
+
+end:
+	return next, err
+}
@@ -0,0 +1,497 @@
+package gompkg
+
+import (
+	"context"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/mikeschinkel/go-cfgstore"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/go-dt/dtx"
+	"github.com/mikeschinkel/gomion/gommod/gitutils"
+	"github.com/mikeschinkel/gomion/gommod/gomion"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+)
+
+const (
+	// ReleaseJournalPath is the directory under a module's .gomion holding the
+	// journal of an unfinished release
+	ReleaseJournalPath = "releases"
+
+	// ReleaseJournalFile is the journal's filename in ReleaseJournalPath
+	ReleaseJournalFile = "journal.json"
+)
+
+// releaseJournalExclude keeps journals out of `git status` in every module so
+// an unfinished release does not leave its module dirty
+const releaseJournalExclude dt.PathSegment = "**/" + gomion.ProjectConfigPath + "/" + ReleaseJournalPath
+
+// ReleaseStepName names a step of a release recorded in its journal
+type ReleaseStepName string
+
+const (
+	ReleaseStepTidy   ReleaseStepName = "tidy"
+	ReleaseStepTest   ReleaseStepName = "test"
+	ReleaseStepCommit ReleaseStepName = "commit"
+	ReleaseStepTag    ReleaseStepName = "tag"
+	ReleaseStepVerify ReleaseStepName = "verify"
+	ReleaseStepPush   ReleaseStepName = "push"
+	ReleaseStepBump   ReleaseStepName = "bump"
+)
+
+// ReleaseStep records a completed step of a release
+type ReleaseStep struct {
+	Name      ReleaseStepName `json:"name"`
+	Completed time.Time       `json:"completed"`
+}
+
+// ReleaseJournal records a release in progress so it can be resumed or rolled
+// back after a failure
+type ReleaseJournal struct {
+	ModulePath    goutils.ModulePath `json:"module_path"`
+	ModuleDir     dt.DirPath         `json:"module_dir"`
+	RepoDir       dt.DirPath         `json:"repo_dir"`
+	BaselineTag   string             `json:"baseline_tag"`
+	Version       string             `json:"version"`
+	Tag           string             `json:"tag"`
+	BaseCommit    string             `json:"base_commit"`    // HEAD when the release started
+	Commit        string             `json:"commit"`         // What the tag points at
+	ReleaseCommit string             `json:"release_commit"` // Commit of tidy changes, if any
+	Verdict       VerdictType        `json:"verdict"`
+	VerdictReason string             `json:"verdict_reason"`
+	VerifyProxy   string             `json:"verify_proxy"`
+	Started       time.Time          `json:"started"`
+	Steps         []ReleaseStep      `json:"steps"` // Completed steps, in order
+}
+
+// Completed returns true if the named step has been recorded as done
+func (j *ReleaseJournal) Completed(name ReleaseStepName) bool {
+	return slices.ContainsFunc(j.Steps, func(step ReleaseStep) bool {
+		return step.Name == name
+	})
+}
+
+// steps returns the steps of the release in the order they are performed. The
+// module is tidied, tested and any tidy changes committed before it is tagged.
+// A local proxy verifies the tag before it is pushed so a bad tag never leaves
+// the repo; a network proxy can only serve the version once it is pushed.
+// Dependents are bumped last, once the version can be required.
+func (j *ReleaseJournal) steps() (steps []ReleaseStepName) {
+	steps = []ReleaseStepName{ReleaseStepTidy, ReleaseStepTest, ReleaseStepCommit, ReleaseStepTag}
+	switch j.VerifyProxy {
+	case VerifyProxyOff:
+		steps = append(steps, ReleaseStepPush)
+	case "", VerifyProxyLocal:
+		steps = append(steps, ReleaseStepVerify, ReleaseStepPush)
+	default:
+		steps = append(steps, ReleaseStepPush, ReleaseStepVerify)
+	}
+	return append(steps, ReleaseStepBump)
+}
+
+// tagMessage returns the annotation of the release tag
+func (j *ReleaseJournal) tagMessage() string {
+	return "Release " + string(j.ModulePath) + " " + j.Version
+}
+
+// commitMessage returns the message of the commit holding the tidy changes
+func (j *ReleaseJournal) commitMessage() string {
+	return "Tidy " + string(j.ModulePath) + " for " + j.Version
+}
+
+// ReleaseJournalStore reads and writes the release journal of one module
+type ReleaseJournalStore struct {
+	cfgstore.ConfigStore
+	ModuleDir dt.DirPath
+}
+
+// NewReleaseJournalStore instantiates a store for the journal in
+// .gomion/releases/journal.json of the module in modDir
+func NewReleaseJournalStore(modDir dt.DirPath) *ReleaseJournalStore {
+	return &ReleaseJournalStore{
+		ModuleDir: modDir,
+		ConfigStore: cfgstore.NewConfigStore(cfgstore.CustomConfigDirType, cfgstore.ConfigStoreArgs{
+			ConfigSlug:  gomion.ConfigSlug,
+			RelFilepath: dt.RelFilepathJoin(ReleaseJournalPath, ReleaseJournalFile),
+			DirsProvider: cfgstore.DefaultDirsProviderWithArgs(cfgstore.DirsProviderArgs{
+				CustomDirPath: modDir,
+			}),
+		}),
+	}
+}
+
+// Save writes the journal, first making sure git ignores it
+func (store ReleaseJournalStore) Save(j *ReleaseJournal) (err error) {
+	var excluded bool
+	var excludeFile *gitutils.ExcludeFile
+
+	excludeFile = gitutils.NewExcludeFile(j.RepoDir)
+	excluded, err = excludeFile.ContainsPathSegment(releaseJournalExclude)
+	if err != nil {
+		goto end
+	}
+	if !excluded {
+		err = excludeFile.AppendPathSegment(releaseJournalExclude)
+	}
+	if err != nil {
+		goto end
+	}
+
+	err = store.SaveJSON(j)
+
+end:
+	if err != nil {
+		err = NewErr(ErrReleaseJournal, "module_dir", store.ModuleDir, err)
+	}
+	return err
+}
+
+// Load reads the journal
+func (store ReleaseJournalStore) Load() (j *ReleaseJournal, err error) {
+	j = &ReleaseJournal{}
+	err = store.LoadJSON(j)
+	if err != nil {
+		err = NewErr(ErrReleaseJournal, "module_dir", store.ModuleDir, err)
+	}
+	return j, err
+}
+
+// Delete removes the journal, ending the release it recorded
+func (store ReleaseJournalStore) Delete() (err error) {
+	var fp dt.Filepath
+
+	fp, err = store.GetFilepath()
+	if err != nil {
+		goto end
+	}
+	err = fp.Remove()
+	if dtx.NoFileOrDirErr(err) {
+		err = nil
+	}
+
+end:
+	if err != nil {
+		err = NewErr(ErrReleaseJournal, "module_dir", store.ModuleDir, err)
+	}
+	return err
+}
+
+// recordStep appends a completed step to the journal and saves it
+func (store ReleaseJournalStore) recordStep(j *ReleaseJournal, name ReleaseStepName) error {
+	j.Steps = append(j.Steps, ReleaseStep{
+		Name:      name,
+		Completed: time.Now(),
+	})
+	return store.Save(j)
+}
+
+// FindReleaseJournal returns the store of the one module in the repo at
+// repoRoot with an unfinished release, or a nil store if there is none
+func FindReleaseJournal(repoRoot dt.DirPath) (store *ReleaseJournalStore, err error) {
+	err = filepath.WalkDir(string(repoRoot), func(path string, d fs.DirEntry, err error) error {
+		var exists bool
+		var modDir dt.DirPath
+
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		switch d.Name() {
+		case ".git", "vendor", "testdata", "node_modules":
+			return fs.SkipDir
+		case string(gomion.ProjectConfigPath):
+		default:
+			return nil
+		}
+		modDir = dt.DirPath(path).Dir()
+		exists, err = dt.FilepathJoin3(path, ReleaseJournalPath, ReleaseJournalFile).Exists()
+		if err != nil {
+			return err
+		}
+		if exists {
+			store = NewReleaseJournalStore(modDir)
+			return fs.SkipAll
+		}
+		return fs.SkipDir
+	})
+	if err != nil {
+		err = NewErr(ErrReleaseJournal, "repo_dir", repoRoot, err)
+	}
+	return store, err
+}
+
+// runReleaseSteps performs the steps of the journaled release not yet
+// completed, recording each as it completes. Returns the dependents bumped if
+// the bump step ran.
+func runReleaseSteps(ctx context.Context, repo *gitutils.Repo, store *ReleaseJournalStore, j *ReleaseJournal, args ReleaseArgs) (bump *BumpResult, err error) {
+	for _, name := range j.steps() {
+		if j.Completed(name) {
+			continue
+		}
+		switch name {
+		case ReleaseStepTidy:
+			_, err = goutils.RunGo(ctx, j.ModuleDir, "mod", "tidy")
+		case ReleaseStepTest:
+			_, err = goutils.RunGo(ctx, j.ModuleDir, "test", "./...")
+			if err != nil {
+				err = NewErr(ErrTestsFailed, err)
+			}
+		case ReleaseStepCommit:
+			err = commitReleaseChanges(ctx, repo, j)
+		case ReleaseStepTag:
+			err = createReleaseTag(ctx, repo, j)
+		case ReleaseStepVerify:
+			err = verifyRelease(ctx, repo, j)
+		case ReleaseStepPush:
+			err = repo.PushTag(ctx, j.Tag)
+		case ReleaseStepBump:
+			bump, err = Bump(ctx, BumpArgs{
+				ModuleDir: string(j.ModuleDir),
+				Version:   j.Version,
+				Config:    args.Config,
+				Logger:    args.Logger,
+				Writer:    args.Writer,
+			})
+		}
+		if err != nil {
+			err = NewErr(ErrReleaseStep, "step", name, err)
+			goto end
+		}
+		err = store.recordStep(j, name)
+		if err != nil {
+			goto end
+		}
+	}
+
+end:
+	return bump, err
+}
+
+// commitReleaseChanges commits the changes tidying made to the module's go.mod
+// and go.sum, leaving anything else uncommitted, and makes that commit the one
+// the release tags. A commit made before the release was interrupted is found
+// by its message rather than made again.
+func commitReleaseChanges(ctx context.Context, repo *gitutils.Repo, j *ReleaseJournal) (err error) {
+	var modRelPath dt.PathSegments
+	var goModFiles []dt.RelFilepath
+	var changed []dt.RelFilepath
+
+	j.ReleaseCommit, err = findReleaseCommit(ctx, repo, j)
+	if err != nil || j.ReleaseCommit != "" {
+		goto end
+	}
+
+	modRelPath, err = j.ModuleDir.Rel(j.RepoDir)
+	if err != nil {
+		goto end
+	}
+	goModFiles = []dt.RelFilepath{
+		dt.RelFilepath(path.Join(string(modRelPath), "go.mod")),
+		dt.RelFilepath(path.Join(string(modRelPath), "go.sum")),
+	}
+	changed, err = repo.GetChangedFiles(ctx, &gitutils.StatusArgs{
+		Path: modRelPath,
+		FileFilter: func(file dt.RelFilepath) bool {
+			return slices.Contains(goModFiles, file)
+		},
+	})
+	if err != nil || len(changed) == 0 {
+		goto end
+	}
+
+	err = repo.CommitPaths(ctx, j.commitMessage(), changed...)
+	if err != nil {
+		goto end
+	}
+	j.ReleaseCommit, err = repo.RevParse("HEAD")
+
+end:
+	if err == nil && j.ReleaseCommit != "" {
+		j.Commit = j.ReleaseCommit
+	}
+	return err
+}
+
+// findReleaseCommit returns the release's tidy commit if it is the only commit
+// since the release started, or "" if there is none
+func findReleaseCommit(ctx context.Context, repo *gitutils.Repo, j *ReleaseJournal) (commit string, err error) {
+	var commits []gitutils.LogEntry
+
+	if j.ReleaseCommit != "" || j.BaseCommit == "" {
+		commit = j.ReleaseCommit
+		goto end
+	}
+	commits, err = repo.CommitsSince(ctx, j.BaseCommit, "", nil)
+	if err != nil {
+		goto end
+	}
+	if len(commits) == 1 && commits[0].Subject == j.commitMessage() {
+		commit = commits[0].SHA
+	}
+
+end:
+	return commit, err
+}
+
+// createReleaseTag tags the release's commit. A tag already pointing at that
+// commit was created before the release was interrupted and is kept.
+func createReleaseTag(ctx context.Context, repo *gitutils.Repo, j *ReleaseJournal) (err error) {
+	var tagCommit string
+
+	tagCommit, err = releaseTagCommit(ctx, repo, j)
+	switch {
+	case err != nil:
+	case tagCommit == j.Commit:
+	case tagCommit != "":
+		err = NewErr(ErrTagAlreadyExists, "tag_commit", tagCommit, "release_commit", j.Commit)
+	default:
+		err = repo.CreateTag(ctx, j.Tag, j.Commit, j.tagMessage())
+	}
+	return err
+}
+
+// releaseTagCommit returns the commit the release's tag points at locally, or
+// "" if there is no such tag
+func releaseTagCommit(ctx context.Context, repo *gitutils.Repo, j *ReleaseJournal) (commit string, err error) {
+	var modRelPath dt.PathSegments
+	var refs map[string]gitutils.TagRef
+
+	modRelPath, err = j.ModuleDir.Rel(j.RepoDir)
+	if err != nil {
+		goto end
+	}
+	refs, err = repo.TagRefs(ctx, string(modRelPath))
+	if err != nil {
+		goto end
+	}
+	commit = refs[j.Tag].Commit
+
+end:
+	return commit, err
+}
+
+// abortRelease undoes the journaled release's local tag and tidy commit unless
+// they already reached the remote, then deletes the journal. Returns what was
+// undone and what had to be left in place.
+func abortRelease(ctx context.Context, repo *gitutils.Repo, store *ReleaseJournalStore, j *ReleaseJournal) (notes []string, err error) {
+	var tagCommit string
+	var pushed bool
+
+	// The tag is looked up rather than trusting the journal as the release may
+	// have stopped between creating the tag and recording it
+	tagCommit, err = releaseTagCommit(ctx, repo, j)
+	if err != nil {
+		goto end
+	}
+	switch tagCommit {
+	case "":
+		notes = append(notes, "tag "+j.Tag+" was never created")
+	case j.Commit:
+		pushed, err = releaseTagPushed(ctx, repo, j)
+		if err != nil {
+			goto end
+		}
+		if pushed {
+			// A pushed tag publishes the commit it points at, so both stay
+			notes = append(notes, "tag "+j.Tag+" was already pushed; left in place")
+			err = store.Delete()
+			goto end
+		}
+		err = repo.DeleteTag(ctx, j.Tag)
+		if err != nil {
+			goto end
+		}
+		// The tag name is free for reuse, so it must not be seen as moved
+		err = ForgetTag(repo.Root, j.Tag)
+		if err != nil {
+			goto end
+		}
+		notes = append(notes, "deleted local tag "+j.Tag)
+	default:
+		notes = append(notes, "tag "+j.Tag+" points at "+tagCommit+", not this release's commit; left in place")
+	}
+
+	notes, err = abortReleaseCommit(ctx, repo, j, notes)
+	if err != nil {
+		goto end
+	}
+	err = store.Delete()
+
+end:
+	return notes, err
+}
+
+// abortReleaseCommit undoes the release's tidy commit, leaving its changes
+// staged, unless it was pushed or is no longer HEAD. Appends what was done to
+// notes.
+func abortReleaseCommit(ctx context.Context, repo *gitutils.Repo, j *ReleaseJournal, notes []string) (_ []string, err error) {
+	var commit, head string
+	var pushed bool
+
+	commit, err = findReleaseCommit(ctx, repo, j)
+	if err != nil {
+		goto end
+	}
+	if commit == "" {
+		if j.Completed(ReleaseStepTidy) {
+			notes = append(notes, "changes made by go mod tidy, if any, are left uncommitted")
+		}
+		goto end
+	}
+
+	pushed, err = repo.RemoteBranchesContain(ctx, commit)
+	if err != nil {
+		goto end
+	}
+	if pushed {
+		notes = append(notes, "commit "+commit+" was already pushed; left in place")
+		goto end
+	}
+	head, err = repo.RevParse("HEAD")
+	if err != nil {
+		goto end
+	}
+	if head != commit {
+		notes = append(notes, "commit "+commit+" has commits on top of it; left in place")
+		goto end
+	}
+
+	err = repo.SoftReset(ctx, j.BaseCommit)
+	if err != nil {
+		goto end
+	}
+	notes = append(notes, "undid commit "+commit+"; its go.mod and go.sum changes are left staged")
+
+end:
+	return notes, err
+}
+
+// releaseTagPushed returns true if the journaled release's tag is on the
+// remote, checking the remote when the push was not recorded as the push may
+// have succeeded before the journal was saved
+func releaseTagPushed(ctx context.Context, repo *gitutils.Repo, j *ReleaseJournal) (pushed bool, err error) {
+	var modRelPath dt.PathSegments
+	var remoteTags []string
+
+	if j.Completed(ReleaseStepPush) {
+		pushed = true
+		goto end
+	}
+	modRelPath, err = j.ModuleDir.Rel(j.RepoDir)
+	if err != nil {
+		goto end
+	}
+	remoteTags, err = repo.RemoteTags(ctx, string(modRelPath))
+	if err != nil {
+		goto end
+	}
+	pushed = slices.Contains(remoteTags, j.Tag)
+
+end:
+	return pushed, err
+}
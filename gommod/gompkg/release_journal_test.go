@@ -0,0 +1,124 @@
+package gompkg
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gitutils"
+)
+
+func TestReleaseJournalSteps(t *testing.T) {
+	tests := []struct {
+		proxy string
+		want  []ReleaseStepName
+	}{
+		{
+			proxy: VerifyProxyLocal,
+			want:  []ReleaseStepName{ReleaseStepTidy, ReleaseStepTest, ReleaseStepCommit, ReleaseStepTag, ReleaseStepVerify, ReleaseStepPush, ReleaseStepBump},
+		},
+		{
+			proxy: VerifyProxyOff,
+			want:  []ReleaseStepName{ReleaseStepTidy, ReleaseStepTest, ReleaseStepCommit, ReleaseStepTag, ReleaseStepPush, ReleaseStepBump},
+		},
+		{
+			proxy: "https://proxy.golang.org",
+			want:  []ReleaseStepName{ReleaseStepTidy, ReleaseStepTest, ReleaseStepCommit, ReleaseStepTag, ReleaseStepPush, ReleaseStepVerify, ReleaseStepBump},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.proxy, func(t *testing.T) {
+			got := (&ReleaseJournal{VerifyProxy: tt.proxy}).steps()
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAbortReleaseUndoesUnpushedCommitAndTag(t *testing.T) {
+	// commitReleaseChanges and createReleaseTag run git with the process environment
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	root := t.TempDir()
+	remoteDir := filepath.Join(root, "remote.git")
+	repoDir := filepath.Join(root, "repo")
+
+	git(t, root, "init", "--quiet", "--bare", remoteDir)
+	git(t, root, "init", "--quiet", "--initial-branch=main", repoDir)
+	git(t, repoDir, "remote", "add", "origin", remoteDir)
+	writeGoMod(t, repoDir, "module github.com/example/app\n\ngo 1.25\n")
+	git(t, repoDir, "add", "go.mod")
+	git(t, repoDir, "commit", "--quiet", "--message", "Initial")
+	git(t, repoDir, "push", "--quiet", "--set-upstream", "origin", "main")
+
+	repo, err := gitutils.Open(dt.DirPath(repoDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := git(t, repoDir, "rev-parse", "HEAD")
+	j := &ReleaseJournal{
+		ModulePath: "github.com/example/app",
+		ModuleDir:  repo.Root,
+		RepoDir:    repo.Root,
+		Version:    "v0.1.0",
+		Tag:        "v0.1.0",
+		BaseCommit: base,
+		Commit:     base,
+	}
+
+	// Stand in for the changes go mod tidy would make
+	writeGoMod(t, repoDir, "module github.com/example/app\n\ngo 1.25.0\n")
+	err = commitReleaseChanges(t.Context(), repo, j)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.ReleaseCommit == "" || j.Commit != j.ReleaseCommit {
+		t.Fatalf("got release commit %q and commit %q, want the tidy commit tagged", j.ReleaseCommit, j.Commit)
+	}
+
+	err = createReleaseTag(t.Context(), repo, j)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A tag left by an interrupted run is kept rather than failing the resume
+	err = createReleaseTag(t.Context(), repo, j)
+	if err != nil {
+		t.Fatalf("got %v recreating the tag at the same commit, want none", err)
+	}
+
+	// Stand in for a ledger that recorded the tag before it was pushed
+	ledger := &TagLedger{Tags: map[string]TagRecord{j.Tag: {}}}
+	err = ledger.Save(repo.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notes, err := abortRelease(t.Context(), repo, NewReleaseJournalStore(repo.Root), j)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 2 {
+		t.Errorf("got notes %q, want the tag and commit undone", notes)
+	}
+	if tags := git(t, repoDir, "tag", "--list"); tags != "" {
+		t.Errorf("got tags %q, want none", tags)
+	}
+	ledger, err = LoadTagLedger(repo.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ledger.Tags[j.Tag]; ok {
+		t.Errorf("got %s still in the tag ledger, want it forgotten", j.Tag)
+	}
+	if head := git(t, repoDir, "rev-parse", "HEAD"); head != base {
+		t.Errorf("got HEAD %s, want %s", head, base)
+	}
+	if staged := git(t, repoDir, "diff", "--cached", "--name-only"); staged != "go.mod" {
+		t.Errorf("got staged %q, want the go.mod change kept staged", staged)
+	}
+}
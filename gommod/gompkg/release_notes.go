@@ -0,0 +1,485 @@
+package gompkg
+
+import (
+	"context"
+	"encoding/json/jsontext"
+	jsonv2 "encoding/json/v2"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gitutils"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+)
+
+// UnreleasedVersion is the changelog heading used when no version is given
+const UnreleasedVersion = "Unreleased"
+
+// Keep a Changelog section titles, plus Other for commits that fit none of them
+const (
+	AddedSection      = "Added"
+	ChangedSection    = "Changed"
+	DeprecatedSection = "Deprecated"
+	RemovedSection    = "Removed"
+	FixedSection      = "Fixed"
+	SecuritySection   = "Security"
+	OtherSection      = "Other"
+)
+
+// notesSectionOrder is the order sections appear in release notes
+var notesSectionOrder = []string{
+	AddedSection,
+	ChangedSection,
+	DeprecatedSection,
+	RemovedSection,
+	FixedSection,
+	SecuritySection,
+	OtherSection,
+}
+
+// notesSectionByType maps conventional-commit types to changelog sections
+var notesSectionByType = map[string]string{
+	"feat":      AddedSection,
+	"perf":      ChangedSection,
+	"refactor":  ChangedSection,
+	"revert":    ChangedSection,
+	"deprecate": DeprecatedSection,
+	"remove":    RemovedSection,
+	"fix":       FixedSection,
+	"security":  SecuritySection,
+}
+
+// conventionalCommitRegex matches "type(scope)!: subject"
+var conventionalCommitRegex = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^)]*)\))?(!)?:\s+(.+)$`)
+
+// NotesArgs contains the input parameters for ReleaseNotesFor
+type NotesArgs struct {
+	// StartDir is the directory to start scanning from (defaults to ".")
+	StartDir string
+
+	// Module selects the module by module path, last path element or directory
+	// (defaults to the module containing StartDir)
+	Module string
+
+	// Version is the heading for the notes (defaults to UnreleasedVersion)
+	Version string
+
+	// Write adds or replaces the Version section of the module's CHANGELOG.md
+	Write bool
+
+	Config *Config
+	Logger *slog.Logger
+	Writer cliutil.Writer
+}
+
+// NotesEntry is a single commit in the release notes
+type NotesEntry struct {
+	SHA      string `json:"sha"`
+	Type     string `json:"type,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+	Subject  string `json:"subject"`
+	Breaking bool   `json:"breaking,omitempty"`
+}
+
+// NotesSection groups the entries for one changelog section
+type NotesSection struct {
+	Title   string       `json:"title"`
+	Entries []NotesEntry `json:"entries"`
+}
+
+// NotesAPIPackage summarizes the exported API changes of one package
+type NotesAPIPackage struct {
+	ImportPath  dt.DirPath `json:"import_path"`
+	Breaking    []string   `json:"breaking,omitempty"`
+	NonBreaking []string   `json:"non_breaking,omitempty"`
+}
+
+// ReleaseNotes are the notes for one module covering the commits since its
+// baseline tag
+type ReleaseNotes struct {
+	ModulePath  goutils.ModulePath `json:"module_path"`
+	ModuleDir   dt.DirPath         `json:"module_dir"`
+	BaselineTag string             `json:"baseline_tag,omitempty"`
+	Version     string             `json:"version"`
+	Date        string             `json:"date,omitempty"`
+
+	// Breaking lists commits marked as breaking with "!" or BREAKING CHANGE
+	Breaking []NotesEntry `json:"breaking,omitempty"`
+
+	Sections []NotesSection `json:"sections"`
+
+	// APIChanges summarizes the APIDiffReport against BaselineTag
+	APIChanges []NotesAPIPackage `json:"api_changes,omitempty"`
+
+	// APINote explains why APIChanges is empty when no API diff was possible
+	APINote string `json:"api_note,omitempty"`
+
+	// Retractions lists the retract directives added to go.mod since
+	// BaselineTag, each with its rationale
+	Retractions []goutils.Retract `json:"retractions,omitempty"`
+
+	// ChangelogFile is set if the notes were written to a CHANGELOG.md
+	ChangelogFile dt.Filepath `json:"changelog_file,omitempty"`
+}
+
+// ReleaseNotesFor collects the commits touching a module since its latest tag,
+// groups them by conventional-commit type and adds the API changes since that
+// tag. With args.Write it also updates the module's CHANGELOG.md.
+func ReleaseNotesFor(ctx context.Context, args NotesArgs) (notes *ReleaseNotes, err error) {
+	var graph *goutils.ModuleGraph
+	var module *goutils.Module
+	var moduleExt *ModuleExt
+	var repo *gitutils.Repo
+	var headSHA string
+	var modRelPath dt.PathSegments
+	var commits []gitutils.LogEntry
+	var report goutils.APIDiffReport
+	var apiErr error
+
+	notes = &ReleaseNotes{
+		Version: args.Version,
+	}
+	if notes.Version == "" {
+		notes.Version = UnreleasedVersion
+	} else {
+		notes.Date = time.Now().Format(time.DateOnly)
+	}
+
+	if args.StartDir == "" {
+		args.StartDir = "."
+	}
+
+	graph, err = LoadModuleGraph(LoadModuleGraphArgs{
+		StartDir: args.StartDir,
+		Config:   args.Config,
+		Logger:   args.Logger,
+		Writer:   args.Writer,
+	})
+	if err != nil {
+		goto end
+	}
+
+	module, err = selectModule(graph, args.StartDir, args.Module)
+	if err != nil {
+		goto end
+	}
+	notes.ModulePath = module.Path
+	notes.ModuleDir = module.Dir()
+
+	moduleExt = &ModuleExt{Module: module}
+	err = moduleExt.SetGraph(graph)
+	if err != nil {
+		goto end
+	}
+
+	repo, err = gitutils.Open(moduleExt.Repo().DirPath)
+	if err != nil {
+		goto end
+	}
+
+	headSHA, err = repo.RevParse("HEAD")
+	if err != nil {
+		goto end
+	}
+
+	modRelPath, err = module.Dir().Rel(repo.Root)
+	if err != nil {
+		goto end
+	}
+
+	notes.BaselineTag, err = repo.LatestTag(ctx, headSHA, &gitutils.LatestTagArgs{
+		ModuleRelPath: modRelPath,
+	})
+	switch {
+	case errors.Is(err, gitutils.ErrNoSemverTags), errors.Is(err, gitutils.ErrNoReachableSemverTags):
+		// First release; notes cover the module's entire history
+		err = nil
+	case err != nil:
+		goto end
+	}
+
+	commits, err = repo.CommitsSince(ctx, notes.BaselineTag, modRelPath, moduleExt.getSubmodulePathsToExclude())
+	if err != nil {
+		goto end
+	}
+	notes.addCommits(commits)
+
+	notes.Retractions, err = retractsSinceTag(ctx, repo, notes.BaselineTag, modRelPath, module)
+	if err != nil {
+		goto end
+	}
+
+	switch {
+	case notes.BaselineTag == "":
+		notes.APINote = "no baseline tag; first release"
+	default:
+		report, apiErr = apiDiffSinceTag(ctx, repo, notes.BaselineTag, modRelPath, module.Dir())
+		if apiErr != nil {
+			notes.APINote = "API diff unavailable: " + apiErr.Error()
+			break
+		}
+		notes.addAPIChanges(report)
+	}
+
+	if !args.Write {
+		goto end
+	}
+
+	notes.ChangelogFile = dt.FilepathJoin(module.Dir(), ChangelogFile)
+	err = UpdateChangelog(notes.ChangelogFile, notes)
+
+end:
+	if err != nil {
+		err = WithErr(err, "module", args.Module)
+	}
+	return notes, err
+}
+
+// addCommits parses each commit subject and files it under its section. If no
+// commit follows the conventional-commit format the raw subjects are listed
+// under Changed.
+func (n *ReleaseNotes) addCommits(commits []gitutils.LogEntry) {
+	var anyConventional bool
+
+	entriesBySection := make(map[string][]NotesEntry)
+
+	for _, commit := range commits {
+		entry, conventional := parseNotesEntry(commit)
+		anyConventional = anyConventional || conventional
+		if entry.Breaking {
+			n.Breaking = append(n.Breaking, entry)
+		}
+		section, ok := notesSectionByType[entry.Type]
+		if !ok {
+			section = OtherSection
+		}
+		entriesBySection[section] = append(entriesBySection[section], entry)
+	}
+
+	if !anyConventional && len(entriesBySection[OtherSection]) > 0 {
+		entriesBySection[ChangedSection] = entriesBySection[OtherSection]
+		delete(entriesBySection, OtherSection)
+	}
+
+	for _, title := range notesSectionOrder {
+		entries, ok := entriesBySection[title]
+		if !ok {
+			continue
+		}
+		n.Sections = append(n.Sections, NotesSection{
+			Title:   title,
+			Entries: entries,
+		})
+	}
+}
+
+// addAPIChanges records the packages in report with breaking or non-breaking changes
+func (n *ReleaseNotes) addAPIChanges(report goutils.APIDiffReport) {
+	for _, pkg := range report.Packages {
+		if len(pkg.Breaking) == 0 && len(pkg.NonBreaking) == 0 {
+			continue
+		}
+		n.APIChanges = append(n.APIChanges, NotesAPIPackage{
+			ImportPath:  pkg.ImportPath,
+			Breaking:    pkg.Breaking,
+			NonBreaking: pkg.NonBreaking,
+		})
+	}
+	if len(n.APIChanges) == 0 {
+		n.APINote = "no exported API changes"
+	}
+}
+
+// retractsSinceTag returns the module's retract directives not in its go.mod
+// as of baselineTag, or all of them if there is no baseline
+func retractsSinceTag(ctx context.Context, repo *gitutils.Repo, baselineTag string, modRelPath dt.PathSegments, module *goutils.Module) (added []goutils.Retract, err error) {
+	var goMod dt.RelFilepath
+	var content []byte
+	var baseline []goutils.Retract
+
+	if baselineTag != "" {
+		goMod = dt.RelFilepathJoin(modRelPath, "go.mod")
+		content, err = repo.ShowFile(ctx, baselineTag, goMod)
+		if err != nil {
+			goto end
+		}
+		baseline, err = goutils.ParseRetracts(dt.Filepath(goMod), content)
+		if err != nil {
+			goto end
+		}
+	}
+
+	for _, r := range module.Retracts() {
+		if !slices.ContainsFunc(baseline, r.SameInterval) {
+			added = append(added, r)
+		}
+	}
+
+end:
+	if err != nil {
+		err = WithErr(err, "baseline_tag", baselineTag)
+	}
+	return added, err
+}
+
+// parseNotesEntry converts a commit into an entry, reporting whether its subject
+// follows the conventional-commit format
+func parseNotesEntry(commit gitutils.LogEntry) (entry NotesEntry, conventional bool) {
+	entry = NotesEntry{
+		SHA:     commit.SHA,
+		Subject: strings.TrimSpace(commit.Subject),
+	}
+
+	m := conventionalCommitRegex.FindStringSubmatch(entry.Subject)
+	if m == nil {
+		goto end
+	}
+	conventional = true
+	entry.Type = strings.ToLower(m[1])
+	entry.Scope = m[2]
+	entry.Breaking = m[3] == "!"
+	entry.Subject = m[4]
+
+end:
+	if strings.Contains(commit.Body, "BREAKING CHANGE:") || strings.Contains(commit.Body, "BREAKING-CHANGE:") {
+		entry.Breaking = true
+	}
+	return entry, conventional
+}
+
+// Heading returns the Keep a Changelog heading for the notes, e.g.
+// "## [v1.2.0] - 2026-01-31"
+func (n *ReleaseNotes) Heading() (heading string) {
+	heading = "## [" + n.Version + "]"
+	if n.Date != "" {
+		heading += " - " + n.Date
+	}
+	return heading
+}
+
+// Markdown renders the notes as a Keep a Changelog section
+func (n *ReleaseNotes) Markdown() string {
+	var sb strings.Builder
+
+	sb.WriteString(n.Heading())
+	sb.WriteString("\n")
+
+	if len(n.Breaking) > 0 {
+		sb.WriteString("\n### Breaking Changes\n\n")
+		for _, entry := range n.Breaking {
+			sb.WriteString(entry.markdown())
+		}
+	}
+
+	if len(n.APIChanges) > 0 {
+		sb.WriteString("\n### API Changes\n\n")
+		for _, pkg := range n.APIChanges {
+			sb.WriteString(fmt.Sprintf("- `%s`\n", pkg.ImportPath))
+			for _, change := range pkg.Breaking {
+				sb.WriteString(fmt.Sprintf("  - Breaking: %s\n", strings.TrimSpace(change)))
+			}
+			for _, change := range pkg.NonBreaking {
+				sb.WriteString(fmt.Sprintf("  - %s\n", strings.TrimSpace(change)))
+			}
+		}
+	}
+
+	if len(n.Retractions) > 0 {
+		sb.WriteString("\n### Retracted\n\n")
+		for _, r := range n.Retractions {
+			sb.WriteString(fmt.Sprintf("- `%s`", r))
+			if r.Rationale != "" {
+				sb.WriteString(": " + r.Rationale)
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	for _, section := range n.Sections {
+		sb.WriteString("\n### " + section.Title + "\n\n")
+		for _, entry := range section.Entries {
+			sb.WriteString(entry.markdown())
+		}
+	}
+
+	if len(n.Sections) == 0 {
+		sb.WriteString("\nNo changes.\n")
+	}
+
+	return sb.String()
+}
+
+// JSON returns JSON representation of the notes
+func (n *ReleaseNotes) JSON() (jsonText string) {
+	var data []byte
+	var err error
+
+	data, err = jsonv2.Marshal(n, jsontext.WithIndent("  "))
+	if err != nil {
+		jsonText = "{}"
+		goto end
+	}
+
+	jsonText = string(data)
+
+end:
+	return jsonText
+}
+
+// markdown renders the entry as a list item
+func (e NotesEntry) markdown() string {
+	var sb strings.Builder
+
+	sb.WriteString("- ")
+	if e.Scope != "" {
+		sb.WriteString("**" + e.Scope + ":** ")
+	}
+	sb.WriteString(e.Subject)
+	if len(e.SHA) >= 7 {
+		sb.WriteString(" (" + e.SHA[:7] + ")")
+	}
+	sb.WriteString("\n")
+
+	return sb.String()
+}
+
+// selectModule returns the module named by sel, matched against module paths,
+// their last element and module directories, or the module containing startDir
+// when sel is empty
+func selectModule(graph *goutils.ModuleGraph, startDir string, sel string) (module *goutils.Module, err error) {
+	var dir dt.DirPath
+	var matches []*goutils.Module
+
+	if sel == "" {
+		dir, err = dt.ParseDirPath(startDir)
+		if err != nil {
+			goto end
+		}
+		module, err = moduleInDir(graph, dir)
+		goto end
+	}
+
+	for modDir, mod := range graph.ModulesByModuleDir {
+		if matchesModules([]string{sel}, mod.Path, modDir) {
+			matches = append(matches, mod)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		err = NewErr(ErrGoModuleNotFound, "module", sel)
+	case 1:
+		module = matches[0]
+	default:
+		err = NewErr(ErrAmbiguousModule, "module", sel, "matches", len(matches))
+	}
+
+end:
+	return module, err
+}
@@ -0,0 +1,127 @@
+package gompkg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+func TestProposeReleaseVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested string
+		baseline  string
+		verdict   VerdictType
+		apiAdded  bool
+		want      string
+		wantWarn  bool
+		wantErr   error
+	}{
+		{name: "Requested", requested: "v1.4.0", baseline: "v1.2.3", verdict: VerdictWithheld, want: "v1.4.0"},
+		{name: "RequestedInvalid", requested: "1.4.0", wantErr: ErrInvalidVersion},
+		{name: "RequestedBuildMetadata", requested: "v1.4.0+build", wantErr: ErrInvalidVersion},
+		{name: "FirstRelease", verdict: VerdictWithheld, want: FirstReleaseVersion},
+		{name: "Compatible", baseline: "v1.2.3", verdict: VerdictMaybeNotBreaking, want: "v1.2.4"},
+		{name: "CompatibleModuleTag", baseline: "cmd/v0.3.1", verdict: VerdictMaybeNotBreaking, want: "v0.3.2"},
+		{name: "Breaking", baseline: "v1.2.3", verdict: VerdictBreaking, want: "v2.0.0"},
+		{name: "BreakingV0", baseline: "v0.3.1", verdict: VerdictBreaking, want: "v0.4.0"},
+		{name: "APIAdded", baseline: "v1.2.3", verdict: VerdictMaybeNotBreaking, apiAdded: true, want: "v1.3.0"},
+		{name: "BreakingAPIAdded", baseline: "v1.2.3", verdict: VerdictBreaking, apiAdded: true, want: "v2.0.0"},
+		{name: "LikelyBreaking", baseline: "v1.2.3", verdict: VerdictLikelyBreaking, want: "v1.2.4", wantWarn: true},
+		{name: "LikelyBreakingAPIAdded", baseline: "v1.2.3", verdict: VerdictLikelyBreaking, apiAdded: true, want: "v1.3.0", wantWarn: true},
+		{name: "Withheld", baseline: "v1.2.3", verdict: VerdictWithheld, wantErr: ErrVerdictWithheld},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &ReleaseResult{
+				Leaf:        &EngineResult{Verdict: tt.verdict, APIAdded: tt.apiAdded},
+				BaselineTag: tt.baseline,
+			}
+			got, err := proposeReleaseVersion(tt.requested, "", result)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("got %q, %v, want error %v", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if (result.VersionWarning != "") != tt.wantWarn {
+				t.Errorf("got warning %q, want one: %t", result.VersionWarning, tt.wantWarn)
+			}
+		})
+	}
+}
+
+func TestReleaseLikelyBreakingV1(t *testing.T) {
+	// The baseline is read through the cached worktree
+	t.Setenv("NEXTVER_CACHE_DIR", t.TempDir())
+
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "BodyChanged",
+			source: "package lib\n\nfunc Foo() int { return 2 }\n",
+			want:   "v1.2.4",
+		},
+		{
+			name:   "BodyChangedAndAPIAdded",
+			source: "package lib\n\nfunc Foo() int { return 2 }\n\nfunc Bar() int { return 3 }\n",
+			want:   "v1.3.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoDir := t.TempDir()
+			writeSource := func(content string) {
+				err := os.WriteFile(filepath.Join(repoDir, "lib", "lib.go"), []byte(content), 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			git(t, repoDir, "init", "--quiet", "--initial-branch=main")
+			// The engine releases a module the start repo's modules require
+			writeGoMod(t, filepath.Join(repoDir, "app"), "module example.com/app\n\ngo 1.25\n\nrequire example.com/lib v1.2.3\n")
+			writeGoMod(t, filepath.Join(repoDir, "lib"), "module example.com/lib\n\ngo 1.25\n")
+			writeSource("package lib\n\nfunc Foo() int { return 1 }\n")
+			git(t, repoDir, "add", ".")
+			git(t, repoDir, "commit", "--quiet", "--message", "Initial")
+			git(t, repoDir, "tag", "lib/v1.2.3")
+			writeSource(tt.source)
+			git(t, repoDir, "commit", "--quiet", "--all", "--message", "Change Foo")
+			remoteDir := t.TempDir()
+			git(t, remoteDir, "init", "--quiet", "--bare")
+			git(t, repoDir, "remote", "add", "origin", remoteDir)
+			git(t, repoDir, "push", "--quiet", "--tags", "--set-upstream", "origin", "main")
+
+			result, err := Release(t.Context(), ReleaseArgs{
+				StartDir: repoDir,
+				DryRun:   true,
+				// Scan nothing beyond the start repo
+				Config: &Config{ScanDirs: []dt.DirPath{dt.DirPath(t.TempDir())}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Leaf.Verdict != VerdictLikelyBreaking {
+				t.Fatalf("got verdict %s (%s), want %s", result.Leaf.Verdict, result.Leaf.VerdictReason, VerdictLikelyBreaking)
+			}
+			if result.Version != tt.want || result.Tag != "lib/"+tt.want {
+				t.Errorf("got version %q tagged %q, want %q", result.Version, result.Tag, tt.want)
+			}
+			if result.VersionWarning == "" {
+				t.Error("got no warning for releasing a likely_breaking verdict compatibly")
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package gompkg
+
+import (
+	"context"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gitutils"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// Values of ReleaseArgs.VerifyProxy other than a GOPROXY URL
+const (
+	// VerifyProxyLocal verifies the new tag through a file:// proxy populated
+	// from the local repo, before the tag is pushed and without network access
+	VerifyProxyLocal = "local"
+
+	// VerifyProxyOff skips verifying that the release resolves
+	VerifyProxyOff = "off"
+)
+
+// verifyRelease checks that the journaled release's version resolves through
+// its verify proxy as the module path it was released as, catching tags whose
+// prefix does not match the module's go.mod before dependents try to `go get`
+// them
+func verifyRelease(ctx context.Context, repo *gitutils.Repo, j *ReleaseJournal) (err error) {
+	var subdir string
+	var tempDir string
+	var proxy goutils.FileProxy
+	var verifyArgs goutils.VerifyResolvesArgs
+
+	verifyArgs = goutils.VerifyResolvesArgs{
+		ModulePath: j.ModulePath,
+		Version:    dt.Version(j.Version),
+		GoProxy:    j.VerifyProxy,
+	}
+	if j.VerifyProxy != "" && j.VerifyProxy != VerifyProxyLocal {
+		err = goutils.VerifyModuleResolves(ctx, verifyArgs)
+		goto end
+	}
+
+	subdir = tagModuleSubdir(ctx, repo, j)
+
+	tempDir, err = os.MkdirTemp("", "gomion-proxy-*")
+	if err != nil {
+		goto end
+	}
+	defer func() { _ = os.RemoveAll(tempDir) }()
+
+	proxy = goutils.FileProxy{Dir: dt.DirPath(tempDir)}
+	err = proxy.AddVersion(goutils.AddProxyVersionArgs{
+		ModulePath: j.ModulePath,
+		Version:    dt.Version(j.Version),
+		RepoRoot:   j.RepoDir,
+		Revision:   j.Tag,
+		Subdir:     subdir,
+	})
+	if err != nil {
+		goto end
+	}
+
+	// The checksum database has never seen an unpushed version
+	verifyArgs.GoProxy = proxy.URL()
+	verifyArgs.NoSumDB = true
+	err = goutils.VerifyModuleResolves(ctx, verifyArgs)
+
+end:
+	if err != nil {
+		err = NewErr(ErrReleaseVerify, "verify_proxy", j.VerifyProxy, err)
+	}
+	return err
+}
+
+// tagModuleSubdir returns the directory the go command would take the
+// release's module from: the tag's prefix, or its /vN major version
+// subdirectory if that holds a go.mod for the module. It is derived from the
+// tag rather than the module's directory so a tag with the wrong prefix
+// yields a zip whose go.mod does not match and fails verification.
+func tagModuleSubdir(ctx context.Context, repo *gitutils.Repo, j *ReleaseJournal) (subdir string) {
+	var pathMajor, majorDir string
+	var content []byte
+	var err error
+
+	subdir = gitutils.ModuleTagPrefix(j.Tag)
+	_, pathMajor, _ = module.SplitPathVersion(string(j.ModulePath))
+	if !strings.HasPrefix(pathMajor, "/") {
+		// No /vN suffix, or a gopkg.in ".vN" one which has no subdirectory form
+		goto end
+	}
+	majorDir = path.Join(subdir, pathMajor[1:])
+	content, err = repo.ShowFile(ctx, j.Tag, dt.RelFilepath(path.Join(majorDir, "go.mod")))
+	if err != nil {
+		// No go.mod in the major version subdirectory at the tag
+		goto end
+	}
+	if modfile.ModulePath(content) == string(j.ModulePath) {
+		subdir = majorDir
+	}
+
+end:
+	return subdir
+}
@@ -0,0 +1,57 @@
+package gompkg
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gitutils"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+)
+
+func TestTagModuleSubdir(t *testing.T) {
+	repoDir := t.TempDir()
+	git(t, repoDir, "init", "--quiet", "--initial-branch=main")
+	writeGoMod(t, repoDir, "module github.com/example/app\n\ngo 1.25\n")
+	writeGoMod(t, filepath.Join(repoDir, "v2"), "module github.com/example/app/v2\n\ngo 1.25\n")
+	writeGoMod(t, filepath.Join(repoDir, "lib"), "module github.com/example/app/lib\n\ngo 1.25\n")
+	git(t, repoDir, "add", ".")
+	git(t, repoDir, "commit", "--quiet", "--message", "Initial")
+	for _, tag := range []string{"v1.0.0", "v2.0.0", "lib/v1.0.0", "cmd/v1.0.0"} {
+		git(t, repoDir, "tag", tag)
+	}
+	remoteDir := t.TempDir()
+	git(t, remoteDir, "init", "--quiet", "--bare")
+	git(t, repoDir, "remote", "add", "origin", remoteDir)
+	git(t, repoDir, "push", "--quiet", "--set-upstream", "origin", "main")
+
+	repo, err := gitutils.Open(dt.DirPath(repoDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		module string
+		tag    string
+		want   string
+	}{
+		{name: "RepoRoot", module: "github.com/example/app", tag: "v1.0.0", want: ""},
+		{name: "MajorSubdir", module: "github.com/example/app/v2", tag: "v2.0.0", want: "v2"},
+		{name: "Subdir", module: "github.com/example/app/lib", tag: "lib/v1.0.0", want: "lib"},
+		// The tag's prefix is used even though the module is elsewhere so
+		// verification fails the way `go get` would
+		{name: "MismatchedPrefix", module: "github.com/example/app/lib", tag: "cmd/v1.0.0", want: "cmd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tagModuleSubdir(t.Context(), repo, &ReleaseJournal{
+				ModulePath: goutils.ModulePath(tt.module),
+				Tag:        tt.tag,
+			})
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
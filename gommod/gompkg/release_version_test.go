@@ -0,0 +1,56 @@
+package gompkg
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPrereleaseVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  string
+		label    string
+		baseline string
+		want     string
+		wantErr  error
+	}{
+		{name: "NoLabel", version: "v1.3.0", baseline: "v1.2.0", want: "v1.3.0"},
+		{name: "StartsSeries", version: "v1.3.0", label: "rc", baseline: "v1.2.0", want: "v1.3.0-rc.1"},
+		{name: "NoBaseline", version: "v0.1.0", label: "alpha", want: "v0.1.0-alpha.1"},
+		{name: "ContinuesBaselineSeries", version: "v1.3.0", label: "rc", baseline: "v1.3.0-rc.1", want: "v1.3.0-rc.2"},
+		{name: "ContinuesModuleTagSeries", version: "v1.3.0", label: "rc", baseline: "cmd/v1.3.0-rc.2", want: "v1.3.0-rc.3"},
+		{name: "SwitchesToLaterSeries", version: "v1.3.0", label: "rc", baseline: "v1.3.0-beta.2", want: "v1.3.0-rc.1"},
+		{name: "ExplicitPrereleaseKept", version: "v1.3.0-rc.5", label: "rc", baseline: "v1.3.0-rc.1", want: "v1.3.0-rc.5"},
+		{name: "RejectsEarlierSeries", version: "v1.3.0", label: "beta", baseline: "v1.3.0-rc.1", wantErr: ErrVersionNotAfterBaseline},
+		{name: "InvalidLabel", version: "v1.3.0", label: "r_c", baseline: "v1.2.0", wantErr: ErrInvalidVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := prereleaseVersion(tt.version, tt.label, tt.baseline)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("got %q, %v, want error %v", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIgnorePrereleases(t *testing.T) {
+	if ignorePrereleases(false, nil) {
+		t.Error("got true with no flag and no config")
+	}
+	if !ignorePrereleases(true, nil) {
+		t.Error("got false with the flag set")
+	}
+	if !ignorePrereleases(false, &Config{IgnorePrereleases: true}) {
+		t.Error("got false with the config set")
+	}
+}
@@ -0,0 +1,205 @@
+package gompkg
+
+import (
+	"context"
+	"encoding/json/jsontext"
+	jsonv2 "encoding/json/v2"
+	"sort"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+)
+
+// WaveModule is an in-flux module scheduled for release in a ReleaseWave
+type WaveModule struct {
+	ModulePath goutils.ModulePath `json:"module_path"`
+	ModuleDir  dt.DirPath         `json:"module_dir"`
+	RepoDir    dt.DirPath         `json:"repo_dir"`
+	Reason     string             `json:"reason"`
+
+	// Unlocks is the number of in-flux modules that directly require this module
+	Unlocks int `json:"unlocks"`
+}
+
+// ReleaseWave is a group of in-flux modules whose in-flux dependencies are all
+// released in earlier waves, so the modules in a wave can be released in parallel
+type ReleaseWave struct {
+	Number  int          `json:"number"`
+	Modules []WaveModule `json:"modules"`
+}
+
+// ReleaseWaves is the complete release ordering, first wave first
+type ReleaseWaves []ReleaseWave
+
+// ModuleCount returns the total number of modules across all waves
+func (ws ReleaseWaves) ModuleCount() (n int) {
+	for _, w := range ws {
+		n += len(w.Modules)
+	}
+	return n
+}
+
+// JSON returns JSON representation of the release waves
+func (ws ReleaseWaves) JSON() (jsonText string) {
+	var data []byte
+	var err error
+
+	data, err = jsonv2.Marshal(ws, jsontext.WithIndent("  "))
+	if err != nil {
+		jsonText = "[]"
+		goto end
+	}
+
+	jsonText = string(data)
+
+end:
+	return jsonText
+}
+
+// TableWriter returns a configured table.Writer for pretty printing the release waves
+func (ws ReleaseWaves) TableWriter() (tw table.Writer) {
+	tw = table.NewWriter()
+
+	if len(ws) > 0 {
+		tw.AppendHeader(table.Row{
+			"WAVE",
+			"MODULE PATH",
+			"MODULE DIR",
+			"REASON",
+			"UNLOCKS",
+		})
+
+		for _, w := range ws {
+			for _, m := range w.Modules {
+				tw.AppendRow(table.Row{
+					w.Number,
+					m.ModulePath,
+					m.ModuleDir.ToTilde(dt.OrFullPath),
+					m.Reason,
+					m.Unlocks,
+				})
+			}
+			tw.AppendSeparator()
+		}
+	}
+
+	tw.SetColumnConfigs([]table.ColumnConfig{
+		{Number: 1, Align: text.AlignCenter}, // WAVE
+		{Number: 2, Align: text.AlignLeft},   // MODULE PATH
+		{Number: 3, Align: text.AlignLeft},   // MODULE DIR
+		{Number: 4, Align: text.AlignLeft},   // REASON
+		{Number: 5, Align: text.AlignRight},  // UNLOCKS
+	})
+
+	tw.SetStyle(table.StyleLight)
+
+	return tw
+}
+
+// computeReleaseWaves groups every in-flux module reachable from the start repo
+// into waves. A module's wave is one more than the highest wave of its in-flux
+// dependencies, so wave 1 contains exactly the leaf candidates.
+func (e *ReleaseEngine) computeReleaseWaves(ctx context.Context) (waves ReleaseWaves, err error) {
+	var traverseResult *goutils.TraverseResult
+	var order []goutils.ModuleDir
+	var waveOf func(modDir goutils.ModuleDir) int
+
+	reasons := make(map[goutils.ModuleDir]string)
+	waveByDir := make(map[goutils.ModuleDir]int)
+	unlocks := make(map[goutils.ModuleDir]int)
+	modulesByWave := make(map[int][]WaveModule)
+
+	traverseResult, err = e.graph.Traverse()
+	if err != nil {
+		goto end
+	}
+
+	// Collect in-flux modules in dependency order
+	for _, moduleDirs := range traverseResult.RepoModules.Iterator() {
+		for _, modDir := range moduleDirs {
+			var inFlux bool
+			var reason string
+
+			err = ctx.Err()
+			if err != nil {
+				goto end
+			}
+
+			module, ok := e.graph.ModulesByModuleDir[modDir]
+			if !ok {
+				continue
+			}
+
+			inFlux, reason, err = e.isModuleInFlux(ctx, module)
+			if err != nil {
+				// Log error but continue with the remaining modules
+				if e.args.Logger != nil {
+					e.args.Logger.Warn("Error checking in-flux status", "module", modDir, "error", err)
+				}
+				err = nil
+				continue
+			}
+			if !inFlux {
+				continue
+			}
+			reasons[modDir] = reason
+			order = append(order, modDir)
+		}
+	}
+
+	waveOf = func(modDir goutils.ModuleDir) (wave int) {
+		wave, ok := waveByDir[modDir]
+		if ok {
+			return wave
+		}
+		// Guard against require cycles before recursing
+		waveByDir[modDir] = 0
+		for _, depDir := range e.graph.ModulesByModuleDir[modDir].RequireDirs() {
+			_, ok = reasons[depDir]
+			if !ok {
+				// Dependency is not in-flux so it does not hold this module back
+				continue
+			}
+			wave = max(wave, waveOf(depDir))
+		}
+		wave++
+		waveByDir[modDir] = wave
+		return wave
+	}
+
+	for _, modDir := range order {
+		for _, depDir := range e.graph.ModulesByModuleDir[modDir].RequireDirs() {
+			_, ok := reasons[depDir]
+			if ok {
+				unlocks[depDir]++
+			}
+		}
+	}
+
+	for _, modDir := range order {
+		wave := waveOf(modDir)
+		modulesByWave[wave] = append(modulesByWave[wave], WaveModule{
+			ModulePath: e.graph.ModulesByModuleDir[modDir].Path,
+			ModuleDir:  modDir,
+			RepoDir:    e.graph.ReposByModuleDir[modDir].DirPath,
+			Reason:     reasons[modDir],
+			Unlocks:    unlocks[modDir],
+		})
+	}
+
+	waves = make(ReleaseWaves, 0, len(modulesByWave))
+	for number, modules := range modulesByWave {
+		waves = append(waves, ReleaseWave{
+			Number:  number,
+			Modules: modules,
+		})
+	}
+	sort.Slice(waves, func(i, j int) bool {
+		return waves[i].Number < waves[j].Number
+	})
+
+end:
+	return waves, err
+}
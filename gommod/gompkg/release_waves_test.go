@@ -0,0 +1,108 @@
+package gompkg
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+)
+
+// releaseWaves runs the engine for next --all in a repo holding modules, each
+// a module dir and its go.mod content, after tagging tags and pushing
+func releaseWaves(t *testing.T, modules map[string]string, tags ...string) (waves ReleaseWaves) {
+	t.Helper()
+	repoDir := t.TempDir()
+	git(t, repoDir, "init", "--quiet", "--initial-branch=main")
+	for dir, content := range modules {
+		writeGoMod(t, filepath.Join(repoDir, dir), content)
+	}
+	git(t, repoDir, "add", ".")
+	git(t, repoDir, "commit", "--quiet", "--message", "Initial")
+	for _, tag := range tags {
+		git(t, repoDir, "tag", tag)
+	}
+	remoteDir := t.TempDir()
+	git(t, remoteDir, "init", "--quiet", "--bare")
+	git(t, repoDir, "remote", "add", "origin", remoteDir)
+	git(t, repoDir, "push", "--quiet", "--tags", "--set-upstream", "origin", "main")
+
+	result, err := NewReleaseEngine(EngineArgs{
+		StartDir: repoDir,
+		// Scan nothing beyond the start repo
+		Config:   &Config{ScanDirs: []dt.DirPath{dt.DirPath(t.TempDir())}},
+		AllWaves: true,
+	}).Run(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result.Waves
+}
+
+// waveNumbers maps each module in waves to its wave number
+func waveNumbers(waves ReleaseWaves) (numbers map[goutils.ModulePath]int) {
+	numbers = make(map[goutils.ModulePath]int)
+	for _, w := range waves {
+		for _, m := range w.Modules {
+			numbers[m.ModulePath] = w.Number
+		}
+	}
+	return numbers
+}
+
+func TestComputeReleaseWaves(t *testing.T) {
+	t.Run("DependencyOrder", func(t *testing.T) {
+		// Waves hold the modules the start repo requires, so app is not one
+		waves := releaseWaves(t, map[string]string{
+			"app":    "module example.com/app\n\ngo 1.25\n\nrequire example.com/c v0.1.0\n",
+			"a":      "module example.com/a\n\ngo 1.25\n",
+			"b":      "module example.com/b\n\ngo 1.25\n\nrequire (\n\texample.com/a v0.1.0\n\texample.com/stable v1.0.0\n)\n",
+			"c":      "module example.com/c\n\ngo 1.25\n\nrequire (\n\texample.com/a v0.1.0\n\texample.com/b v0.1.0\n)\n",
+			"stable": "module example.com/stable\n\ngo 1.25\n",
+		}, "stable/v1.0.0")
+
+		got := waveNumbers(waves)
+		want := map[goutils.ModulePath]int{"example.com/a": 1, "example.com/b": 2, "example.com/c": 3}
+		if len(got) != len(want) {
+			t.Fatalf("got waves %v, want %v", got, want)
+		}
+		for modulePath, number := range want {
+			if got[modulePath] != number {
+				t.Errorf("got %s in wave %d, want wave %d", modulePath, got[modulePath], number)
+			}
+		}
+
+		wantUnlocks := map[goutils.ModulePath]int{"example.com/a": 2, "example.com/b": 1, "example.com/c": 0}
+		for _, w := range waves {
+			for _, m := range w.Modules {
+				if m.Unlocks != wantUnlocks[m.ModulePath] {
+					t.Errorf("got %s unlocking %d, want %d", m.ModulePath, m.Unlocks, wantUnlocks[m.ModulePath])
+				}
+				if m.Reason == "" {
+					t.Errorf("got no reason for %s", m.ModulePath)
+				}
+			}
+		}
+	})
+
+	t.Run("RequireCycle", func(t *testing.T) {
+		waves := releaseWaves(t, map[string]string{
+			"app": "module example.com/app\n\ngo 1.25\n\nrequire example.com/x v0.1.0\n",
+			"x":   "module example.com/x\n\ngo 1.25\n\nrequire example.com/y v0.1.0\n",
+			"y":   "module example.com/y\n\ngo 1.25\n\nrequire example.com/x v0.1.0\n",
+		})
+
+		got := waveNumbers(waves)
+		if len(got) != 2 {
+			t.Fatalf("got waves %v, want both modules of the cycle scheduled", got)
+		}
+		// The guard breaks the cycle at whichever module is reached first, so
+		// the other lands in the wave before it
+		if got["example.com/x"] == got["example.com/y"] {
+			t.Errorf("got both modules in wave %d, want consecutive waves", got["example.com/x"])
+		}
+		if waves.ModuleCount() != 2 {
+			t.Errorf("got %d modules, want 2", waves.ModuleCount())
+		}
+	})
+}
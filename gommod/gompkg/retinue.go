@@ -6,6 +6,7 @@ import (
 	"github.com/mikeschinkel/go-cfgstore"
 	"github.com/mikeschinkel/go-dt"
 	"github.com/mikeschinkel/gomion/gommod/gomion"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
 	"golang.org/x/mod/modfile"
 )
 
@@ -17,6 +18,7 @@ const (
 	LibModuleKind
 	ExeModuleKind
 	TestModuleKind
+	ToolModuleKind
 )
 
 // String returns the string representation of ModuleKind
@@ -28,6 +30,8 @@ func (k ModuleKind) String() string {
 		return "exe"
 	case TestModuleKind:
 		return "test"
+	case ToolModuleKind:
+		return "tool"
 	default:
 		return "unspecified"
 	}
@@ -58,6 +62,11 @@ type Module struct {
 	//
 	// Only requires that are themselves known Gomion-managed modules are included.
 	Requires []ModulePath
+
+	// Tools lists the Go module paths of Gomion-managed modules this module
+	// requires only to provide packages named by its "tool" directives. These
+	// are not included in Requires.
+	Tools []ModulePath
 }
 
 // RepoName returns the human-readable repository name (last component of repo root path)
@@ -67,6 +76,11 @@ func (m *Module) RepoName() (name string) {
 	return name
 }
 
+// Dir returns the module's directory
+func (m *Module) Dir() dt.DirPath {
+	return dt.DirPathJoin(m.RepoRoot, m.RelDir.TrimPrefix("./"))
+}
+
 // ShortName returns the short module name (last component of module path)
 // Example: github.com/mikeschinkel/go-dt -> "go-dt"
 func (m *Module) ShortName() (name string) {
@@ -207,16 +221,23 @@ end:
 }
 
 // determineModuleKind determines the ModuleKind based on the relative directory
-func determineModuleKind(relDir dt.PathSegments) ModuleKind {
+// and, for tools modules, the go.mod
+func determineModuleKind(relDir dt.PathSegments, mf *modfile.File) ModuleKind {
 	var normalized dt.PathSegments
 
 	normalized = relDir.TrimPrefix("./").ToLower()
 
+	if isToolsModule(mf) {
+		return ToolModuleKind
+	}
+
 	switch normalized {
 	case "test", "tests":
 		return TestModuleKind
 	case "cmd":
 		return ExeModuleKind
+	case "tool", "tools":
+		return ToolModuleKind
 	default:
 		return UnspecifiedModuleKind
 	}
@@ -239,10 +260,24 @@ func determineModuleKind(relDir dt.PathSegments) ModuleKind {
 	return LibModuleKind
 }
 
+// isToolsModule returns true if mf declares tools and imports nothing itself,
+// i.e. every require is indirect, as for a module that only pins tool versions
+func isToolsModule(mf *modfile.File) bool {
+	if mf == nil || len(mf.Tool) == 0 {
+		return false
+	}
+	for _, req := range mf.Require {
+		if !req.Indirect {
+			return false
+		}
+	}
+	return true
+}
+
 // determineVersioned determines if a module should be versioned based on its kind
 func determineVersioned(kind ModuleKind) bool {
-	// Test modules are not versioned
-	return kind != TestModuleKind
+	// Test and tool modules are not versioned
+	return kind != TestModuleKind && kind != ToolModuleKind
 }
 
 // parseGoMod reads and parses a go.mod file
@@ -254,8 +289,9 @@ func parseGoMod(goModPath dt.Filepath) (mf *modfile.File, err error) {
 		goto end
 	}
 
-	// Use ParseLax for syntax-only validation (go.mod may not be buildable during local dev)
-	mf, err = modfile.ParseLax(string(goModPath), content, nil)
+	// Parse strictly as ParseLax drops the tool directives that become tool
+	// edges; this still validates syntax only, not buildability
+	mf, err = modfile.Parse(string(goModPath), content, nil)
 	if err != nil {
 		goto end
 	}
@@ -387,6 +423,7 @@ func discoverSingleRepoModules(repoRoot dt.DirPath, ms *ModuleSet) (err error) {
 	var mf *modfile.File
 	var module *Module
 	var require *modfile.Require
+	var toolProviders map[ModulePath]struct{}
 
 	// Create config store for this repo
 	store = cfgstore.NewConfigStore(cfgstore.ProjectConfigDirType, cfgstore.ConfigStoreArgs{
@@ -407,8 +444,9 @@ func discoverSingleRepoModules(repoRoot dt.DirPath, ms *ModuleSet) (err error) {
 	}
 
 	// Process each module from config
-	for moduleDir = range repoConfig.Modules {
-		// Determine module directory
+	for configDir := range repoConfig.Modules {
+		// Determine module directory; config keys are relative to the repo root
+		relDir = dt.PathSegments(configDir)
 		moduleDir = dt.DirPathJoin(repoRoot, relDir.TrimPrefix("./"))
 
 		// Read go.mod
@@ -434,8 +472,9 @@ func discoverSingleRepoModules(repoRoot dt.DirPath, ms *ModuleSet) (err error) {
 			RepoRoot:   repoRoot,
 			RelDir:     relDir,
 			ModulePath: ModulePath(mf.Module.Mod.Path),
-			Kind:       determineModuleKind(relDir),
+			Kind:       determineModuleKind(relDir, mf),
 			Requires:   make([]ModulePath, 0),
+			Tools:      make([]ModulePath, 0),
 		}
 
 		module.Versioned = determineVersioned(module.Kind)
@@ -445,7 +484,9 @@ func discoverSingleRepoModules(repoRoot dt.DirPath, ms *ModuleSet) (err error) {
 	}
 
 	// Second pass: build dependencies
-	for moduleDir = range repoConfig.Modules {
+	for configDir := range repoConfig.Modules {
+		relDir = dt.PathSegments(configDir)
+		moduleDir = dt.DirPathJoin(repoRoot, relDir.TrimPrefix("./"))
 		goModPath = dt.FilepathJoin(moduleDir, "go.mod")
 
 		mf, err = parseGoMod(goModPath)
@@ -461,12 +502,20 @@ func discoverSingleRepoModules(repoRoot dt.DirPath, ms *ModuleSet) (err error) {
 			goto end
 		}
 
-		// Add requires that are in our module set
+		// Add requires that are in our module set, keeping those only required
+		// for tools separate
+		toolProviders = toolProviderPaths(mf)
 		for _, require = range mf.Require {
 			_, inSet := ms.Get(ModulePath(require.Mod.Path))
-			if inSet {
-				module.Requires = append(module.Requires, ModulePath(require.Mod.Path))
+			if !inSet {
+				continue
 			}
+			_, isTool := toolProviders[ModulePath(require.Mod.Path)]
+			if isTool && require.Indirect {
+				module.Tools = append(module.Tools, ModulePath(require.Mod.Path))
+				continue
+			}
+			module.Requires = append(module.Requires, ModulePath(require.Mod.Path))
 		}
 	}
 
@@ -474,6 +523,25 @@ end:
 	return err
 }
 
+// toolProviderPaths returns the paths of the required modules providing the
+// packages named by mf's tool directives
+func toolProviderPaths(mf *modfile.File) (providers map[ModulePath]struct{}) {
+	var paths []goutils.ModulePath
+
+	providers = make(map[ModulePath]struct{}, len(mf.Tool))
+	paths = make([]goutils.ModulePath, 0, len(mf.Require))
+	for _, req := range mf.Require {
+		paths = append(paths, goutils.ModulePath(req.Mod.Path))
+	}
+	for _, tool := range mf.Tool {
+		provider, ok := goutils.ToolProvider(tool.Path, paths)
+		if ok {
+			providers[ModulePath(provider)] = struct{}{}
+		}
+	}
+	return providers
+}
+
 // findRepoRootFromDir finds the repo root starting from a directory
 func findRepoRootFromDir(startDir dt.DirPath) (repoRoot dt.DirPath, err error) {
 	var dir dt.DirPath
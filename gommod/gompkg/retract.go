@@ -0,0 +1,178 @@
+package gompkg
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"slices"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gitutils"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+)
+
+// RetractArgs contains the input parameters for Retract
+type RetractArgs struct {
+	// StartDir is the directory to start scanning from (defaults to ".")
+	StartDir string
+
+	// Module selects the module by module path, last path element or directory
+	Module string
+
+	// Versions is the version or "[low, high]" interval to retract
+	Versions string
+
+	// Reason is the rationale recorded as the retract directive's comment
+	Reason string
+
+	Config *Config
+	Logger *slog.Logger
+	Writer cliutil.Writer
+}
+
+// RetractResult contains the outcome of Retract
+type RetractResult struct {
+	ModulePath goutils.ModulePath
+	ModuleDir  dt.DirPath
+
+	// Retract is the directive added to go.mod
+	Retract goutils.Retract
+
+	// Tags lists the module's tags the directive retracts
+	Tags []string
+
+	// Edit is the change made to go.mod
+	Edit MajorFileEdit
+
+	// Commit is the commit holding the edit
+	Commit string
+}
+
+// Retract adds a retract directive for versions of a module that have been
+// tagged and commits it. The commit leaves the module in-flux so the engine
+// selects it to release a patch carrying the retraction, whose rationale the
+// release notes then list.
+func Retract(ctx context.Context, args RetractArgs) (result *RetractResult, err error) {
+	var graph *goutils.ModuleGraph
+	var module *goutils.Module
+	var repo *gitutils.Repo
+	var modRelPath dt.PathSegments
+	var goModFile dt.RelFilepath
+	var changed []dt.RelFilepath
+	var tags []string
+
+	result = &RetractResult{}
+
+	if args.StartDir == "" {
+		args.StartDir = "."
+	}
+
+	result.Retract, err = goutils.ParseRetract(args.Versions, args.Reason)
+	if err != nil {
+		goto end
+	}
+
+	graph, err = LoadModuleGraph(LoadModuleGraphArgs{
+		StartDir: args.StartDir,
+		Config:   args.Config,
+		Logger:   args.Logger,
+		Writer:   args.Writer,
+	})
+	if err != nil {
+		goto end
+	}
+
+	module, err = selectModule(graph, args.StartDir, args.Module)
+	if err != nil {
+		goto end
+	}
+	result.ModulePath = module.Path
+	result.ModuleDir = module.Dir()
+
+	repo, err = gitutils.Open(module.Dir())
+	if err != nil {
+		goto end
+	}
+	modRelPath, err = module.Dir().Rel(repo.Root)
+	if err != nil {
+		goto end
+	}
+
+	// Only versions that were actually published can be retracted
+	tags, err = repo.Tags(ctx, string(modRelPath))
+	if err != nil {
+		goto end
+	}
+	for _, tag := range tags {
+		if result.Retract.Contains(dt.Version(gitutils.ModuleTagVersion(tag))) {
+			result.Tags = append(result.Tags, tag)
+		}
+	}
+	if len(result.Tags) == 0 {
+		err = NewErr(ErrVersionNotTagged, "versions", result.Retract)
+		goto end
+	}
+
+	if slices.ContainsFunc(module.Retracts(), result.Retract.SameInterval) {
+		err = NewErr(ErrAlreadyRetracted, "versions", result.Retract)
+		goto end
+	}
+
+	// Only the retraction may go into its commit
+	goModFile = dt.RelFilepath(path.Join(string(modRelPath), "go.mod"))
+	changed, err = repo.GetChangedFiles(ctx, &gitutils.StatusArgs{
+		Path: modRelPath,
+		FileFilter: func(file dt.RelFilepath) bool {
+			return file == goModFile
+		},
+	})
+	if err != nil {
+		goto end
+	}
+	if len(changed) > 0 {
+		err = NewErr(ErrGoModChanged, "file", module.Filepath)
+		goto end
+	}
+
+	result.Edit.File = module.Filepath
+	result.Edit.Old, err = module.Filepath.ReadFile()
+	if err != nil {
+		goto end
+	}
+
+	err = module.AddRetract(result.Retract)
+	if err != nil {
+		goto end
+	}
+	result.Edit.New, err = module.Format()
+	if err != nil {
+		goto end
+	}
+	err = module.Save()
+	if err != nil {
+		goto end
+	}
+
+	err = repo.CommitPaths(ctx, result.commitMessage(), goModFile)
+	if err != nil {
+		goto end
+	}
+	result.Commit, err = repo.RevParse("HEAD")
+
+end:
+	if err != nil {
+		err = WithErr(err, "module", args.Module)
+	}
+	return result, err
+}
+
+// commitMessage returns the message of the commit holding the retraction,
+// whose body is the rationale
+func (r *RetractResult) commitMessage() (msg string) {
+	msg = "Retract " + string(r.ModulePath) + " " + r.Retract.String()
+	if r.Retract.Rationale != "" {
+		msg += "\n\n" + r.Retract.Rationale
+	}
+	return msg
+}
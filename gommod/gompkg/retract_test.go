@@ -0,0 +1,63 @@
+package gompkg
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+func TestRetractCommitsGoMod(t *testing.T) {
+	// Retract commits with the process environment
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	root := t.TempDir()
+	remoteDir := filepath.Join(root, "remote.git")
+	repoDir := filepath.Join(root, "repo")
+	git(t, root, "init", "--quiet", "--bare", remoteDir)
+	git(t, root, "init", "--quiet", "--initial-branch=main", repoDir)
+	git(t, repoDir, "remote", "add", "origin", remoteDir)
+	writeGoMod(t, repoDir, "module github.com/example/app\n\ngo 1.25\n")
+	git(t, repoDir, "add", "go.mod")
+	git(t, repoDir, "commit", "--quiet", "--message", "Initial")
+	git(t, repoDir, "tag", "v1.0.0")
+	git(t, repoDir, "push", "--quiet", "--tags", "--set-upstream", "origin", "main")
+
+	args := RetractArgs{
+		StartDir: repoDir,
+		Versions: "v1.0.0",
+		Reason:   "Broken build",
+		// Scan nothing beyond the start repo
+		Config: &Config{ScanDirs: []dt.DirPath{dt.DirPath(t.TempDir())}},
+	}
+
+	t.Run("GoModChanged", func(t *testing.T) {
+		writeGoMod(t, repoDir, "module github.com/example/app\n\ngo 1.25.0\n")
+		t.Cleanup(func() { git(t, repoDir, "checkout", "--quiet", "go.mod") })
+		_, err := Retract(t.Context(), args)
+		if !errors.Is(err, ErrGoModChanged) {
+			t.Errorf("got error %v, want ErrGoModChanged", err)
+		}
+	})
+
+	t.Run("Commits", func(t *testing.T) {
+		result, err := Retract(t.Context(), args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if head := git(t, repoDir, "rev-parse", "HEAD"); head != result.Commit {
+			t.Errorf("got HEAD %s, want the retraction commit %s", head, result.Commit)
+		}
+		if status := git(t, repoDir, "status", "--porcelain"); status != "" {
+			t.Errorf("got status %q, want a clean tree", status)
+		}
+		want := "Retract github.com/example/app v1.0.0\n\nBroken build"
+		if msg := git(t, repoDir, "log", "-1", "--format=%B"); msg != want {
+			t.Errorf("got message %q, want %q", msg, want)
+		}
+	})
+}
@@ -0,0 +1,471 @@
+package gompkg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json/jsontext"
+	jsonv2 "encoding/json/v2"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+)
+
+// RunTask names the go command RunModules runs in each module
+type RunTask string
+
+const (
+	TestRunTask  RunTask = "test"
+	VetRunTask   RunTask = "vet"
+	BuildRunTask RunTask = "build"
+	LintRunTask  RunTask = "lint"
+)
+
+// RunTasks lists the valid RunTask values
+var RunTasks = []RunTask{TestRunTask, VetRunTask, BuildRunTask, LintRunTask}
+
+// LintExecutable is the name of golangci-lint looked up in PATH
+const LintExecutable = "golangci-lint"
+
+// runCommand is the command RunModules runs in one module
+type runCommand struct {
+	name string
+	args []string
+}
+
+// String returns the command line
+func (c runCommand) String() string {
+	return strings.Join(append([]string{c.name}, c.args...), " ")
+}
+
+// command returns the command that runs the task in module. Lint runs the
+// golangci-lint of the module's tool directive if it has one, else linter.
+func (t RunTask) command(module *goutils.Module, linter string) runCommand {
+	switch {
+	case t != LintRunTask:
+		return runCommand{name: "go", args: []string{string(t), "./..."}}
+	case hasLintTool(module):
+		return runCommand{name: "go", args: []string{"tool", "golangci-lint", "run", "./..."}}
+	default:
+		return runCommand{name: linter, args: []string{"run", "./..."}}
+	}
+}
+
+// hasLintTool returns true if module declares golangci-lint in a tool directive
+func hasLintTool(module *goutils.Module) bool {
+	return slices.ContainsFunc(module.Tools, func(tool string) bool {
+		return strings.HasSuffix(tool, "/golangci-lint")
+	})
+}
+
+// findLinter returns the golangci-lint executable set in cfg, else the one
+// found in PATH
+func findLinter(cfg *Config) (linter string, err error) {
+	linter = LintExecutable
+	if cfg != nil && cfg.LintCommand != "" {
+		linter = cfg.LintCommand
+	}
+	linter, err = exec.LookPath(linter)
+	if err != nil {
+		err = NewErr(ErrLinterNotFound,
+			"hint", "install golangci-lint, declare it in a tool directive or set lint_command in the config",
+			err,
+		)
+	}
+	return linter, err
+}
+
+// RunStatus is the outcome of running a task in one module
+type RunStatus string
+
+const (
+	RunPassed RunStatus = "passed"
+	RunFailed RunStatus = "failed"
+
+	// RunSkipped means a module it requires failed or was skipped, or the run
+	// was cancelled before it started
+	RunSkipped RunStatus = "skipped"
+)
+
+// RunArgs contains the input parameters for RunModules
+type RunArgs struct {
+	// StartDir is any directory inside the starting repo (defaults to ".")
+	StartDir string
+
+	Task RunTask
+
+	// Jobs is the most modules to run at once (defaults to the number of CPUs)
+	Jobs int
+
+	// Hook, if set, receives progress messages and each line of output,
+	// prefixed with the module's name
+	Hook StreamingHook
+
+	Config *Config
+	Logger *slog.Logger
+	Writer cliutil.Writer
+}
+
+// ModuleRun is the outcome of running a task in one module
+type ModuleRun struct {
+	ModulePath goutils.ModulePath `json:"module_path"`
+	ModuleDir  dt.DirPath         `json:"module_dir"`
+	Status     RunStatus          `json:"status"`
+	Command    string             `json:"command"`
+	Seconds    float64            `json:"seconds"`
+	Output     string             `json:"output,omitempty"`
+	Error      string             `json:"error,omitempty"`
+
+	// BlockedBy is the required module whose failure caused a skip
+	BlockedBy goutils.ModulePath `json:"blocked_by,omitempty"`
+}
+
+// RunResult contains the outcome of RunModules
+type RunResult struct {
+	Task RunTask `json:"task"`
+
+	// Modules lists every module in dependency order, dependencies first
+	Modules []ModuleRun `json:"modules"`
+
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+}
+
+// JSON returns the result as an indented JSON report
+func (r *RunResult) JSON() (jsonText string) {
+	var data []byte
+	var err error
+
+	data, err = jsonv2.Marshal(r, jsontext.WithIndent("  "))
+	if err != nil {
+		jsonText = "{}"
+		goto end
+	}
+
+	jsonText = string(data)
+
+end:
+	return jsonText
+}
+
+// runNode is a module scheduled by RunModules
+type runNode struct {
+	module *goutils.Module
+
+	// deps are the indexes of the scheduled modules this one requires
+	deps    []int
+	started bool
+	done    bool
+}
+
+// RunModules runs `go test`, `go vet`, `go build` or golangci-lint in each
+// managed module the repo containing StartDir requires, and in the repo's own
+// modules, in the order ModuleGraph.Traverse visits them. Up to Jobs modules
+// run at once but never before the modules they require have finished, and a
+// module is skipped if one it requires failed or if ctx is done before it
+// starts. Failures are reported per module rather than as an error.
+func RunModules(ctx context.Context, args RunArgs) (result *RunResult, err error) {
+	var graph *goutils.ModuleGraph
+	var nodes []*runNode
+	var commands []runCommand
+	var linter string
+
+	result = &RunResult{Task: args.Task}
+
+	if !slices.Contains(RunTasks, args.Task) {
+		err = NewErr(ErrInvalidRunTask, "task", args.Task)
+		goto end
+	}
+	if args.StartDir == "" {
+		args.StartDir = "."
+	}
+	if args.Jobs <= 0 {
+		args.Jobs = runtime.NumCPU()
+	}
+
+	graph, err = LoadModuleGraph(LoadModuleGraphArgs{
+		StartDir: args.StartDir,
+		Config:   args.Config,
+		Logger:   args.Logger,
+		Writer:   args.Writer,
+	})
+	if err != nil {
+		goto end
+	}
+
+	nodes, err = runOrder(graph)
+	if err != nil {
+		goto end
+	}
+
+	// Only look for golangci-lint if a module lacks its own
+	if args.Task == LintRunTask && slices.ContainsFunc(nodes, func(node *runNode) bool {
+		return !hasLintTool(node.module)
+	}) {
+		linter, err = findLinter(args.Config)
+		if err != nil {
+			goto end
+		}
+	}
+
+	result.Modules = make([]ModuleRun, len(nodes))
+	commands = make([]runCommand, len(nodes))
+	for i, node := range nodes {
+		commands[i] = args.Task.command(node.module, linter)
+		result.Modules[i] = ModuleRun{
+			ModulePath: node.module.Path,
+			ModuleDir:  node.module.Dir(),
+			Command:    commands[i].String(),
+		}
+	}
+
+	runScheduled(ctx, nodes, args.Jobs, func(i int) {
+		result.Modules[i] = runModuleTask(ctx, nodes[i].module, commands[i], result.Modules[i], args)
+	}, func(i, blocker int) {
+		result.Modules[i].Status = RunSkipped
+		if blocker < 0 {
+			result.Modules[i].Error = ctx.Err().Error()
+			streamRun(args.Hook, fmt.Sprintf("- %s skipped: %v", nodes[i].module.Path, ctx.Err()))
+			return
+		}
+		result.Modules[i].BlockedBy = nodes[blocker].module.Path
+		streamRun(args.Hook, fmt.Sprintf("- %s skipped: requires %s", nodes[i].module.Path, nodes[blocker].module.Path))
+	}, func(i int) bool {
+		return result.Modules[i].Status == RunPassed
+	})
+
+	for _, mr := range result.Modules {
+		switch mr.Status {
+		case RunPassed:
+			result.Passed++
+		case RunFailed:
+			result.Failed++
+		case RunSkipped:
+			result.Skipped++
+		}
+	}
+
+end:
+	if err != nil {
+		err = WithErr(err, "task", args.Task, "start_dir", args.StartDir)
+	}
+	return result, err
+}
+
+// runOrder returns the modules to run in traversal order followed by any of
+// the start repo's modules that no other module requires, each with the
+// indexes of the modules it requires
+func runOrder(graph *goutils.ModuleGraph) (nodes []*runNode, err error) {
+	var traverse *goutils.TraverseResult
+	var modules []*goutils.Module
+	var index map[goutils.ModuleDir]int
+
+	traverse, err = graph.Traverse()
+	if err != nil {
+		goto end
+	}
+	for _, modDirs := range traverse.RepoModules.Iterator() {
+		for _, modDir := range modDirs {
+			modules = append(modules, graph.ModulesByModuleDir[modDir])
+		}
+	}
+	for _, module := range graph.ReposByRepoDir[graph.RepoDir].Modules() {
+		if !slices.Contains(modules, module) {
+			modules = append(modules, module)
+		}
+	}
+
+	index = make(map[goutils.ModuleDir]int, len(modules))
+	for i, module := range modules {
+		index[module.Dir()] = i
+	}
+	for _, module := range modules {
+		err = module.SetGraph(graph)
+		if err != nil {
+			goto end
+		}
+		node := &runNode{module: module}
+		for _, dir := range append(module.RequireDirs(), module.ToolDirs()...) {
+			if i, ok := index[dir]; ok && !slices.Contains(node.deps, i) {
+				node.deps = append(node.deps, i)
+			}
+		}
+		nodes = append(nodes, node)
+	}
+
+end:
+	return nodes, err
+}
+
+// runScheduled calls run for each node in its own goroutine, at most jobs at a
+// time, once every node it depends on is done and passed. Nodes depending on
+// one that did not pass are passed to skip instead along with that node, and
+// once ctx is done every node not yet started is passed to skip with a blocker
+// of -1. Nodes in a require cycle are run in order once nothing else can start.
+func runScheduled(ctx context.Context, nodes []*runNode, jobs int, run func(i int), skip func(i, blocker int), passed func(i int) bool) {
+	var running, completed int
+
+	done := make(chan int)
+	for completed < len(nodes) {
+		started := false
+		for i, node := range nodes {
+			if node.started {
+				continue
+			}
+			if ctx.Err() != nil {
+				node.started, node.done = true, true
+				skip(i, -1)
+				completed++
+				continue
+			}
+			ready, blocker := true, -1
+			for _, dep := range node.deps {
+				switch {
+				case !nodes[dep].done:
+					ready = false
+				case !passed(dep):
+					blocker = dep
+				}
+			}
+			if ready && blocker >= 0 {
+				node.started, node.done = true, true
+				skip(i, blocker)
+				completed++
+				started = true
+				continue
+			}
+			if !ready || running >= jobs {
+				continue
+			}
+			node.started = true
+			running++
+			started = true
+			go func() {
+				run(i)
+				done <- i
+			}()
+		}
+		if completed == len(nodes) {
+			break
+		}
+		if !started && running == 0 {
+			// Only a require cycle can leave nothing ready and nothing running
+			for _, node := range nodes {
+				if !node.started {
+					node.deps = nil
+					break
+				}
+			}
+			continue
+		}
+		if running == 0 {
+			continue
+		}
+		i := <-done
+		nodes[i].done = true
+		running--
+		completed++
+	}
+}
+
+// runModuleTask runs cmd in module, streaming its output through the hook
+func runModuleTask(ctx context.Context, module *goutils.Module, cmd runCommand, mr ModuleRun, args RunArgs) ModuleRun {
+	var out runOutput
+	var err error
+
+	out = runOutput{prefix: "  " + path.Base(string(module.Path)) + " | ", hook: args.Hook}
+
+	streamRun(args.Hook, fmt.Sprintf("- %s: %s", module.Path, mr.Command))
+	start := time.Now()
+	switch cmd.name {
+	case "go":
+		err = goutils.StreamGoEnv(ctx, module.Dir(), args.Config.goEnv(), &out, cmd.args...)
+	default:
+		err = streamCommand(ctx, module.Dir(), args.Config.goEnv(), &out, cmd)
+	}
+	out.flush()
+	mr.Seconds = time.Since(start).Round(time.Millisecond).Seconds()
+	mr.Output = out.buf.String()
+
+	mr.Status = RunPassed
+	if err != nil {
+		mr.Status = RunFailed
+		mr.Error = err.Error()
+	}
+	streamRun(args.Hook, fmt.Sprintf("- %s %s in %.1fs", module.Path, mr.Status, mr.Seconds))
+	return mr
+}
+
+// streamCommand runs cmd in dir with env added to the current environment,
+// writing its combined output to w as it is produced
+func streamCommand(ctx context.Context, dir dt.DirPath, env []string, w io.Writer, cmd runCommand) (err error) {
+	c := exec.CommandContext(ctx, cmd.name, cmd.args...)
+	c.Dir = string(dir)
+	if len(env) > 0 {
+		c.Env = append(os.Environ(), env...)
+	}
+	c.Stdout = w
+	c.Stderr = w
+	err = c.Run()
+	if err != nil {
+		err = fmt.Errorf("%s: %w", cmd, err)
+	}
+	return err
+}
+
+// runHookMu serializes the hook calls of modules running at once
+var runHookMu sync.Mutex
+
+// streamRun sends message to hook, if set, without interleaving messages
+func streamRun(hook StreamingHook, message string) {
+	if hook == nil {
+		return
+	}
+	runHookMu.Lock()
+	defer runHookMu.Unlock()
+	hook(message)
+}
+
+// runOutput collects a module's output and passes each complete line to the
+// hook with a prefix naming the module
+type runOutput struct {
+	prefix  string
+	hook    StreamingHook
+	buf     bytes.Buffer
+	partial []byte
+}
+
+// Write implements io.Writer
+func (o *runOutput) Write(p []byte) (int, error) {
+	o.buf.Write(p)
+	o.partial = append(o.partial, p...)
+	for {
+		line, rest, ok := bytes.Cut(o.partial, []byte("\n"))
+		if !ok {
+			break
+		}
+		streamRun(o.hook, o.prefix+string(line))
+		o.partial = rest
+	}
+	return len(p), nil
+}
+
+// flush sends any final line lacking a newline to the hook
+func (o *runOutput) flush() {
+	if len(o.partial) > 0 {
+		streamRun(o.hook, o.prefix+string(o.partial))
+		o.partial = nil
+	}
+}
@@ -0,0 +1,114 @@
+package gompkg
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestRunTaskCommand(t *testing.T) {
+	tests := []struct {
+		name  string
+		task  RunTask
+		gomod string
+		want  string
+	}{
+		{
+			name:  "test",
+			task:  TestRunTask,
+			gomod: "module example.com/a\n\ngo 1.25\n",
+			want:  "go test ./...",
+		},
+		{
+			name:  "lint with golangci-lint tool directive",
+			task:  LintRunTask,
+			gomod: "module example.com/a\n\ngo 1.25\n\ntool github.com/golangci/golangci-lint/v2/cmd/golangci-lint\n\nrequire github.com/golangci/golangci-lint/v2 v2.6.2\n",
+			want:  "go tool golangci-lint run ./...",
+		},
+		{
+			name:  "lint without tool directive",
+			task:  LintRunTask,
+			gomod: "module example.com/a\n\ngo 1.25\n",
+			want:  "/usr/local/bin/golangci-lint run ./...",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mod := loadModule(t, writeGoMod(t, filepath.Join(t.TempDir(), "a"), tt.gomod))
+			got := tt.task.command(mod, "/usr/local/bin/golangci-lint").String()
+			if got != tt.want {
+				t.Errorf("command() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindLinter(t *testing.T) {
+	dir := t.TempDir()
+	linter := filepath.Join(dir, LintExecutable)
+	err := os.WriteFile(linter, []byte("#!/bin/sh\n"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Path", func(t *testing.T) {
+		t.Setenv("PATH", dir)
+		got, err := findLinter(&Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != linter {
+			t.Errorf("got %q, want %q", got, linter)
+		}
+	})
+
+	t.Run("Config", func(t *testing.T) {
+		t.Setenv("PATH", "")
+		got, err := findLinter(&Config{LintCommand: linter})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != linter {
+			t.Errorf("got %q, want %q", got, linter)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		t.Setenv("PATH", t.TempDir())
+		_, err := findLinter(nil)
+		if !errors.Is(err, ErrLinterNotFound) {
+			t.Errorf("got error %v, want ErrLinterNotFound", err)
+		}
+	})
+}
+
+func TestRunScheduledSkipsOnceCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	// The second and third require the first, which cancels the run
+	nodes := []*runNode{{}, {deps: []int{0}}, {deps: []int{0}}}
+
+	var mu sync.Mutex
+	var ran []int
+	blockers := make(map[int]int)
+	runScheduled(ctx, nodes, 1, func(i int) {
+		mu.Lock()
+		ran = append(ran, i)
+		mu.Unlock()
+		cancel()
+	}, func(i, blocker int) {
+		blockers[i] = blocker
+	}, func(i int) bool {
+		return true
+	})
+
+	if !slices.Equal(ran, []int{0}) {
+		t.Errorf("got ran %v, want only the first", ran)
+	}
+	if len(blockers) != 2 || blockers[1] != -1 || blockers[2] != -1 {
+		t.Errorf("got skipped %v, want the rest skipped with no blocker", blockers)
+	}
+}
@@ -0,0 +1,147 @@
+package gompkg
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gitutils"
+	"github.com/mikeschinkel/gomion/gommod/gomion"
+)
+
+// TagRecord is what a module tag resolved to when Gomion first saw it
+type TagRecord struct {
+	gitutils.TagRef
+	FirstSeen time.Time `json:"first_seen"`
+}
+
+// TagLedger records every module tag Gomion has seen in a repo. Once a version
+// has been fetched through the module proxy its content is immutable, so a tag
+// must never resolve to anything else. It is stored in .git/info/gomion/tags.json
+// so it is never committed.
+type TagLedger struct {
+	Tags map[string]TagRecord `json:"tags"`
+}
+
+// LoadTagLedger loads the tag ledger for a repo. A repo Gomion has never
+// recorded tags for has an empty ledger.
+func LoadTagLedger(repoRoot dt.DirPath) (ledger *TagLedger, err error) {
+	ledger = &TagLedger{}
+	err = gitutils.NewInfoStore(repoRoot, gomion.TagLedgerFile).LoadJSON(ledger)
+	if errors.Is(err, dt.ErrFileNotExist) {
+		err = nil
+	}
+	if ledger.Tags == nil {
+		ledger.Tags = make(map[string]TagRecord)
+	}
+	return ledger, err
+}
+
+// Save persists the tag ledger for a repo
+func (l *TagLedger) Save(repoRoot dt.DirPath) (err error) {
+	return gitutils.NewInfoStore(repoRoot, gomion.TagLedgerFile).SaveJSON(l)
+}
+
+// MovedTag is a tag that no longer resolves to what it did when first seen
+type MovedTag struct {
+	Tag      string
+	Recorded TagRecord
+	Current  gitutils.TagRef
+}
+
+// ContentChanged returns true if the tag's tree changed, which breaks the
+// checksum of every go.sum that recorded the version, rather than only the
+// commit it points at
+func (m MovedTag) ContentChanged() bool {
+	return m.Recorded.Tree != m.Current.Tree
+}
+
+// CheckTagIntegrity records the commit and tree of each tag of the module at
+// the repo-relative prefix that the remote has and the repo's ledger does not,
+// and returns the recorded tags that now resolve to something else. Tags cmp
+// lists as not pushed or pointing elsewhere on the remote are not recorded as
+// they can still be moved or deleted without harm, and nothing is recorded
+// when cmp is nil as the remote's tags are unknown. Moved tags keep their
+// original record so the warning persists until the tag is restored.
+func CheckTagIntegrity(ctx context.Context, repo *gitutils.Repo, prefix string, cmp *gitutils.TagComparison) (moved []MovedTag, err error) {
+	var refs map[string]gitutils.TagRef
+	var ledger *TagLedger
+	var unpublished map[string]bool
+	var changed bool
+
+	refs, err = repo.TagRefs(ctx, prefix)
+	if err != nil {
+		goto end
+	}
+	ledger, err = LoadTagLedger(repo.Root)
+	if err != nil {
+		goto end
+	}
+
+	if cmp != nil {
+		unpublished = make(map[string]bool, len(cmp.LocalOnly)+len(cmp.Mismatched))
+		for _, tag := range cmp.LocalOnly {
+			unpublished[tag] = true
+		}
+		for _, mismatch := range cmp.Mismatched {
+			unpublished[mismatch.Tag] = true
+		}
+	}
+
+	for tag, ref := range refs {
+		record, ok := ledger.Tags[tag]
+		switch {
+		case ok && record.TagRef != ref:
+			moved = append(moved, MovedTag{
+				Tag:      tag,
+				Recorded: record,
+				Current:  ref,
+			})
+		case ok, cmp == nil, unpublished[tag]:
+			continue
+		default:
+			ledger.Tags[tag] = TagRecord{
+				TagRef:    ref,
+				FirstSeen: time.Now(),
+			}
+			changed = true
+		}
+	}
+	sort.Slice(moved, func(i, j int) bool {
+		return moved[i].Tag < moved[j].Tag
+	})
+
+	if changed {
+		err = ledger.Save(repo.Root)
+	}
+
+end:
+	if err != nil {
+		err = WithErr(err, "repo_dir", repo.Root)
+	}
+	return moved, err
+}
+
+// ForgetTag removes tag from the repo's ledger, for a tag deleted before it
+// was pushed
+func ForgetTag(repoRoot dt.DirPath, tag string) (err error) {
+	var ledger *TagLedger
+
+	ledger, err = LoadTagLedger(repoRoot)
+	if err != nil {
+		goto end
+	}
+	if _, ok := ledger.Tags[tag]; !ok {
+		goto end
+	}
+	delete(ledger.Tags, tag)
+	err = ledger.Save(repoRoot)
+
+end:
+	if err != nil {
+		err = WithErr(err, "repo_dir", repoRoot, "tag", tag)
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+package gompkg
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gitutils"
+)
+
+func TestCheckTagIntegrity(t *testing.T) {
+	root := t.TempDir()
+	remoteDir := filepath.Join(root, "remote.git")
+	repoDir := filepath.Join(root, "repo")
+
+	git(t, root, "init", "--quiet", "--bare", remoteDir)
+	git(t, root, "init", "--quiet", "--initial-branch=main", repoDir)
+	git(t, repoDir, "remote", "add", "origin", remoteDir)
+	writeGoMod(t, repoDir, "module github.com/example/app\n\ngo 1.25\n")
+	git(t, repoDir, "add", "go.mod")
+	git(t, repoDir, "commit", "--quiet", "--message", "Initial")
+	git(t, repoDir, "tag", "v1.0.0")
+	git(t, repoDir, "push", "--quiet", "--tags", "--set-upstream", "origin", "main")
+	git(t, repoDir, "tag", "v1.1.0")
+
+	repo, err := gitutils.Open(dt.DirPath(repoDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(t *testing.T) (moved []MovedTag, ledger *TagLedger) {
+		t.Helper()
+		cmp, err := repo.CompareTags(t.Context(), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		moved, err = CheckTagIntegrity(t.Context(), repo, "", &cmp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ledger, err = LoadTagLedger(repo.Root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return moved, ledger
+	}
+
+	t.Run("RecordsPushedTags", func(t *testing.T) {
+		moved, ledger := check(t)
+		if len(moved) != 0 {
+			t.Errorf("got moved %+v, want none", moved)
+		}
+		if _, ok := ledger.Tags["v1.0.0"]; !ok {
+			t.Error("got v1.0.0 not recorded, want the pushed tag recorded")
+		}
+		if _, ok := ledger.Tags["v1.1.0"]; ok {
+			t.Error("got v1.1.0 recorded, want the unpushed tag left out")
+		}
+	})
+
+	t.Run("NoRemote", func(t *testing.T) {
+		git(t, repoDir, "tag", "v1.2.0")
+		git(t, repoDir, "push", "--quiet", "origin", "v1.2.0")
+		_, err := CheckTagIntegrity(t.Context(), repo, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ledger, err := LoadTagLedger(repo.Root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := ledger.Tags["v1.2.0"]; ok {
+			t.Error("got v1.2.0 recorded without comparing tags, want it left out")
+		}
+	})
+
+	t.Run("DetectsMovedTags", func(t *testing.T) {
+		writeGoMod(t, repoDir, "module github.com/example/app\n\ngo 1.25.0\n")
+		git(t, repoDir, "commit", "--quiet", "--all", "--message", "Second")
+		git(t, repoDir, "tag", "--force", "v1.0.0")
+
+		moved, ledger := check(t)
+		if len(moved) != 1 || moved[0].Tag != "v1.0.0" {
+			t.Fatalf("got moved %+v, want v1.0.0", moved)
+		}
+		if !moved[0].ContentChanged() {
+			t.Error("got content unchanged, want the tree change reported")
+		}
+		if ledger.Tags["v1.0.0"] != moved[0].Recorded {
+			t.Error("got the ledger updated, want the original record kept")
+		}
+	})
+}
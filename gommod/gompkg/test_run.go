@@ -0,0 +1,112 @@
+package gompkg
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/mikeschinkel/go-cliutil"
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+)
+
+// TestArgs contains the input parameters for RunTests
+type TestArgs struct {
+	// StartDir is the directory to start scanning from (defaults to ".")
+	StartDir string
+
+	// Module selects the changed module by module path, last path element or
+	// directory (defaults to the module in StartDir)
+	Module string
+
+	// Affected limits `go test` to the packages affected by the changes rather
+	// than every package of the module and its dependents
+	Affected bool
+
+	Config *Config
+	Logger *slog.Logger
+	Writer cliutil.Writer
+}
+
+// TestRun is the outcome of running `go test` in one module
+type TestRun struct {
+	ModulePath goutils.ModulePath
+	ModuleDir  dt.DirPath
+
+	// Packages are the packages tested, nil for all of them
+	Packages []goutils.PackagePath
+
+	Output string
+	Err    error
+}
+
+// TestResult contains the outcome of RunTests
+type TestResult struct {
+	Impact *ImpactResult
+
+	// Runs lists one run per module tested, in the order of Impact.Modules
+	Runs []TestRun
+
+	// Skipped lists the modules with no affected packages
+	Skipped []goutils.ModulePath
+}
+
+// Failed returns the runs whose tests failed
+func (r *TestResult) Failed() (failed []TestRun) {
+	for _, run := range r.Runs {
+		if run.Err != nil {
+			failed = append(failed, run)
+		}
+	}
+	return failed
+}
+
+// RunTests runs `go test` in a module and each local module that depends on
+// it, through a temporary go.work so dependents build against the module's
+// uncommitted changes. With Affected set only the packages AnalyzeImpact finds
+// affected are tested and modules with none are skipped. Test failures are
+// reported per run rather than as an error.
+func RunTests(ctx context.Context, args TestArgs) (result *TestResult, err error) {
+	var run TestRun
+
+	result = &TestResult{}
+
+	result.Impact, err = AnalyzeImpact(ctx, ImpactArgs{
+		StartDir: args.StartDir,
+		Module:   args.Module,
+		Config:   args.Config,
+		Logger:   args.Logger,
+		Writer:   args.Writer,
+	})
+	if err != nil {
+		goto end
+	}
+	defer result.Impact.Cleanup()
+
+	for _, am := range result.Impact.Modules {
+		if args.Affected && len(am.Packages) == 0 {
+			result.Skipped = append(result.Skipped, am.ModulePath)
+			continue
+		}
+		run = TestRun{
+			ModulePath: am.ModulePath,
+			ModuleDir:  am.ModuleDir,
+		}
+		testArgs := []string{"test"}
+		if args.Affected {
+			run.Packages = am.Packages
+			for _, pkgPath := range am.Packages {
+				testArgs = append(testArgs, string(pkgPath))
+			}
+		} else {
+			testArgs = append(testArgs, "./...")
+		}
+		run.Output, run.Err = goutils.RunGoEnv(ctx, am.ModuleDir, result.Impact.Env(), testArgs...)
+		result.Runs = append(result.Runs, run)
+	}
+
+end:
+	if err != nil {
+		err = WithErr(err, "module", args.Module)
+	}
+	return result, err
+}
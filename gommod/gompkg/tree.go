@@ -13,6 +13,9 @@ import (
 type TreeNode struct {
 	Module   *Module
 	Children []*TreeNode
+
+	// Tool is true if the parent requires Module only to provide tools
+	Tool bool
 }
 
 // TreeOptions controls how the tree is rendered
@@ -21,6 +24,9 @@ type TreeOptions struct {
 	ShowPaths bool
 	ShowAll   bool
 	ShowExt   bool
+
+	// InFlux holds the in-flux reason of each in-flux module to highlight
+	InFlux map[ModulePath]string
 }
 
 // RenderTree renders a dependency tree starting from root modules
@@ -93,7 +99,7 @@ func (ms *ModuleSet) buildTreeNode(module *Module, visited map[ModulePath]struct
 	}
 
 	// Don't expand if we've already visited this module
-	if _, ok := visited[module.ModulePath]; !ok {
+	if _, ok := visited[module.ModulePath]; ok {
 		goto end
 	}
 
@@ -110,6 +116,18 @@ func (ms *ModuleSet) buildTreeNode(module *Module, visited map[ModulePath]struct
 		node.Children = append(node.Children, childNode)
 	}
 
+	// Tool dependencies follow the regular ones
+	for _, depPath = range module.Tools {
+		depModule, ok = ms.Get(depPath)
+		if !ok {
+			continue
+		}
+
+		childNode = ms.buildTreeNode(depModule, visited)
+		childNode.Tool = true
+		node.Children = append(node.Children, childNode)
+	}
+
 end:
 	return node
 }
@@ -125,6 +143,12 @@ func (ms *ModuleSet) renderNode(node *TreeNode, prefix string, isLast bool, line
 
 	// Build the label for this node
 	label = ms.buildNodeLabel(node.Module, opts)
+	if _, ok := opts.InFlux[node.Module.ModulePath]; ok {
+		label += " *in-flux*"
+	}
+	if node.Tool {
+		label += " [tool]"
+	}
 
 	// Build the tree branch characters
 	if prefix == "" {
@@ -228,6 +252,17 @@ end:
 
 // EmbedTree embeds a rendered tree into a markdown file
 func EmbedTree(markdownPath dt.Filepath, treeContent string, before bool) (err error) {
+	return EmbedGraph(markdownPath, treeContent, "text", before)
+}
+
+// generatedBlockMarker precedes every code block EmbedGraph writes so later
+// embeds replace only blocks it generated, never a hand-written one
+const generatedBlockMarker = "<!-- gomion:generated -->"
+
+// EmbedGraph embeds rendered content into a markdown file as a code block of
+// the given language before or after the embed marker, replacing the marked
+// block a previous embed left there so the embedded graph stays current
+func EmbedGraph(markdownPath dt.Filepath, rendered, lang string, before bool) (err error) {
 	var content []byte
 	var lines []string
 	var i int
@@ -236,6 +271,7 @@ func EmbedTree(markdownPath dt.Filepath, treeContent string, before bool) (err e
 	var newLines []string
 	var codeBlock string
 	var newContent string
+	var start, stop int
 
 	// Read the markdown file
 	content, err = markdownPath.ReadFile()
@@ -261,22 +297,44 @@ func EmbedTree(markdownPath dt.Filepath, treeContent string, before bool) (err e
 	}
 
 	// Build the code block
-	codeBlock = "```text\n" + treeContent + "```"
+	codeBlock = generatedBlockMarker + "\n```" + lang + "\n" + rendered + "```"
 
 	// Build new content
-	newLines = make([]string, 0, len(lines)+4)
+	newLines = make([]string, 0, len(lines)+5)
 
 	if before {
-		// Insert before marker
-		newLines = append(newLines, lines[:markerIndex]...)
+		// Insert before marker, replacing a generated code block ending just before it
+		start = markerIndex
+		if start > 0 && strings.TrimSpace(lines[start-1]) == "```" {
+			for i = start - 2; i >= 0; i-- {
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), "```") {
+					break
+				}
+			}
+			if i > 0 && strings.TrimSpace(lines[i-1]) == generatedBlockMarker {
+				start = i - 1
+			}
+		}
+		newLines = append(newLines, lines[:start]...)
 		newLines = append(newLines, codeBlock)
 		newLines = append(newLines, lines[markerIndex:]...)
 	} else {
-		// Insert after marker
+		// Insert after marker, replacing a generated code block starting just after it
+		stop = markerIndex + 1
+		if stop+1 < len(lines) &&
+			strings.TrimSpace(lines[stop]) == generatedBlockMarker &&
+			strings.HasPrefix(strings.TrimSpace(lines[stop+1]), "```") {
+			for i = stop + 2; i < len(lines); i++ {
+				if strings.TrimSpace(lines[i]) == "```" {
+					stop = i + 1
+					break
+				}
+			}
+		}
 		newLines = append(newLines, lines[:markerIndex+1]...)
 		newLines = append(newLines, codeBlock)
-		if markerIndex+1 < len(lines) {
-			newLines = append(newLines, lines[markerIndex+1:]...)
+		if stop < len(lines) {
+			newLines = append(newLines, lines[stop:]...)
 		}
 	}
 
@@ -0,0 +1,61 @@
+package gompkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+func TestEmbedGraph(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		before  bool
+		want    string
+	}{
+		{
+			name:    "after marker keeps hand-written block",
+			content: "# Deps\n<!-- gomion:embed-requires-tree -->\n```go\nfunc main() {}\n```\n",
+			want:    "# Deps\n<!-- gomion:embed-requires-tree -->\n<!-- gomion:generated -->\n```text\nnew\n```\n```go\nfunc main() {}\n```\n",
+		},
+		{
+			name:    "after marker replaces generated block",
+			content: "# Deps\n<!-- gomion:embed-requires-tree -->\n<!-- gomion:generated -->\n```text\nold\n```\nmore\n",
+			want:    "# Deps\n<!-- gomion:embed-requires-tree -->\n<!-- gomion:generated -->\n```text\nnew\n```\nmore\n",
+		},
+		{
+			name:    "before marker keeps hand-written block",
+			content: "# Deps\n```go\nfunc main() {}\n```\n<!-- gomion:embed-requires-tree -->\n",
+			before:  true,
+			want:    "# Deps\n```go\nfunc main() {}\n```\n<!-- gomion:generated -->\n```text\nnew\n```\n<!-- gomion:embed-requires-tree -->\n",
+		},
+		{
+			name:    "before marker replaces generated block",
+			content: "# Deps\n<!-- gomion:generated -->\n```text\nold\n```\n<!-- gomion:embed-requires-tree -->\n",
+			before:  true,
+			want:    "# Deps\n<!-- gomion:generated -->\n```text\nnew\n```\n<!-- gomion:embed-requires-tree -->\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "README.md")
+			err := os.WriteFile(file, []byte(tt.content), 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = EmbedGraph(dt.Filepath(file), "new\n", "text", tt.before)
+			if err != nil {
+				t.Fatalf("EmbedGraph() error = %v", err)
+			}
+			got, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("EmbedGraph() wrote\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
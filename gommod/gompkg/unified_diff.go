@@ -0,0 +1,160 @@
+package gompkg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContextLines is the number of unchanged lines shown around each change
+const diffContextLines = 3
+
+// diffOp is one line of a line-based diff
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	text string
+}
+
+// unifiedDiff returns a unified diff of oldText and newText labelled with name,
+// or an empty string if they are equal. Common leading and trailing lines are
+// trimmed before the LCS so the typical handful of edited lines stays cheap.
+func unifiedDiff(name string, oldText, newText string) string {
+	var sb strings.Builder
+	var ops []diffOp
+
+	if oldText == newText {
+		return ""
+	}
+
+	oldLines := splitDiffLines(oldText)
+	newLines := splitDiffLines(newText)
+
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	for _, line := range oldLines[:prefix] {
+		ops = append(ops, diffOp{kind: ' ', text: line})
+	}
+	ops = append(ops, lcsDiff(oldLines[prefix:len(oldLines)-suffix], newLines[prefix:len(newLines)-suffix])...)
+	for _, line := range oldLines[len(oldLines)-suffix:] {
+		ops = append(ops, diffOp{kind: ' ', text: line})
+	}
+
+	sb.WriteString("--- a/" + name + "\n")
+	sb.WriteString("+++ b/" + name + "\n")
+	writeDiffHunks(&sb, ops)
+
+	return sb.String()
+}
+
+// splitDiffLines splits text into lines without their trailing newlines
+func splitDiffLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// lcsDiff diffs two line slices using a longest-common-subsequence table
+func lcsDiff(oldLines, newLines []string) (ops []diffOp) {
+	n, m := len(oldLines), len(newLines)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+				continue
+			}
+			lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && oldLines[i] == newLines[j]:
+			ops = append(ops, diffOp{kind: ' ', text: oldLines[i]})
+			i++
+			j++
+		case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{kind: '-', text: oldLines[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', text: newLines[j]})
+			j++
+		}
+	}
+	return ops
+}
+
+// writeDiffHunks writes ops as "@@" hunks with diffContextLines of context
+func writeDiffHunks(sb *strings.Builder, ops []diffOp) {
+	var oldLine, newLine int
+
+	start := 0
+	for start < len(ops) {
+		// Find the next change
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		// Extend the hunk until more than two contexts' worth of unchanged lines
+		from := max(0, start-diffContextLines)
+		to := start
+		for to < len(ops) {
+			if ops[to].kind != ' ' {
+				to++
+				continue
+			}
+			run := to
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-to > 2*diffContextLines {
+				to = min(run, to+diffContextLines)
+				break
+			}
+			to = run
+		}
+
+		// Count the lines before the hunk to number it
+		oldLine, newLine = 1, 1
+		for _, op := range ops[:from] {
+			if op.kind != '+' {
+				oldLine++
+			}
+			if op.kind != '-' {
+				newLine++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, op := range ops[from:to] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+
+		fmt.Fprintf(sb, "@@ -%d,%d +%d,%d @@\n", oldLine, oldCount, newLine, newCount)
+		for _, op := range ops[from:to] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.text)
+			sb.WriteByte('\n')
+		}
+		start = to
+	}
+}
@@ -29,6 +29,9 @@ type UpdateRepoRequiresResult struct {
 type ModuleConfig struct {
 	Name  string       `json:"name"`
 	Kinds []ModuleKind `json:"kinds"`
+
+	// Bench holds the module's `gomion bench` settings, if any
+	Bench *BenchConfig `json:"bench,omitempty"`
 }
 
 // RepoConfig contains the modules and their required modules
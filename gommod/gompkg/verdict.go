@@ -0,0 +1,154 @@
+package gompkg
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+	"github.com/mikeschinkel/gomion/gommod/precommit"
+)
+
+// maxEvidenceNames bounds how many names are listed in one evidence item
+const maxEvidenceNames = 5
+
+// analyzeRelease runs every analyzer over the module as of the baseline tag in
+// baselineDir and as of HEAD in currentDir. Only the API analysis is required;
+// AST and test signal failures are returned as warnings to list as evidence.
+func analyzeRelease(ctx context.Context, baselineTag string, baselineDir, currentDir dt.DirPath) (analysis precommit.Results, warnings []string, err error) {
+	var astErr, testsErr error
+
+	analysis.Timestamp = time.Now()
+	analysis.BaselineTag = baselineTag
+
+	analysis.API, err = goutils.AnalyzeAPICompatibility(ctx, baselineDir, currentDir)
+	if err != nil {
+		goto end
+	}
+	analysis.API.BaselineTag = baselineTag
+
+	analysis.AST, astErr = goutils.AnalyzeASTDiff(ctx, baselineDir, currentDir)
+	if astErr != nil {
+		warnings = append(warnings, "AST analysis failed: "+astErr.Error())
+	}
+	analysis.Tests, testsErr = goutils.AnalyzeTestSignals(ctx, baselineDir, currentDir)
+	if testsErr != nil {
+		warnings = append(warnings, "test analysis failed: "+testsErr.Error())
+	}
+
+end:
+	return analysis, warnings, err
+}
+
+// gradeVerdict grades a release verdict from the analyses and itemizes the
+// evidence for it. Breaking API changes are breaking; functions whose behavior
+// changed behind an unchanged exported API, i.e. changed exported bodies or
+// changed or removed unexported functions, and changed struct tags are likely
+// breaking; anything else may not be breaking, as no analysis can prove a
+// change compatible.
+func gradeVerdict(analysis precommit.Results) (verdict VerdictType, reason string, evidence []string) {
+	var signals []string
+
+	add := func(signal string, names []string) {
+		signals = append(signals, signal)
+		evidence = append(evidence, evidenceItem(signal, names))
+	}
+
+	api := analysis.API
+	if len(api.BreakingChanges) > 0 {
+		names := make([]string, len(api.BreakingChanges))
+		for i, change := range api.BreakingChanges {
+			names[i] = change.Signature + " " + change.Description
+		}
+		add("exported API broken", names)
+	}
+	if len(api.Additions) > 0 {
+		add("exported API added", apiChangeNames(api.Additions))
+	}
+	if len(api.Modifications) > 0 {
+		add("exported API changed compatibly", apiChangeNames(api.Modifications))
+	}
+
+	bodies := analysis.AST.ExportedBodyChanges()
+	if len(bodies) > 0 {
+		add("exported func body changed", funcChangeNames(bodies))
+	}
+	if len(analysis.AST.StructTagChanges) > 0 {
+		add("struct tags changed", analysis.AST.StructTagChanges)
+	}
+	unexported := unexportedFuncChanges(analysis.AST)
+	if len(unexported) > 0 {
+		add("unexported code changed", funcChangeNames(unexported))
+	}
+	if len(analysis.AST.DocChanges) > 0 {
+		add("docs changed", analysis.AST.DocChanges)
+	}
+
+	tests := analysis.Tests
+	if len(tests.NewTests) > 0 {
+		add("tests added", tests.NewTests)
+	}
+	if len(tests.ModifiedTests) > 0 {
+		add("tests modified", tests.ModifiedTests)
+	}
+	if len(tests.RemovedTests) > 0 {
+		add("tests removed", tests.RemovedTests)
+	}
+	if len(tests.UntestedChanges) > 0 {
+		add("exported changes without test changes", tests.UntestedChanges)
+	}
+
+	switch {
+	case len(api.BreakingChanges) > 0:
+		verdict = VerdictBreaking
+	case len(bodies) > 0, len(unexported) > 0, len(analysis.AST.StructTagChanges) > 0:
+		verdict = VerdictLikelyBreaking
+	default:
+		verdict = VerdictMaybeNotBreaking
+	}
+
+	reason = strings.Join(signals, ", ")
+	if reason == "" {
+		reason = "no Go source changes detected since " + analysis.BaselineTag
+	}
+	return verdict, reason, evidence
+}
+
+// evidenceItem formats a signal and the names supporting it, e.g.
+// "exported func body changed: Foo, Bar"
+func evidenceItem(signal string, names []string) string {
+	if len(names) > maxEvidenceNames {
+		names = append(names[:maxEvidenceNames:maxEvidenceNames], fmt.Sprintf("and %d more", len(names)-maxEvidenceNames))
+	}
+	return signal + ": " + strings.Join(names, ", ")
+}
+
+func apiChangeNames(changes []goutils.APIChange) (names []string) {
+	names = make([]string, len(changes))
+	for i, change := range changes {
+		names[i] = change.Signature
+	}
+	return names
+}
+
+func funcChangeNames(changes []goutils.FuncChange) (names []string) {
+	names = make([]string, len(changes))
+	for i, change := range changes {
+		names[i] = change.FuncName
+	}
+	return names
+}
+
+// unexportedFuncChanges returns the changes to functions outside the exported
+// API, which can change exported behavior indirectly. Added functions are
+// skipped as they only change behavior through a caller that also changed.
+func unexportedFuncChanges(r goutils.ASTDiffResult) (changes []goutils.FuncChange) {
+	for _, fc := range r.FuncChanges {
+		if !fc.Exported && fc.ChangeType != "added" {
+			changes = append(changes, fc)
+		}
+	}
+	return changes
+}
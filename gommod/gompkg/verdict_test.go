@@ -0,0 +1,78 @@
+package gompkg
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/gomion/gommod/goutils"
+	"github.com/mikeschinkel/gomion/gommod/precommit"
+)
+
+func TestGradeVerdict(t *testing.T) {
+	tests := []struct {
+		name     string
+		analysis precommit.Results
+		want     VerdictType
+	}{
+		{
+			name: "BreakingAPIChange",
+			analysis: precommit.Results{API: goutils.APICompatResult{
+				BreakingChanges: []goutils.APIChange{{Signature: "func Foo()", Description: "removed"}},
+			}},
+			want: VerdictBreaking,
+		},
+		{
+			name: "ExportedBodyChanged",
+			analysis: precommit.Results{AST: goutils.ASTDiffResult{FuncChanges: []goutils.FuncChange{
+				{FuncName: "Foo", ChangeType: "modified", Description: goutils.FuncBodyChanged, Exported: true},
+			}}},
+			want: VerdictLikelyBreaking,
+		},
+		{
+			name: "UnexportedBodyChanged",
+			analysis: precommit.Results{AST: goutils.ASTDiffResult{FuncChanges: []goutils.FuncChange{
+				{FuncName: "foo", ChangeType: "modified", Description: goutils.FuncBodyChanged},
+			}}},
+			want: VerdictLikelyBreaking,
+		},
+		{
+			name: "UnexportedRemoved",
+			analysis: precommit.Results{AST: goutils.ASTDiffResult{FuncChanges: []goutils.FuncChange{
+				{FuncName: "foo", ChangeType: "removed", Description: "removed"},
+			}}},
+			want: VerdictLikelyBreaking,
+		},
+		{
+			name: "UnexportedAdded",
+			analysis: precommit.Results{AST: goutils.ASTDiffResult{FuncChanges: []goutils.FuncChange{
+				{FuncName: "foo", ChangeType: "added", Description: "added"},
+			}}},
+			want: VerdictMaybeNotBreaking,
+		},
+		{
+			name: "ExportedAPIAdded",
+			analysis: precommit.Results{API: goutils.APICompatResult{
+				Additions: []goutils.APIChange{{Signature: "func Bar()"}},
+			}},
+			want: VerdictMaybeNotBreaking,
+		},
+		{
+			name: "StructTagsChanged",
+			analysis: precommit.Results{AST: goutils.ASTDiffResult{
+				StructTagChanges: []string{"Foo.Name: `json:\"name\"` → `json:\"n\"`"},
+			}},
+			want: VerdictLikelyBreaking,
+		},
+		{
+			name: "NoChanges",
+			want: VerdictMaybeNotBreaking,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reason, _ := gradeVerdict(tt.analysis)
+			if got != tt.want {
+				t.Errorf("got %s (%s), want %s", got, reason, tt.want)
+			}
+		})
+	}
+}
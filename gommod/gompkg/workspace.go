@@ -0,0 +1,368 @@
+package gompkg
+
+import (
+	"slices"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gitutils"
+	"github.com/mikeschinkel/gomion/gommod/gomcfg"
+)
+
+// Workspace is a named grouping of related repos from the root config
+type Workspace struct {
+	Name string
+
+	// RepoDirs are the member repos' root directories, expanded and absolute
+	RepoDirs []dt.DirPath
+
+	// ModuleSpecs replace the root config's module specs when not empty
+	ModuleSpecs []ModuleSpec
+
+	// DefaultBranch is the branch member repos are expected to be on, if set
+	DefaultBranch string
+
+	// GoWork is the workspace's go.work file, if set
+	GoWork dt.Filepath
+}
+
+// ParseWorkspaces converts the root config's workspaces to their typed form
+func ParseWorkspaces(wss []gomcfg.WorkspaceV1) (workspaces []Workspace, err error) {
+	var errs []error
+
+	workspaces = make([]Workspace, 0, len(wss))
+	for _, w := range wss {
+		var ws Workspace
+		ws, err = parseWorkspace(w)
+		if err != nil {
+			errs = AppendErr(errs, WithErr(err, "workspace", w.Name))
+			continue
+		}
+		workspaces = append(workspaces, ws)
+	}
+	err = CombineErrs(errs)
+	return workspaces, err
+}
+
+// parseWorkspace converts one root config workspace to its typed form
+func parseWorkspace(w gomcfg.WorkspaceV1) (ws Workspace, err error) {
+	var dir dt.DirPath
+
+	ws = Workspace{
+		Name:          w.Name,
+		RepoDirs:      make([]dt.DirPath, 0, len(w.Repos)),
+		DefaultBranch: w.DefaultBranch,
+	}
+	for _, repo := range w.Repos {
+		dir, err = expandDir(repo)
+		if err != nil {
+			goto end
+		}
+		ws.RepoDirs = append(ws.RepoDirs, dir)
+	}
+	ws.ModuleSpecs, err = ParseModuleSpecs(w.ModuleSpecs)
+	if err != nil {
+		goto end
+	}
+	if w.GoWork != "" {
+		ws.GoWork, err = dt.ParseFilepath(w.GoWork)
+		if err != nil {
+			goto end
+		}
+		ws.GoWork, err = expandFile(ws.GoWork)
+	}
+
+end:
+	return ws, err
+}
+
+// expandDir parses dir, expanding a leading "~", and makes it absolute
+func expandDir(dir string) (dp dt.DirPath, err error) {
+	dp, err = dt.ParseDirPath(dir)
+	if err != nil {
+		goto end
+	}
+	dp, err = dp.Expand()
+	if err != nil {
+		goto end
+	}
+	dp, err = dp.Clean().Abs()
+
+end:
+	return dp, err
+}
+
+// expandFile expands a leading "~" in file and makes it absolute
+func expandFile(file dt.Filepath) (fp dt.Filepath, err error) {
+	fp, err = file.Expand()
+	if err != nil {
+		goto end
+	}
+	fp, err = fp.Abs()
+
+end:
+	return fp, err
+}
+
+// Workspace returns the named workspace
+func (c *Config) Workspace(name string) (ws *Workspace, err error) {
+	for i := range c.Workspaces {
+		if c.Workspaces[i].Name == name {
+			ws = &c.Workspaces[i]
+			goto end
+		}
+	}
+	err = NewErr(ErrWorkspaceNotFound, "workspace", name)
+
+end:
+	return ws, err
+}
+
+// ForWorkspace returns a copy of the config that scans only the named
+// workspace's repos, using its module specs if it has any and its go.work, if
+// it exists, for the go commands run with it. An empty name returns the config
+// unchanged.
+func (c *Config) ForWorkspace(name string) (config *Config, ws *Workspace, err error) {
+	var exists bool
+
+	config = c
+	if name == "" {
+		goto end
+	}
+	ws, err = c.Workspace(name)
+	if err != nil {
+		goto end
+	}
+	config = new(Config)
+	*config = *c
+	config.ScanDirs = ws.RepoDirs
+	if len(ws.ModuleSpecs) > 0 {
+		config.ModuleSpecs = ws.ModuleSpecs
+	}
+	if ws.GoWork == "" {
+		goto end
+	}
+	// InspectWorkspace reports a missing go.work for commands to warn about
+	exists, err = ws.GoWork.Exists()
+	if err != nil || !exists {
+		goto end
+	}
+	config.GoEnv = append(slices.Clone(c.GoEnv), "GOWORK="+string(ws.GoWork))
+
+end:
+	if err != nil {
+		err = WithErr(err, "workspace", name)
+	}
+	return config, ws, err
+}
+
+// StartDir returns the directory a command should start from: dir if it is in
+// one of the workspace's repos, or the first repo when dir is "" or "." and the
+// current directory is outside the workspace
+func (ws *Workspace) StartDir(dir string) (startDir string, err error) {
+	var dp, repoRoot dt.DirPath
+
+	startDir = dir
+	if startDir == "" {
+		startDir = "."
+	}
+	dp, err = expandDir(startDir)
+	if err != nil {
+		goto end
+	}
+	repoRoot, err = FindRepoRoot(dp)
+	if err == nil && slices.Contains(ws.RepoDirs, repoRoot) {
+		goto end
+	}
+	err = nil
+	if (startDir == "." || startDir == "./") && len(ws.RepoDirs) > 0 {
+		startDir = string(ws.RepoDirs[0])
+		goto end
+	}
+	err = NewErr(ErrNotInWorkspace, "dir", dir, "workspace", ws.Name)
+
+end:
+	return startDir, err
+}
+
+// WorkspaceRepo is the state of one of a workspace's repos
+type WorkspaceRepo struct {
+	Dir dt.DirPath
+
+	// Missing is true if Dir is not a git repo
+	Missing bool
+
+	// Branch is the checked out branch, "HEAD" if detached
+	Branch string
+
+	// OffBranch is true if the workspace has a default branch and the repo has
+	// another checked out
+	OffBranch bool
+
+	// Unmanaged is true if the repo has no loadable .gomion/config.json
+	Unmanaged bool
+
+	// Modules are the managed modules listed in the repo's .gomion/config.json
+	Modules []ModulePath
+}
+
+// WorkspaceStatus contains the outcome of InspectWorkspace
+type WorkspaceStatus struct {
+	Workspace *Workspace
+	Repos     []WorkspaceRepo
+
+	// GoWorkExists is true if the workspace's go.work file exists
+	GoWorkExists bool
+}
+
+// OffBranch returns the repos that are not on the workspace's default branch
+func (s *WorkspaceStatus) OffBranch() (repos []WorkspaceRepo) {
+	for _, r := range s.Repos {
+		if r.OffBranch {
+			repos = append(repos, r)
+		}
+	}
+	return repos
+}
+
+// InspectWorkspace reports each member repo's branch and managed modules and
+// whether the workspace's go.work exists. Missing repos are reported rather
+// than returned as errors.
+func InspectWorkspace(ws *Workspace) (status *WorkspaceStatus, err error) {
+	var repo *gitutils.Repo
+	var branch gitutils.GitRef
+	var ms *ModuleSet
+
+	status = &WorkspaceStatus{Workspace: ws}
+
+	for _, dir := range ws.RepoDirs {
+		wr := WorkspaceRepo{Dir: dir}
+		repo, err = gitutils.Open(dir)
+		if err != nil {
+			wr.Missing = true
+			err = nil
+			status.Repos = append(status.Repos, wr)
+			continue
+		}
+		branch, err = repo.CurrentBranch()
+		if err != nil {
+			goto end
+		}
+		wr.Branch = string(branch)
+		wr.OffBranch = ws.DefaultBranch != "" && wr.Branch != ws.DefaultBranch
+
+		ms = NewModuleSet()
+		err = discoverSingleRepoModules(dir, ms)
+		if err != nil {
+			// Commands skip repos Gomion does not manage
+			wr.Unmanaged = true
+			err = nil
+		}
+		for _, m := range ms.Modules {
+			wr.Modules = append(wr.Modules, m.ModulePath)
+		}
+		slices.Sort(wr.Modules)
+		status.Repos = append(status.Repos, wr)
+	}
+
+	if ws.GoWork != "" {
+		status.GoWorkExists, err = ws.GoWork.Exists()
+	}
+
+end:
+	if err != nil {
+		err = WithErr(err, "workspace", ws.Name)
+	}
+	return status, err
+}
+
+// AddWorkspaceArgs contains the input parameters for AddWorkspace
+type AddWorkspaceArgs struct {
+	Name          string
+	Repos         []string
+	ModuleSpecs   []string
+	DefaultBranch string
+	GoWork        string
+}
+
+// AddWorkspace adds a named workspace to the root config. Each repo may be
+// any directory inside the repo and is stored as its repo root, shortened with
+// "~" where possible. The go.work file is stored as an absolute path.
+func AddWorkspace(rootConfig *gomcfg.RootConfigV1, args AddWorkspaceArgs) (err error) {
+	var w gomcfg.WorkspaceV1
+	var dir dt.DirPath
+	var goWork dt.Filepath
+
+	if args.Name == "" {
+		err = NewErr(dt.ErrEmpty, "field", "name")
+		goto end
+	}
+	if rootConfig.WorkspaceIndex(args.Name) >= 0 {
+		err = NewErr(ErrWorkspaceExists)
+		goto end
+	}
+	if len(args.Repos) == 0 {
+		err = NewErr(dt.ErrEmpty, "field", "repos")
+		goto end
+	}
+
+	w = gomcfg.WorkspaceV1{
+		Name:          args.Name,
+		Repos:         make([]string, 0, len(args.Repos)),
+		ModuleSpecs:   args.ModuleSpecs,
+		DefaultBranch: args.DefaultBranch,
+	}
+	if args.GoWork != "" {
+		// Relative to where the workspace was added, not where it is used
+		goWork, err = dt.ParseFilepath(args.GoWork)
+		if err != nil {
+			goto end
+		}
+		goWork, err = expandFile(goWork)
+		if err != nil {
+			goto end
+		}
+		w.GoWork = string(goWork)
+	}
+	for _, repo := range args.Repos {
+		dir, err = expandDir(repo)
+		if err != nil {
+			goto end
+		}
+		dir, err = FindRepoRoot(dir)
+		if err != nil {
+			err = WithErr(err, "repo", repo)
+			goto end
+		}
+		repo = string(dir.ToTilde(dt.OrFullPath))
+		if !slices.Contains(w.Repos, repo) {
+			w.Repos = append(w.Repos, repo)
+		}
+	}
+
+	// Fail now rather than on every later command
+	_, err = parseWorkspace(w)
+	if err != nil {
+		goto end
+	}
+
+	rootConfig.Workspaces = append(rootConfig.Workspaces, w)
+
+end:
+	if err != nil {
+		err = WithErr(err, "workspace", args.Name)
+	}
+	return err
+}
+
+// RemoveWorkspace removes the named workspace from the root config
+func RemoveWorkspace(rootConfig *gomcfg.RootConfigV1, name string) (err error) {
+	i := rootConfig.WorkspaceIndex(name)
+	if i < 0 {
+		err = NewErr(ErrWorkspaceNotFound, "workspace", name)
+		goto end
+	}
+	rootConfig.Workspaces = slices.Delete(rootConfig.Workspaces, i, i+1)
+
+end:
+	return err
+}
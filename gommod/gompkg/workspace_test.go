@@ -0,0 +1,76 @@
+package gompkg
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/gomion/gommod/gomcfg"
+)
+
+func TestConfigForWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	workFile := filepath.Join(dir, "go.work")
+	repoDir := dt.DirPath(filepath.Join(dir, "repo"))
+
+	c := &Config{
+		ScanDirs:   []dt.DirPath{dt.DirPath(dir)},
+		GoEnv:      []string{"GOFLAGS=-mod=mod"},
+		Workspaces: []Workspace{{Name: "ws", RepoDirs: []dt.DirPath{repoDir}, GoWork: dt.Filepath(workFile)}},
+	}
+
+	config, ws, err := c.ForWorkspace("ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws == nil || !slices.Equal(config.ScanDirs, []dt.DirPath{repoDir}) {
+		t.Errorf("got scan dirs %v, want the workspace's repos", config.ScanDirs)
+	}
+	if !slices.Equal(config.GoEnv, c.GoEnv) {
+		t.Errorf("got go env %q with a missing go.work, want it unchanged", config.GoEnv)
+	}
+
+	err = os.WriteFile(workFile, []byte("go 1.25\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, _, err = c.ForWorkspace("ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"GOFLAGS=-mod=mod", "GOWORK=" + workFile}
+	if !slices.Equal(config.GoEnv, want) {
+		t.Errorf("got go env %q, want %q", config.GoEnv, want)
+	}
+	if len(c.GoEnv) != 1 || os.Getenv("GOWORK") == workFile {
+		t.Error("got the config or process environment changed, want only the copy")
+	}
+
+	config, ws, err = c.ForWorkspace("")
+	if err != nil || config != c || ws != nil {
+		t.Errorf("got %p, %v, %v for no workspace, want the config unchanged", config, ws, err)
+	}
+}
+
+func TestAddWorkspaceStoresAbsoluteGoWork(t *testing.T) {
+	dir := t.TempDir()
+	repoDir := filepath.Join(dir, "repo")
+	git(t, dir, "init", "--quiet", repoDir)
+	t.Chdir(dir)
+
+	rootConfig := &gomcfg.RootConfigV1{}
+	err := AddWorkspace(rootConfig, AddWorkspaceArgs{
+		Name:   "ws",
+		Repos:  []string{repoDir},
+		GoWork: "go.work",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(dir, "go.work")
+	if got := rootConfig.Workspaces[0].GoWork; got != want {
+		t.Errorf("got go.work %q, want %q", got, want)
+	}
+}
@@ -25,9 +25,12 @@ type APICompatResult struct {
 	Modifications   []APIChange
 }
 
-// AnalyzeAPICompatibility analyzes API compatibility between baseline and staged code
+// AnalyzeAPICompatibility analyzes API compatibility between baseline and
+// staged code by type-checking both with APIDiffDirs. Internal packages are
+// excluded as they are not part of the importable API.
 func AnalyzeAPICompatibility(ctx context.Context, baseline, staged dt.DirPath) (result APICompatResult, err error) {
-	// For now, return a skeleton result
+	var report APIDiffReport
+
 	result = APICompatResult{
 		Verdict:         VerdictUnknown,
 		BaselineTag:     "",
@@ -36,12 +39,71 @@ func AnalyzeAPICompatibility(ctx context.Context, baseline, staged dt.DirPath) (
 		Modifications:   []APIChange{},
 	}
 
-	// Placeholder: This will call Compare() to analyze API changes
-	// and categorize them into breaking/additions/modifications
+	err = ctx.Err()
+	if err != nil {
+		goto end
+	}
 
+	report, err = APIDiffDirs(baseline, staged, APIDiffDirsOptions{
+		ExcludeInternalPackages: true,
+	})
+	if err != nil {
+		goto end
+	}
+
+	for _, pkg := range report.Packages {
+		for _, msg := range pkg.Breaking {
+			result.BreakingChanges = append(result.BreakingChanges, newAPIChange(pkg.ImportPath, msg))
+		}
+		for _, msg := range pkg.NonBreaking {
+			change := newAPIChange(pkg.ImportPath, msg)
+			if change.Type == "added" {
+				result.Additions = append(result.Additions, change)
+				continue
+			}
+			result.Modifications = append(result.Modifications, change)
+		}
+	}
+
+	switch {
+	case len(result.BreakingChanges) > 0:
+		result.Verdict = VerdictBreaking
+	case len(result.Additions) == 0 && len(result.Modifications) == 0:
+		result.Verdict = VerdictNoChanges
+	default:
+		result.Verdict = VerdictLikelyCompatible
+	}
+
+end:
 	return result, err
 }
 
+// newAPIChange parses an APIDiffReport message such as "func Foo(): removed"
+// from the package at pkgPath into an APIChange
+func newAPIChange(pkgPath PackagePath, msg string) (change APIChange) {
+	change = APIChange{
+		Type:        "modified",
+		Entity:      "package",
+		Signature:   string(pkgPath),
+		Description: strings.TrimSpace(msg),
+	}
+	if target, desc, ok := strings.Cut(msg, ":"); ok {
+		change.Signature = string(pkgPath) + ": " + target
+		change.Description = strings.TrimSpace(desc)
+		change.Entity = ""
+		if kind, _, ok := strings.Cut(target, " "); ok {
+			change.Entity = kind
+		}
+	}
+	switch change.Description {
+	case "removed", "package removed":
+		change.Type = "removed"
+	case "added", "package added":
+		change.Type = "added"
+	}
+	return change
+}
+
 // AnalysisSummary implements AnalysisResult interface
 func (r APICompatResult) AnalysisSummary(format OutputFormat) string {
 	switch format {
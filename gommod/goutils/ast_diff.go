@@ -2,6 +2,15 @@ package goutils
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"sort"
 	"strings"
 
 	"github.com/mikeschinkel/go-dt"
@@ -21,8 +30,16 @@ type FuncChange struct {
 	ChangeType  string // "added", "removed", "modified"
 	Signature   string
 	Description string
+	Exported    bool // Part of the exported API (exported name, receiver and package)
 }
 
+// Function change descriptions used for FuncChange.Description when ChangeType
+// is "modified"
+const (
+	FuncSignatureChanged = "signature changed"
+	FuncBodyChanged      = "body changed"
+)
+
 // ASTDiffResult contains AST-level analysis results
 type ASTDiffResult struct {
 	Verdict          VerdictType
@@ -32,10 +49,28 @@ type ASTDiffResult struct {
 	StructTagChanges []string // Struct tag modifications
 }
 
-// AnalyzeASTDiff analyzes AST-level changes between baseline and staged code
+// ExportedBodyChanges returns the exported functions and methods whose body
+// changed without their signature changing, i.e. behavior-only changes
+func (r ASTDiffResult) ExportedBodyChanges() (changes []FuncChange) {
+	for _, fc := range r.FuncChanges {
+		if fc.Exported && fc.Description == FuncBodyChanged {
+			changes = append(changes, fc)
+		}
+	}
+	return changes
+}
+
+var ErrASTDiff = errors.New("AST diff failed")
+
+// AnalyzeASTDiff analyzes AST-level changes between baseline and staged code.
+// Both directories are parsed with go/parser, skipping _test.go files, and
+// functions, methods and types are matched by package directory and name.
+// Function bodies are compared by a hash of their printed form, printed without
+// the original positions, so formatting and comment-only edits are not reported
+// as body changes.
 func AnalyzeASTDiff(ctx context.Context, baseline, staged dt.DirPath) (result ASTDiffResult, err error) {
-	// TODO: Implement using go/parser and go/ast
-	// For now, return a skeleton result
+	var oldDecls, newDecls astDecls
+
 	result = ASTDiffResult{
 		Verdict:          VerdictUnknown,
 		TypeChanges:      []TypeChange{},
@@ -44,12 +79,304 @@ func AnalyzeASTDiff(ctx context.Context, baseline, staged dt.DirPath) (result AS
 		StructTagChanges: []string{},
 	}
 
-	// Placeholder: This will parse both directories using go/parser,
-	// compare ASTs, and detect type/function/doc changes
+	oldDecls, err = parseASTDecls(ctx, baseline)
+	if err != nil {
+		goto end
+	}
+	newDecls, err = parseASTDecls(ctx, staged)
+	if err != nil {
+		goto end
+	}
+
+	result.diffFuncs(oldDecls.funcs, newDecls.funcs)
+	result.diffTypes(oldDecls.types, newDecls.types)
+	result.Verdict = result.verdict()
 
+end:
+	if err != nil {
+		err = NewErr(ErrASTDiff, "baseline", baseline, "staged", staged, err)
+	}
 	return result, err
 }
 
+// verdict grades the changes found: removed or re-signed exported functions are
+// breaking, changed exported behavior may or may not be, and anything else is
+// likely compatible
+func (r ASTDiffResult) verdict() VerdictType {
+	var behavior bool
+
+	if len(r.FuncChanges) == 0 && len(r.TypeChanges) == 0 && len(r.StructTagChanges) == 0 {
+		return VerdictNoChanges
+	}
+	for _, fc := range r.FuncChanges {
+		if !fc.Exported {
+			continue
+		}
+		switch {
+		case fc.ChangeType == "removed", fc.Description == FuncSignatureChanged:
+			return VerdictBreaking
+		case fc.Description == FuncBodyChanged:
+			behavior = true
+		}
+	}
+	for _, tc := range r.TypeChanges {
+		if tc.ChangeType == "removed" {
+			return VerdictBreaking
+		}
+	}
+	if behavior || len(r.StructTagChanges) > 0 {
+		return VerdictMaybeCompatible
+	}
+	return VerdictLikelyCompatible
+}
+
+func (r *ASTDiffResult) diffFuncs(oldFuncs, newFuncs map[string]astFunc) {
+	for _, name := range sortedKeys(oldFuncs, newFuncs) {
+		oldFn, inOld := oldFuncs[name]
+		newFn, inNew := newFuncs[name]
+		fc := FuncChange{
+			FuncName:  name,
+			Signature: newFn.signature,
+			Exported:  newFn.exported,
+		}
+		switch {
+		case !inNew:
+			fc.ChangeType = "removed"
+			fc.Signature = oldFn.signature
+			fc.Exported = oldFn.exported
+			fc.Description = "removed"
+		case !inOld:
+			fc.ChangeType = "added"
+			fc.Description = "added"
+		case oldFn.signature != newFn.signature:
+			fc.ChangeType = "modified"
+			fc.Description = FuncSignatureChanged
+		case oldFn.bodyHash != newFn.bodyHash:
+			fc.ChangeType = "modified"
+			fc.Description = FuncBodyChanged
+		default:
+			if oldFn.exported && oldFn.doc != newFn.doc {
+				r.DocChanges = append(r.DocChanges, name)
+			}
+			continue
+		}
+		r.FuncChanges = append(r.FuncChanges, fc)
+	}
+}
+
+func (r *ASTDiffResult) diffTypes(oldTypes, newTypes map[string]astType) {
+	for _, name := range sortedKeys(oldTypes, newTypes) {
+		oldT, inOld := oldTypes[name]
+		newT, inNew := newTypes[name]
+		switch {
+		case !inNew:
+			r.TypeChanges = append(r.TypeChanges, TypeChange{TypeName: name, ChangeType: "removed", Description: oldT.decl})
+			continue
+		case !inOld:
+			r.TypeChanges = append(r.TypeChanges, TypeChange{TypeName: name, ChangeType: "added", Description: newT.decl})
+			continue
+		case oldT.decl != newT.decl:
+			r.TypeChanges = append(r.TypeChanges, TypeChange{TypeName: name, ChangeType: "modified", Description: newT.decl})
+		}
+		if oldT.doc != newT.doc {
+			r.DocChanges = append(r.DocChanges, name)
+		}
+		for _, field := range sortedKeys(oldT.tags, newT.tags) {
+			oldTag, inOldT := oldT.tags[field]
+			newTag, inNewT := newT.tags[field]
+			if !inOldT || !inNewT || oldTag == newTag {
+				continue
+			}
+			r.StructTagChanges = append(r.StructTagChanges, fmt.Sprintf("%s.%s: %s → %s", name, field, oldTag, newTag))
+		}
+	}
+}
+
+// astFunc is what AnalyzeASTDiff compares for a function or method
+type astFunc struct {
+	pkgDir    string
+	name      string // Unqualified name; the method name for methods
+	signature string
+	bodyHash  string
+	doc       string
+	exported  bool
+}
+
+// astType is what AnalyzeASTDiff compares for an exported type
+type astType struct {
+	decl string
+	doc  string
+	tags map[string]string // struct field name → tag literal
+}
+
+// astDecls are the functions and exported types of a module keyed by their
+// package-qualified name
+type astDecls struct {
+	funcs map[string]astFunc
+	types map[string]astType
+}
+
+// parseASTDecls parses the non-test Go files of the module in dir
+func parseASTDecls(ctx context.Context, dir dt.DirPath) (decls astDecls, err error) {
+	decls = astDecls{
+		funcs: make(map[string]astFunc),
+		types: make(map[string]astType),
+	}
+	fset := token.NewFileSet()
+	err = WalkGoFiles(dir, func(pkgDir string, file dt.Filepath) (err error) {
+		var f *ast.File
+
+		err = ctx.Err()
+		if err != nil || isTestFile(file) {
+			return err
+		}
+		f, err = parser.ParseFile(fset, string(file), nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+		decls.add(pkgDir, f)
+		return nil
+	})
+	return decls, err
+}
+
+func (d astDecls) add(pkgDir string, f *ast.File) {
+	internal := isInternalImport(PackagePath("/" + pkgDir))
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			d.addFunc(pkgDir, internal, decl)
+		case *ast.GenDecl:
+			if decl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range decl.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if internal || !ts.Name.IsExported() {
+					continue
+				}
+				doc := ts.Doc
+				if doc == nil && len(decl.Specs) == 1 {
+					doc = decl.Doc
+				}
+				d.types[qualifiedName(pkgDir, ts.Name.Name)] = astType{
+					decl: printNode(&ast.TypeSpec{Name: ts.Name, TypeParams: ts.TypeParams, Assign: ts.Assign, Type: ts.Type}),
+					doc:  doc.Text(),
+					tags: structTags(ts.Type),
+				}
+			}
+		}
+	}
+}
+
+func (d astDecls) addFunc(pkgDir string, internal bool, fn *ast.FuncDecl) {
+	var body string
+
+	name := fn.Name.Name
+	exported := !internal && fn.Name.IsExported()
+	if fn.Recv != nil && len(fn.Recv.List) > 0 {
+		recv := receiverTypeName(fn.Recv.List[0].Type)
+		exported = exported && ast.IsExported(recv)
+		name = recv + "." + name
+	}
+	if name == "init" || name == "_" {
+		// Not addressable by name, and there may be several per package
+		return
+	}
+	if fn.Body != nil {
+		body = printNode(fn.Body)
+	}
+	key := qualifiedName(pkgDir, name)
+	prev, ok := d.funcs[key]
+	if ok {
+		// Declared again in a file with other build constraints
+		body = prev.bodyHash + body
+	}
+	sum := sha256.Sum256([]byte(body))
+	d.funcs[key] = astFunc{
+		pkgDir:    pkgDir,
+		name:      fn.Name.Name,
+		signature: printNode(&ast.FuncDecl{Recv: fn.Recv, Name: fn.Name, Type: fn.Type}),
+		bodyHash:  hex.EncodeToString(sum[:]),
+		doc:       fn.Doc.Text(),
+		exported:  exported,
+	}
+}
+
+// receiverTypeName returns the base type name of a method receiver
+func receiverTypeName(expr ast.Expr) string {
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// structTags returns the tags of the fields of expr if it is a struct type
+func structTags(expr ast.Expr) (tags map[string]string) {
+	st, ok := expr.(*ast.StructType)
+	if !ok || st.Fields == nil {
+		return nil
+	}
+	tags = make(map[string]string)
+	for _, field := range st.Fields.List {
+		if field.Tag == nil {
+			continue
+		}
+		if len(field.Names) == 0 {
+			tags[printNode(field.Type)] = field.Tag.Value
+			continue
+		}
+		for _, name := range field.Names {
+			tags[name.Name] = field.Tag.Value
+		}
+	}
+	return tags
+}
+
+// qualifiedName prefixes name with its package directory unless it is the root
+func qualifiedName(pkgDir, name string) string {
+	if pkgDir == "." {
+		return name
+	}
+	return pkgDir + "." + name
+}
+
+// printNode prints node without its comments. An empty FileSet is used rather
+// than the one node was parsed with so the printer cannot reproduce the
+// original line breaks, making the output independent of formatting.
+func printNode(node any) string {
+	var b strings.Builder
+	_ = printer.Fprint(&b, token.NewFileSet(), node)
+	return b.String()
+}
+
+// sortedKeys returns the union of the keys of a and b in sorted order
+func sortedKeys[V any](a, b map[string]V) (keys []string) {
+	keys = make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // AnalysisSummary implements AnalysisResult interface
 func (r ASTDiffResult) AnalysisSummary(format OutputFormat) string {
 	switch format {
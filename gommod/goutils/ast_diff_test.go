@@ -0,0 +1,59 @@
+package goutils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// writeGoFile writes content to dir/name.go, creating dir
+func writeGoFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, name+".go"), []byte(content), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAnalyzeASTDiffIgnoresFormatting(t *testing.T) {
+	root := t.TempDir()
+	baseline := filepath.Join(root, "baseline")
+	current := filepath.Join(root, "current")
+
+	writeGoFile(t, baseline, "foo", `package foo
+
+func Foo(a, b int) []int {
+	if a > b { return []int{a, b} }
+	return []int{b, a}
+}
+`)
+	writeGoFile(t, current, "foo", `package foo
+
+func Foo(a,
+	b int) []int {
+	// Keep the larger value first
+	if a > b {
+		return []int{
+			a,
+			b,
+		}
+	}
+
+	return []int{b, a} // swapped
+}
+`)
+
+	result, err := AnalyzeASTDiff(t.Context(), dt.DirPath(baseline), dt.DirPath(current))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.FuncChanges) != 0 {
+		t.Errorf("got func changes %+v, want none", result.FuncChanges)
+	}
+}
@@ -0,0 +1,281 @@
+package goutils
+
+import (
+	"context"
+	"errors"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+var ErrNoBenchmarks = errors.New("no benchmark results")
+
+// BenchKey identifies one metric of one benchmark
+type BenchKey struct {
+	Name string // Package-qualified benchmark name without the -GOMAXPROCS suffix
+	Unit string // e.g. "ns/op", "B/op", "allocs/op", "MB/s"
+}
+
+// BenchSamples holds every sample of every metric from one or more runs
+type BenchSamples map[BenchKey][]float64
+
+// BenchComparison is the benchstat-style comparison of one metric between a
+// baseline and a current run
+type BenchComparison struct {
+	BenchKey
+	BaselineMedian float64
+	CurrentMedian  float64
+	BaselineN      int
+	CurrentN       int
+
+	// Delta is the change of the median in percent
+	Delta float64
+
+	// P is the two-sided Mann-Whitney U test p-value
+	P float64
+
+	// Significant is true if P is below the comparison's alpha
+	Significant bool
+}
+
+// Worse returns true if the metric got worse: higher for cost units such as
+// ns/op, B/op and allocs/op, lower for throughput units such as MB/s
+func (c BenchComparison) Worse() bool {
+	if HigherIsBetter(c.Unit) {
+		return c.Delta < 0
+	}
+	return c.Delta > 0
+}
+
+// HigherIsBetter returns true for throughput units
+func HigherIsBetter(unit string) bool {
+	return strings.HasSuffix(unit, "/s")
+}
+
+// RunBenchmarksArgs contains the input parameters for RunBenchmarks
+type RunBenchmarksArgs struct {
+	// Bench is the -bench regexp
+	Bench string
+
+	// Count is how many times each benchmark runs
+	Count int
+}
+
+// RunBenchmarks runs the benchmarks of the module in dir matching args.Bench
+// with -benchmem and returns their samples
+func RunBenchmarks(ctx context.Context, dir dt.DirPath, args RunBenchmarksArgs) (samples BenchSamples, err error) {
+	var out string
+
+	out, err = RunGo(ctx, dir, "test", "-run=^$",
+		"-bench="+args.Bench,
+		"-count="+strconv.Itoa(args.Count),
+		"-benchmem",
+		"./...",
+	)
+	if err != nil {
+		goto end
+	}
+
+	samples = ParseBenchOutput(out)
+	if len(samples) == 0 {
+		err = NewErr(ErrNoBenchmarks, "bench", args.Bench)
+	}
+
+end:
+	if err != nil {
+		err = WithErr(err, "dir", dir)
+	}
+	return samples, err
+}
+
+// benchProcsSuffix matches the -GOMAXPROCS suffix of a benchmark name
+var benchProcsSuffix = regexp.MustCompile(`-\d+$`)
+
+// ParseBenchOutput parses `go test -bench` output, qualifying each benchmark
+// name with the package from the preceding "pkg:" line
+func ParseBenchOutput(out string) (samples BenchSamples) {
+	var pkg string
+
+	samples = make(BenchSamples)
+	for _, line := range strings.Split(out, "\n") {
+		if p, ok := strings.CutPrefix(line, "pkg: "); ok {
+			pkg = strings.TrimSpace(p)
+			continue
+		}
+		if !strings.HasPrefix(line, "Benchmark") {
+			continue
+		}
+		// BenchmarkFoo-8  1000000  1234 ns/op  16 B/op  1 allocs/op
+		fields := strings.Fields(line)
+		if len(fields) < 4 || len(fields)%2 != 0 {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[1]); err != nil {
+			continue
+		}
+		name := benchProcsSuffix.ReplaceAllString(fields[0], "")
+		if pkg != "" {
+			name = pkg + "." + name
+		}
+		for i := 2; i+1 < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				continue
+			}
+			key := BenchKey{Name: name, Unit: fields[i+1]}
+			samples[key] = append(samples[key], value)
+		}
+	}
+	return samples
+}
+
+// CompareBenchmarks compares the metrics present in both baseline and current,
+// marking as significant those whose samples differ at the alpha level
+func CompareBenchmarks(baseline, current BenchSamples, alpha float64) (comparisons []BenchComparison) {
+	for key, oldSamples := range baseline {
+		newSamples, ok := current[key]
+		if !ok {
+			continue
+		}
+		c := BenchComparison{
+			BenchKey:       key,
+			BaselineMedian: median(oldSamples),
+			CurrentMedian:  median(newSamples),
+			BaselineN:      len(oldSamples),
+			CurrentN:       len(newSamples),
+			P:              mannWhitneyP(oldSamples, newSamples),
+		}
+		if c.BaselineMedian != 0 {
+			c.Delta = 100 * (c.CurrentMedian - c.BaselineMedian) / c.BaselineMedian
+		}
+		c.Significant = c.P < alpha
+		comparisons = append(comparisons, c)
+	}
+	sort.Slice(comparisons, func(i, j int) bool {
+		if comparisons[i].Name != comparisons[j].Name {
+			return comparisons[i].Name < comparisons[j].Name
+		}
+		return comparisons[i].Unit < comparisons[j].Unit
+	})
+	return comparisons
+}
+
+// median returns the median of samples
+func median(samples []float64) float64 {
+	s := slices.Clone(samples)
+	slices.Sort(s)
+	n := len(s)
+	switch {
+	case n == 0:
+		return 0
+	case n%2 == 1:
+		return s[n/2]
+	default:
+		return (s[n/2-1] + s[n/2]) / 2
+	}
+}
+
+// mannWhitneyP returns the two-sided p-value of the Mann-Whitney U test that
+// a and b come from the same distribution, as benchstat uses. The exact
+// distribution of U is used for small samples without ties, otherwise the
+// normal approximation with tie and continuity corrections.
+func mannWhitneyP(a, b []float64) float64 {
+	n1, n2 := len(a), len(b)
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+
+	type sample struct {
+		value float64
+		fromA bool
+	}
+	all := make([]sample, 0, n1+n2)
+	for _, v := range a {
+		all = append(all, sample{value: v, fromA: true})
+	}
+	for _, v := range b {
+		all = append(all, sample{value: v})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].value < all[j].value })
+
+	// Rank with ties given their average rank
+	var rankA, tieSum float64
+	ties := false
+	for i := 0; i < len(all); {
+		j := i + 1
+		for j < len(all) && all[j].value == all[i].value {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].fromA {
+				rankA += rank
+			}
+		}
+		if t := float64(j - i); t > 1 {
+			ties = true
+			tieSum += t*t*t - t
+		}
+		i = j
+	}
+	u := rankA - float64(n1*(n1+1))/2
+
+	if !ties && n1 <= 20 && n2 <= 20 {
+		return exactMannWhitneyP(n1, n2, int(u))
+	}
+
+	n := float64(n1 + n2)
+	mean := float64(n1*n2) / 2
+	variance := float64(n1*n2) / 12 * ((n + 1) - tieSum/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+	z := (math.Abs(u-mean) - 0.5) / math.Sqrt(variance)
+	if z < 0 {
+		z = 0
+	}
+	return math.Min(1, math.Erfc(z/math.Sqrt2))
+}
+
+// exactMannWhitneyP returns the two-sided p-value of U = u for samples of
+// sizes n1 and n2 from the exact distribution of U without ties
+func exactMannWhitneyP(n1, n2, u int) float64 {
+	maxU := n1 * n2
+
+	// counts[i][j][k] is the number of orderings of i a's and j b's with U = k
+	counts := make([][][]float64, n1+1)
+	for i := range counts {
+		counts[i] = make([][]float64, n2+1)
+		for j := range counts[i] {
+			counts[i][j] = make([]float64, maxU+1)
+			if i == 0 || j == 0 {
+				counts[i][j][0] = 1
+				continue
+			}
+			for k := 0; k <= i*j; k++ {
+				// The largest sample is either an a, which exceeds all j b's, or a b
+				if k >= j {
+					counts[i][j][k] += counts[i-1][j][k-j]
+				}
+				counts[i][j][k] += counts[i][j-1][k]
+			}
+		}
+	}
+
+	var total, lower, upper float64
+	for k, c := range counts[n1][n2] {
+		total += c
+		if k <= u {
+			lower += c
+		}
+		if k >= u {
+			upper += c
+		}
+	}
+	return math.Min(1, 2*math.Min(lower, upper)/total)
+}
@@ -0,0 +1,83 @@
+package goutils
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestParseBenchOutput(t *testing.T) {
+	out := `goos: linux
+goarch: amd64
+pkg: example.com/m/pkg
+cpu: Some CPU
+BenchmarkParse-8   	 1000000	      1200 ns/op	      64 B/op	       2 allocs/op
+BenchmarkParse-8   	 1000000	      1100 ns/op	      64 B/op	       2 allocs/op
+BenchmarkCopy/small-8	  500000	      2000 ns/op	  512.00 MB/s
+BenchmarkBroken-8 	--- FAIL: BenchmarkBroken
+PASS
+ok  	example.com/m/pkg	3.210s
+pkg: example.com/m/other
+BenchmarkParse-16  	 2000000	       900 ns/op
+PASS
+`
+	samples := ParseBenchOutput(out)
+
+	want := map[BenchKey][]float64{
+		{Name: "example.com/m/pkg.BenchmarkParse", Unit: "ns/op"}:      {1200, 1100},
+		{Name: "example.com/m/pkg.BenchmarkParse", Unit: "B/op"}:       {64, 64},
+		{Name: "example.com/m/pkg.BenchmarkParse", Unit: "allocs/op"}:  {2, 2},
+		{Name: "example.com/m/pkg.BenchmarkCopy/small", Unit: "ns/op"}: {2000},
+		{Name: "example.com/m/pkg.BenchmarkCopy/small", Unit: "MB/s"}:  {512},
+		{Name: "example.com/m/other.BenchmarkParse", Unit: "ns/op"}:    {900},
+	}
+	if len(samples) != len(want) {
+		t.Errorf("got %d metrics, want %d: %v", len(samples), len(want), samples)
+	}
+	for key, values := range want {
+		if !slices.Equal(samples[key], values) {
+			t.Errorf("%s %s: got %v, want %v", key.Name, key.Unit, samples[key], values)
+		}
+	}
+}
+
+func TestMannWhitneyP(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float64
+		want float64
+	}{
+		// Exact: the 2 most extreme of the C(6,3) = 20 orderings
+		{name: "SeparatedSmall", a: []float64{1, 2, 3}, b: []float64{4, 5, 6}, want: 0.1},
+		// Exact: U = 3 or U >= 6 in 14 of the 20 orderings
+		{name: "Interleaved", a: []float64{1, 3, 5}, b: []float64{2, 4, 6}, want: 0.7},
+		// Exact: the 2 most extreme of the C(20,10) = 184756 orderings
+		{
+			name: "SeparatedTen",
+			a:    []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+			b:    []float64{11, 12, 13, 14, 15, 16, 17, 18, 19, 20},
+			want: 2.0 / 184756,
+		},
+		{name: "AllTied", a: []float64{5, 5, 5}, b: []float64{5, 5, 5}, want: 1},
+		{name: "Empty", a: nil, b: []float64{1, 2}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mannWhitneyP(tt.a, tt.b)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			// The test is symmetric in its samples
+			if swapped := mannWhitneyP(tt.b, tt.a); math.Abs(swapped-got) > 1e-9 {
+				t.Errorf("got %v with samples swapped, want %v", swapped, got)
+			}
+		})
+	}
+
+	t.Run("TiesUseNormalApproximation", func(t *testing.T) {
+		p := mannWhitneyP([]float64{1, 1, 2, 2, 3}, []float64{4, 4, 5, 5, 6})
+		if p <= 0 || p >= 0.05 {
+			t.Errorf("got %v, want a significant p-value", p)
+		}
+	})
+}
@@ -0,0 +1,417 @@
+package goutils
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mikeschinkel/go-dt"
+	"github.com/mikeschinkel/go-dt/dtx"
+)
+
+var ErrCoverage = errors.New("coverage analysis failed")
+
+// Coverage signals for TestSignalsResult.CoverageSignal
+const (
+	CoverageGood    = "good"
+	CoveragePoor    = "poor"
+	CoverageUnknown = "unknown"
+)
+
+// minCoverageDrop is the smallest drop in percentage points reported, so
+// rounding noise is not flagged
+const minCoverageDrop = 0.05
+
+// CoverageDelta is the change in statement coverage of a package or function,
+// in percentage points
+type CoverageDelta struct {
+	Name     string
+	Baseline float64 // Percent covered at baseline
+	Staged   float64 // Percent covered in the staged tree
+	Delta    float64 // Staged minus Baseline
+}
+
+// CoverageDeltaResult contains coverage delta analysis results
+type CoverageDeltaResult struct {
+	Verdict       VerdictType
+	BaselineTotal float64
+	StagedTotal   float64
+	Packages      []CoverageDelta // Packages present in both trees, by name
+	Funcs         []CoverageDelta // Functions present in both trees whose coverage changed, by name
+	Drops         []string        // e.g. "coverage dropped in pkg X by 4.2%"
+}
+
+// Signal returns the CoverageSignal for TestSignalsResult
+func (r CoverageDeltaResult) Signal() string {
+	switch r.Verdict {
+	case VerdictUnknown, VerdictUnspecified:
+		return CoverageUnknown
+	}
+	if len(r.Drops) > 0 {
+		return CoveragePoor
+	}
+	return CoverageGood
+}
+
+// coverageProfile is the coverage of one tree
+type coverageProfile struct {
+	total    float64
+	packages map[string]float64 // import path → percent
+	funcs    map[string]float64 // "<import path>/<file>:<func>" → percent
+}
+
+// AnalyzeCoverageDelta runs `go test -coverprofile` over the module in each of
+// baseline and staged and compares their per-package and per-function
+// statement coverage. Failing tests do not abort the analysis as long as a
+// profile was written for the packages that passed.
+func AnalyzeCoverageDelta(ctx context.Context, baseline, staged dt.DirPath) (result CoverageDeltaResult, err error) {
+	var oldProf, newProf coverageProfile
+
+	result = CoverageDeltaResult{
+		Verdict:  VerdictUnknown,
+		Packages: []CoverageDelta{},
+		Funcs:    []CoverageDelta{},
+		Drops:    []string{},
+	}
+
+	oldProf, err = runCoverage(ctx, baseline)
+	if err != nil {
+		goto end
+	}
+	newProf, err = runCoverage(ctx, staged)
+	if err != nil {
+		goto end
+	}
+
+	result.BaselineTotal = oldProf.total
+	result.StagedTotal = newProf.total
+	result.Packages = coverageDeltas(oldProf.packages, newProf.packages, false)
+	result.Funcs = coverageDeltas(oldProf.funcs, newProf.funcs, true)
+
+	for _, pkg := range result.Packages {
+		if -pkg.Delta < minCoverageDrop {
+			continue
+		}
+		result.Drops = append(result.Drops, fmt.Sprintf("coverage dropped in pkg %s by %.1f%%", pkg.Name, -pkg.Delta))
+	}
+
+	result.Verdict = VerdictLikelyCompatible
+	if len(result.Drops) > 0 {
+		result.Verdict = VerdictMaybeCompatible
+	}
+
+end:
+	if err != nil {
+		err = NewErr(ErrCoverage, "baseline", baseline, "staged", staged, err)
+	}
+	return result, err
+}
+
+// coverageDeltas compares the entries present in both maps, optionally only
+// those whose coverage changed
+func coverageDeltas(oldPcts, newPcts map[string]float64, changedOnly bool) (deltas []CoverageDelta) {
+	deltas = []CoverageDelta{}
+	for _, name := range sortedKeys(oldPcts, newPcts) {
+		oldPct, inOld := oldPcts[name]
+		newPct, inNew := newPcts[name]
+		if !inOld || !inNew {
+			continue
+		}
+		if changedOnly && oldPct == newPct {
+			continue
+		}
+		deltas = append(deltas, CoverageDelta{
+			Name:     name,
+			Baseline: oldPct,
+			Staged:   newPct,
+			Delta:    newPct - oldPct,
+		})
+	}
+	return deltas
+}
+
+// runCoverage runs the module's tests in dir with a coverage profile and
+// returns its per-package and per-function coverage
+func runCoverage(ctx context.Context, dir dt.DirPath) (prof coverageProfile, err error) {
+	var f *os.File
+	var profile dt.Filepath
+	var testErr error
+	var out string
+
+	f, err = os.CreateTemp("", "gomion-cover-*.out")
+	if err != nil {
+		goto end
+	}
+	profile = dt.Filepath(f.Name())
+	_ = f.Close()
+	defer func() { _ = profile.Remove() }()
+
+	_, testErr = RunGo(ctx, dir, "test", "-covermode=set", "-coverprofile="+string(profile), "./...")
+
+	prof.packages, err = packageCoverage(profile)
+	if err != nil {
+		goto end
+	}
+	if len(prof.packages) == 0 {
+		// CreateTemp made the profile, so it is empty rather than missing when
+		// nothing was profiled, most likely because no package built
+		err = CombineErrs([]error{errors.New("empty coverage profile"), testErr})
+		goto end
+	}
+
+	out, err = RunGo(ctx, dir, "tool", "cover", "-func="+string(profile))
+	if err != nil {
+		goto end
+	}
+	prof.total, prof.funcs = parseCoverFunc(out)
+
+end:
+	if err != nil {
+		err = WithErr(err, "dir", dir)
+	}
+	return prof, err
+}
+
+// coverBlock is a block of a coverage profile
+type coverBlock struct {
+	stmts   int
+	covered bool
+}
+
+// packageCoverage reads a coverage profile and returns the percentage of
+// statements covered in each package
+func packageCoverage(profile dt.Filepath) (pcts map[string]float64, err error) {
+	var f *os.File
+	var scanner *bufio.Scanner
+
+	blocks := make(map[string]coverBlock)
+
+	f, err = os.Open(string(profile))
+	if err != nil {
+		goto end
+	}
+	defer dt.CloseOrLog(f)
+
+	scanner = bufio.NewScanner(f)
+	for scanner.Scan() {
+		// file:startLine.startCol,endLine.endCol numStmts count
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 || fields[0] == "mode:" {
+			continue
+		}
+		stmts, convErr := strconv.Atoi(fields[1])
+		if convErr != nil {
+			continue
+		}
+		count, convErr := strconv.Atoi(fields[2])
+		if convErr != nil {
+			continue
+		}
+		// Blocks listed more than once are covered if any listing covered them
+		b := blocks[fields[0]]
+		b.stmts = stmts
+		b.covered = b.covered || count > 0
+		blocks[fields[0]] = b
+	}
+	err = scanner.Err()
+	if err != nil {
+		goto end
+	}
+
+	pcts = packagePercents(blocks)
+
+end:
+	return pcts, err
+}
+
+// packagePercents sums profile blocks keyed by "file:range" into the percent
+// of statements covered per package
+func packagePercents(blocks map[string]coverBlock) (pcts map[string]float64) {
+	total := make(map[string]int)
+	covered := make(map[string]int)
+	for pos, b := range blocks {
+		file, _, _ := strings.Cut(pos, ":")
+		pkg := path.Dir(file)
+		total[pkg] += b.stmts
+		if b.covered {
+			covered[pkg] += b.stmts
+		}
+	}
+
+	pcts = make(map[string]float64, len(total))
+	for pkg, n := range total {
+		pcts[pkg] = 100
+		if n > 0 {
+			pcts[pkg] = 100 * float64(covered[pkg]) / float64(n)
+		}
+	}
+	return pcts
+}
+
+// parseCoverFunc parses `go tool cover -func` output, whose lines look like
+// "example.com/m/pkg/file.go:12:\tFunc\t\t75.0%" followed by a final
+// "total:\t(statements)\t80.0%" line. Functions are keyed by file and name
+// as their line numbers differ between trees. The output omits receivers, so
+// same-named methods in one file are told apart by their order, e.g. the
+// second String method in file.go is keyed "example.com/m/pkg/file.go:String#2".
+func parseCoverFunc(out string) (total float64, funcs map[string]float64) {
+	funcs = make(map[string]float64)
+	seen := make(map[string]int)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		pct, err := strconv.ParseFloat(strings.TrimSuffix(fields[len(fields)-1], "%"), 64)
+		if err != nil {
+			continue
+		}
+		if fields[0] == "total:" {
+			total = pct
+			continue
+		}
+		file, _, _ := strings.Cut(fields[0], ":")
+		key := file + ":" + fields[1]
+		seen[key]++
+		if seen[key] > 1 {
+			key += "#" + strconv.Itoa(seen[key])
+		}
+		funcs[key] = pct
+	}
+	return total, funcs
+}
+
+// AnalysisSummary implements AnalysisResult interface
+func (r CoverageDeltaResult) AnalysisSummary(format OutputFormat) string {
+	switch format {
+	case MarkdownFormat:
+		return r.formatAsMarkdown()
+	case ANSIEscapedFormat:
+		return r.formatAsANSI()
+	case TextFormat:
+		return r.formatAsPlainText()
+	default:
+		return r.formatAsPlainText()
+	}
+}
+
+// droppedFuncs returns the functions whose coverage dropped, largest drop first
+func (r CoverageDeltaResult) droppedFuncs() (funcs []CoverageDelta) {
+	for _, fn := range r.Funcs {
+		if -fn.Delta >= minCoverageDrop {
+			funcs = append(funcs, fn)
+		}
+	}
+	sort.SliceStable(funcs, func(i, j int) bool {
+		return funcs[i].Delta < funcs[j].Delta
+	})
+	return funcs
+}
+
+func (r CoverageDeltaResult) formatAsMarkdown() string {
+	var b strings.Builder
+
+	b.WriteString("## Coverage Analysis\n\n")
+	dtx.Fprintf(&b, "**Verdict:** %s\n", r.Verdict)
+	if r.Verdict == VerdictUnknown {
+		b.WriteString("\n")
+		return b.String()
+	}
+	dtx.Fprintf(&b, "**Total:** %.1f%% → %.1f%%\n\n", r.BaselineTotal, r.StagedTotal)
+
+	if len(r.Drops) > 0 {
+		b.WriteString("### Coverage Drops\n")
+		for _, drop := range r.Drops {
+			dtx.Fprintf(&b, "- %s\n", drop)
+		}
+		b.WriteString("\n")
+	}
+
+	if funcs := r.droppedFuncs(); len(funcs) > 0 {
+		b.WriteString("### Functions With Less Coverage\n")
+		for _, fn := range funcs {
+			dtx.Fprintf(&b, "- `%s` - %.1f%% → %.1f%%\n", fn.Name, fn.Baseline, fn.Staged)
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+func (r CoverageDeltaResult) formatAsANSI() string {
+	var b strings.Builder
+
+	const (
+		magenta = "\033[35m"
+		green   = "\033[32m"
+		red     = "\033[31m"
+		bold    = "\033[1m"
+		reset   = "\033[0m"
+	)
+
+	dtx.Fprintf(&b, "%s%sCoverage Analysis%s\n\n", bold, magenta, reset)
+	if r.Verdict == VerdictUnknown {
+		dtx.Fprintf(&b, "Verdict: %s\n\n", r.Verdict)
+		return b.String()
+	}
+
+	totalColor := green
+	if r.StagedTotal < r.BaselineTotal {
+		totalColor = red
+	}
+	dtx.Fprintf(&b, "Total: %.1f%% → %s%.1f%%%s\n\n", r.BaselineTotal, totalColor, r.StagedTotal, reset)
+
+	if len(r.Drops) > 0 {
+		dtx.Fprintf(&b, "%s%sCoverage Drops:%s\n", bold, red, reset)
+		for _, drop := range r.Drops {
+			dtx.Fprintf(&b, "  %s↓ %s%s\n", red, drop, reset)
+		}
+		b.WriteString("\n")
+	}
+
+	if funcs := r.droppedFuncs(); len(funcs) > 0 {
+		dtx.Fprintf(&b, "%s%sFunctions With Less Coverage:%s\n", bold, red, reset)
+		for _, fn := range funcs {
+			dtx.Fprintf(&b, "  %s• %s %.1f%% → %.1f%%%s\n", red, fn.Name, fn.Baseline, fn.Staged, reset)
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+func (r CoverageDeltaResult) formatAsPlainText() string {
+	var b strings.Builder
+
+	b.WriteString("Coverage Analysis\n\n")
+	dtx.Fprintf(&b, "Verdict: %s\n", r.Verdict)
+	if r.Verdict == VerdictUnknown {
+		b.WriteString("\n")
+		return b.String()
+	}
+	dtx.Fprintf(&b, "Total: %.1f%% -> %.1f%%\n\n", r.BaselineTotal, r.StagedTotal)
+
+	if len(r.Drops) > 0 {
+		b.WriteString("Coverage Drops:\n")
+		for _, drop := range r.Drops {
+			dtx.Fprintf(&b, "  - %s\n", drop)
+		}
+		b.WriteString("\n")
+	}
+
+	if funcs := r.droppedFuncs(); len(funcs) > 0 {
+		b.WriteString("Functions With Less Coverage:\n")
+		for _, fn := range funcs {
+			dtx.Fprintf(&b, "  - %s %.1f%% -> %.1f%%\n", fn.Name, fn.Baseline, fn.Staged)
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,28 @@
+package goutils
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParseCoverFunc(t *testing.T) {
+	out := "example.com/m/pkg/file.go:12:\tString\t\t75.0%\n" +
+		"example.com/m/pkg/file.go:30:\tString\t\t50.0%\n" +
+		"example.com/m/pkg/other.go:8:\tString\t\t100.0%\n" +
+		"example.com/m/pkg/other.go:20:\tParse\t\t0.0%\n" +
+		"total:\t\t\t\t(statements)\t62.5%\n"
+
+	total, funcs := parseCoverFunc(out)
+	if total != 62.5 {
+		t.Errorf("got total %v, want 62.5", total)
+	}
+	want := map[string]float64{
+		"example.com/m/pkg/file.go:String":   75,
+		"example.com/m/pkg/file.go:String#2": 50,
+		"example.com/m/pkg/other.go:String":  100,
+		"example.com/m/pkg/other.go:Parse":   0,
+	}
+	if !maps.Equal(funcs, want) {
+		t.Errorf("got %v, want %v", funcs, want)
+	}
+}
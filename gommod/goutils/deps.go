@@ -36,6 +36,10 @@ func (m *Module) AnalyzeStatus() (status Status) {
 	inFlux := false
 
 	for _, req := range m.Requires {
+		if req.ToolOnly() && m.Graph != nil && m.Graph.ToolDepsInFlux {
+			// Analyze the tool dependency as if it were imported
+			req.Indirect = false
+		}
 		dep, skip := analyzeRequire(req, replaces)
 		if skip {
 			continue
@@ -77,7 +81,7 @@ func analyzeRequire(req Require, replaces replacesMap) (ds DependencyState, skip
 	}
 	if ok {
 		switch {
-		case rep.New.Version == "" && rep.New.Path.maybeLocalPath():
+		case rep.IsLocal():
 			ds.InFlux = true
 			ds.Reason = "local replace"
 		case rep.New.Version != "" && module.IsPseudoVersion(string(rep.New.Version)):
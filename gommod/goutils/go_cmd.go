@@ -0,0 +1,55 @@
+package goutils
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// RunGo runs the go command in dir and returns its combined output
+func RunGo(ctx context.Context, dir dt.DirPath, args ...string) (string, error) {
+	return RunGoEnv(ctx, dir, nil, args...)
+}
+
+// RunGoEnv runs the go command in dir with env, e.g. "GOPROXY=off", added to
+// the current environment and returns its combined output
+func RunGoEnv(ctx context.Context, dir dt.DirPath, env []string, args ...string) (_ string, err error) {
+	var out bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = string(dir)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err = cmd.Run()
+	if err != nil {
+		msg := strings.TrimSpace(out.String())
+		err = fmt.Errorf("go %s: %w (%s)", strings.Join(args, " "), err, msg)
+	}
+	return out.String(), err
+}
+
+// StreamGoEnv runs the go command in dir with env added to the current
+// environment, writing its combined output to w as it is produced
+func StreamGoEnv(ctx context.Context, dir dt.DirPath, env []string, w io.Writer, args ...string) (err error) {
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = string(dir)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	cmd.Stdout = w
+	cmd.Stderr = w
+	err = cmd.Run()
+	if err != nil {
+		err = fmt.Errorf("go %s: %w", strings.Join(args, " "), err)
+	}
+	return err
+}
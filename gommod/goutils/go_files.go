@@ -0,0 +1,66 @@
+package goutils
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// GoFileFunc is called by WalkGoFiles for each Go file with the slash-separated
+// directory of its package relative to the walk root ("." for the root)
+type GoFileFunc func(pkgDir string, file dt.Filepath) error
+
+// WalkGoFiles calls fn for each .go file, including _test.go files, of the
+// module rooted at root. Nested modules, vendor and testdata directories and
+// directories starting with "." or "_" are skipped, as the go command does.
+func WalkGoFiles(root dt.DirPath, fn GoFileFunc) error {
+	return filepath.WalkDir(string(root), func(path string, d fs.DirEntry, err error) error {
+		var rel string
+
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return skipNonPackageDir(dt.DirPath(path), root, d.Name())
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		rel, err = filepath.Rel(string(root), filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		return fn(filepath.ToSlash(rel), dt.Filepath(path))
+	})
+}
+
+// skipNonPackageDir returns fs.SkipDir for directories below root the go
+// command would not treat as packages of root's module
+func skipNonPackageDir(path, root dt.DirPath, name string) (err error) {
+	var hasGoMod bool
+
+	if path == root {
+		goto end
+	}
+	if name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+		err = fs.SkipDir
+		goto end
+	}
+	hasGoMod, err = dt.FilepathJoin(path, "go.mod").Exists()
+	if err != nil {
+		goto end
+	}
+	if hasGoMod {
+		err = fs.SkipDir
+	}
+
+end:
+	return err
+}
+
+// isTestFile returns true if file is a _test.go file
+func isTestFile(file dt.Filepath) bool {
+	return strings.HasSuffix(string(file), "_test.go")
+}
@@ -0,0 +1,34 @@
+package goutils
+
+import (
+	"github.com/mikeschinkel/go-dt"
+	"golang.org/x/mod/modfile"
+)
+
+// WorkUses returns the directories of the `use` directives in the go.work file
+// at workFile, exactly as written
+func WorkUses(workFile dt.Filepath) (uses []string, err error) {
+	var content []byte
+	var parsed *modfile.WorkFile
+
+	content, err = workFile.ReadFile()
+	if err != nil {
+		goto end
+	}
+
+	parsed, err = modfile.ParseWork(string(workFile), content, nil)
+	if err != nil {
+		goto end
+	}
+
+	uses = make([]string, 0, len(parsed.Use))
+	for _, use := range parsed.Use {
+		uses = append(uses, use.Path)
+	}
+
+end:
+	if err != nil {
+		err = WithErr(err, "go_work", workFile)
+	}
+	return uses, err
+}
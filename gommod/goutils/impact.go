@@ -0,0 +1,191 @@
+package goutils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/mikeschinkel/go-dt"
+	"golang.org/x/tools/go/packages"
+)
+
+// PackageNode is one package of an ImportGraph with its test files and test
+// imports folded in
+type PackageNode struct {
+	Path   PackagePath
+	Dir    dt.DirPath
+	Module ModulePath
+
+	// Files are the package's source files, including test files and files
+	// excluded by build constraints
+	Files []dt.Filepath
+
+	// Imports are the paths of the packages imported by any of Files
+	Imports []PackagePath
+}
+
+// ImportGraph is the package-level import graph of one or more modules, keyed
+// by package path
+type ImportGraph map[PackagePath]*PackageNode
+
+// LoadImportGraph loads the packages of the module in dir, along with their
+// tests, and returns their imports. env, e.g. "GOWORK=/tmp/go.work", is added
+// to the current environment so dependents can be loaded against a workspace.
+// Packages that fail to type-check are still included since only their import
+// declarations are needed.
+func LoadImportGraph(ctx context.Context, dir dt.DirPath, env []string) (g ImportGraph, err error) {
+	var pkgs []*packages.Package
+
+	cfg := &packages.Config{
+		Context: ctx,
+		Dir:     string(dir),
+		Mode: packages.NeedName |
+			packages.NeedFiles |
+			packages.NeedImports |
+			packages.NeedModule,
+		Env:   append(os.Environ(), env...),
+		Tests: true,
+	}
+
+	pkgs, err = packages.Load(cfg, "./...")
+	if err != nil {
+		err = NewErr(ErrLoadingPackages, "dir", dir, err)
+		goto end
+	}
+
+	g = make(ImportGraph)
+	for _, pkg := range pkgs {
+		if pkg == nil || strings.HasSuffix(pkg.ID, ".test") {
+			// Skip the generated test main packages
+			continue
+		}
+		g.add(pkg)
+	}
+	for _, node := range g {
+		slices.Sort(node.Files)
+		slices.Sort(node.Imports)
+	}
+
+end:
+	return g, err
+}
+
+// add folds pkg, which may be a test variant or external test package, into
+// the node of the package it tests
+func (g ImportGraph) add(pkg *packages.Package) {
+	pkgPath := PackagePath(pkg.PkgPath)
+	if strings.HasSuffix(pkg.Name, "_test") {
+		pkgPath = PackagePath(strings.TrimSuffix(pkg.PkgPath, "_test"))
+	}
+
+	node, ok := g[pkgPath]
+	if !ok {
+		node = &PackageNode{Path: pkgPath}
+		g[pkgPath] = node
+	}
+	if node.Module == "" && pkg.Module != nil {
+		node.Module = ModulePath(pkg.Module.Path)
+	}
+	for _, files := range [][]string{pkg.GoFiles, pkg.OtherFiles, pkg.IgnoredFiles} {
+		for _, file := range files {
+			fp := dt.Filepath(file)
+			if node.Dir == "" {
+				node.Dir = fp.Dir()
+			}
+			if !slices.Contains(node.Files, fp) {
+				node.Files = append(node.Files, fp)
+			}
+		}
+	}
+	for _, imp := range pkg.Imports {
+		impPath := PackagePath(imp.PkgPath)
+		if impPath == pkgPath || slices.Contains(node.Imports, impPath) {
+			continue
+		}
+		node.Imports = append(node.Imports, impPath)
+	}
+}
+
+// PackagesForFiles returns the packages directly affected by changes to files,
+// given as absolute paths. A .go file affects the package in its directory,
+// even if it was deleted, and a file inside a testdata directory affects the
+// package containing that testdata directory. Other files affect no package.
+func (g ImportGraph) PackagesForFiles(files []dt.Filepath) (pkgs []PackagePath) {
+	byDir := make(map[dt.DirPath]PackagePath, len(g))
+	for _, node := range g {
+		if node.Dir != "" {
+			byDir[node.Dir] = node.Path
+		}
+	}
+
+	for _, file := range files {
+		dir := file.Dir()
+		if file.Ext() != ".go" {
+			dir = testdataParent(dir)
+		}
+		pkgPath, ok := byDir[dir]
+		if !ok || slices.Contains(pkgs, pkgPath) {
+			continue
+		}
+		pkgs = append(pkgs, pkgPath)
+	}
+	slices.Sort(pkgs)
+	return pkgs
+}
+
+// testdataParent returns the directory containing the outermost testdata
+// directory dir is in, or "" if dir is not inside a testdata directory
+func testdataParent(dir dt.DirPath) dt.DirPath {
+	parts := strings.Split(filepath.ToSlash(string(dir)), "/")
+	for i, part := range parts {
+		if part == "testdata" {
+			return dt.DirPath(filepath.FromSlash(strings.Join(parts[:i], "/")))
+		}
+	}
+	return ""
+}
+
+// Importers returns pkgs plus every package in the graph that imports one of
+// them, directly or transitively, sorted by path
+func (g ImportGraph) Importers(pkgs []PackagePath) (affected []PackagePath) {
+	reverse := make(map[PackagePath][]PackagePath, len(g))
+	for _, node := range g {
+		for _, imp := range node.Imports {
+			reverse[imp] = append(reverse[imp], node.Path)
+		}
+	}
+
+	seen := make(map[PackagePath]bool, len(pkgs))
+	queue := slices.Clone(pkgs)
+	for _, pkgPath := range pkgs {
+		seen[pkgPath] = true
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		affected = append(affected, current)
+		for _, importer := range reverse[current] {
+			if seen[importer] {
+				continue
+			}
+			seen[importer] = true
+			queue = append(queue, importer)
+		}
+	}
+	slices.Sort(affected)
+	return affected
+}
+
+// ModulePackages returns the paths of the packages in the graph belonging to
+// modulePath, sorted
+func (g ImportGraph) ModulePackages(modulePath ModulePath) (pkgs []PackagePath) {
+	for _, node := range g {
+		if node.Module == modulePath {
+			pkgs = append(pkgs, node.Path)
+		}
+	}
+	slices.Sort(pkgs)
+	return pkgs
+}
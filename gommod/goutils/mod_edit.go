@@ -0,0 +1,227 @@
+package goutils
+
+import (
+	"errors"
+
+	"github.com/mikeschinkel/go-dt"
+	"golang.org/x/mod/modfile"
+)
+
+var ErrRequireNotFound = errors.New("require directive not found")
+var ErrInvalidRequire = errors.New("invalid require directive")
+var ErrInvalidReplace = errors.New("invalid replace directive")
+var ErrInvalidModulePath = errors.New("invalid module path")
+var ErrInvalidRetract = errors.New("invalid retract directive")
+
+// SetRequireVersion changes the version of an existing require directive and
+// returns the version it replaced. Call Save to write the change to go.mod.
+func (m *Module) SetRequireVersion(path ModulePath, version dt.Version) (prev dt.Version, err error) {
+	var found bool
+
+	m.chkLoaded("SetRequireVersion")
+
+	for i, req := range m.Requires {
+		if req.Path != path {
+			continue
+		}
+		prev = req.Version
+		m.Requires[i].Version = version
+		found = true
+	}
+	if !found {
+		err = NewErr(ErrRequireNotFound, "require", path, "go_mod", m.Filepath)
+		goto end
+	}
+
+	// AddRequire updates the existing require line in place
+	err = m.modfile.AddRequire(string(path), string(version))
+	if err != nil {
+		err = NewErr(ErrInvalidRequire, "require", path, "version", version, "go_mod", m.Filepath, err)
+		goto end
+	}
+
+end:
+	return prev, err
+}
+
+// Save formats the parsed go.mod, including any edits, and writes it back to
+// Filepath
+func (m *Module) Save() (err error) {
+	var data []byte
+
+	m.chkLoaded("Save")
+
+	data, err = m.Format()
+	if err != nil {
+		goto end
+	}
+
+	err = m.Filepath.WriteFile(data, 0o644)
+
+end:
+	if err != nil {
+		err = WithErr(err, "go_mod", m.Filepath)
+	}
+	return err
+}
+
+// Format returns the parsed go.mod, including any edits, formatted as Save
+// would write it
+func (m *Module) Format() (data []byte, err error) {
+	m.chkLoaded("Format")
+
+	m.modfile.Cleanup()
+	return m.modfile.Format()
+}
+
+// SetModulePath changes the module directive. Call Save to write the change to
+// go.mod.
+func (m *Module) SetModulePath(path ModulePath) (err error) {
+	m.chkLoaded("SetModulePath")
+
+	err = m.modfile.AddModuleStmt(string(path))
+	if err != nil {
+		err = NewErr(ErrInvalidModulePath, "module", path, "go_mod", m.Filepath, err)
+		goto end
+	}
+	m.Path = path
+
+end:
+	return err
+}
+
+// RenameRequire replaces the require directive for oldPath with one for newPath
+// at version, keeping it direct or indirect as before. Call Save to write the
+// change to go.mod.
+func (m *Module) RenameRequire(oldPath, newPath ModulePath, version dt.Version) (err error) {
+	var indirect bool
+	var found bool
+
+	m.chkLoaded("RenameRequire")
+
+	for i, req := range m.Requires {
+		if req.Path != oldPath {
+			continue
+		}
+		indirect = req.Indirect
+		m.Requires[i] = NewRequire(NewPathVersion(newPath, version), indirect)
+		found = true
+	}
+	if !found {
+		err = NewErr(ErrRequireNotFound, "require", oldPath, "go_mod", m.Filepath)
+		goto end
+	}
+
+	err = m.modfile.DropRequire(string(oldPath))
+	if err != nil {
+		goto end
+	}
+	m.modfile.AddNewRequire(string(newPath), string(version), indirect)
+
+end:
+	if err != nil {
+		err = WithErr(err, "require", oldPath, "new_require", newPath)
+	}
+	return err
+}
+
+// ReplaceFor returns the replace directive for path, if any
+func (m *Module) ReplaceFor(path ModulePath) (rep Replace, ok bool) {
+	m.chkLoaded("ReplaceFor")
+	for _, rep = range m.Replaces {
+		if rep.Old.Path == path {
+			ok = true
+			goto end
+		}
+	}
+	rep = Replace{}
+end:
+	return rep, ok
+}
+
+// AddReplace adds a replace directive for all versions of path pointing at a
+// local directory. Call Save to write the change to go.mod.
+func (m *Module) AddReplace(path ModulePath, dir string) (err error) {
+	m.chkLoaded("AddReplace")
+
+	err = m.modfile.AddReplace(string(path), "", dir, "")
+	if err != nil {
+		err = NewErr(ErrInvalidReplace, "replace", path, "dir", dir, "go_mod", m.Filepath, err)
+		goto end
+	}
+	m.Replaces = append(m.Replaces, NewReplace(
+		NewPathVersion(path, ""),
+		NewPathVersion(ModulePath(dir), ""),
+	))
+
+end:
+	return err
+}
+
+// DropReplace removes every replace directive for path. Call Save to write the
+// change to go.mod.
+func (m *Module) DropReplace(path ModulePath) (err error) {
+	var replaces []Replace
+
+	m.chkLoaded("DropReplace")
+
+	for _, rep := range m.Replaces {
+		if rep.Old.Path != path {
+			replaces = append(replaces, rep)
+			continue
+		}
+		err = m.modfile.DropReplace(string(path), string(rep.Old.Version))
+		if err != nil {
+			err = NewErr(ErrInvalidReplace, "replace", path, "go_mod", m.Filepath, err)
+			goto end
+		}
+	}
+	m.Replaces = replaces
+
+end:
+	return err
+}
+
+// RenameReplace points every replace directive for oldPath at newPath instead,
+// keeping each replacement. Version-specific replaces become replaces of all
+// versions since oldPath's versions do not apply to newPath. Call Save to write
+// the change to go.mod.
+func (m *Module) RenameReplace(oldPath, newPath ModulePath) (err error) {
+	m.chkLoaded("RenameReplace")
+
+	for i, rep := range m.Replaces {
+		if rep.Old.Path != oldPath {
+			continue
+		}
+		err = m.modfile.DropReplace(string(oldPath), string(rep.Old.Version))
+		if err != nil {
+			goto end
+		}
+		err = m.modfile.AddReplace(string(newPath), "", string(rep.New.Path), string(rep.New.Version))
+		if err != nil {
+			goto end
+		}
+		m.Replaces[i].Old = NewPathVersion(newPath, "")
+	}
+
+end:
+	if err != nil {
+		err = NewErr(ErrInvalidReplace, "replace", oldPath, "new_replace", newPath, "go_mod", m.Filepath, err)
+	}
+	return err
+}
+
+// AddRetract adds a retract directive, keeping r.Rationale as its comment.
+// Call Save to write the change to go.mod.
+func (m *Module) AddRetract(r Retract) (err error) {
+	m.chkLoaded("AddRetract")
+
+	err = m.modfile.AddRetract(modfile.VersionInterval{
+		Low:  string(r.Low),
+		High: string(r.High),
+	}, r.Rationale)
+	if err != nil {
+		err = NewErr(ErrInvalidRetract, "retract", r, "go_mod", m.Filepath, err)
+	}
+	return err
+}
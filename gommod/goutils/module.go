@@ -25,6 +25,8 @@ func (mp ModulePath) maybeLocalPath() (looksLocal bool) {
 	switch {
 	case dp.IsTidlePath():
 	case dp.IsAbs():
+	case modfile.IsDirectoryPath(string(mp)):
+		// Relative paths beginning with ./ or ../
 	default:
 		looksLocal = false
 	}
@@ -38,6 +40,7 @@ type Module struct {
 	Filepath dt.Filepath // Path to go.mod file
 	Requires []Require   // Required dependencies
 	Replaces []Replace   // Replace directives
+	Tools    []string    // Package paths named by tool directives
 
 	// Graph integration (optional - from gompkg)
 	Graph *ModuleGraph // Optional: dependency graph (nil if standalone)
@@ -53,6 +56,7 @@ func NewModule(goModPath dt.Filepath) *Module {
 		Filepath: goModPath,
 		Requires: make([]Require, 0),
 		Replaces: make([]Replace, 0),
+		Tools:    make([]string, 0),
 		loaded:   false,
 		Graph:    nil,
 		repo:     nil,
@@ -72,8 +76,9 @@ func (m *Module) Load() (err error) {
 		goto end
 	}
 
-	// Parse (syntax-only validation - intentional for local dev)
-	parsed, err = modfile.ParseLax(string(m.Filepath), content, nil)
+	// Parse strictly as ParseLax drops main-module-only directives such as
+	// replace and tool; this still validates syntax only, not buildability
+	parsed, err = modfile.Parse(string(m.Filepath), content, nil)
 	if err != nil {
 		goto end
 	}
@@ -98,13 +103,24 @@ func (m *Module) Load() (err error) {
 		))
 	}
 
+	// Extract tools and mark the requires that provide them
+	m.Tools = make([]string, 0, len(parsed.Tool))
+	for _, tool := range parsed.Tool {
+		m.Tools = append(m.Tools, tool.Path)
+	}
+	m.markToolRequires()
+
 	// Extract replaces
 	m.Replaces = make([]Replace, 0, len(parsed.Replace))
 	for _, rep := range parsed.Replace {
-		m.Replaces = append(m.Replaces, NewReplace(
+		r := NewReplace(
 			NewPathVersion(ModulePath(rep.Old.Path), dt.Version(rep.Old.Version)),
 			NewPathVersion(ModulePath(rep.New.Path), dt.Version(rep.New.Version)),
-		))
+		)
+		if rep.Syntax != nil {
+			r.Line = rep.Syntax.Start.Line
+		}
+		m.Replaces = append(m.Replaces, r)
 	}
 
 	m.loaded = true
@@ -150,20 +166,71 @@ func (m *Module) RequiredModulePaths() []ModulePath {
 	return paths
 }
 
-// RequireDirs returns directories of required modules (graph-aware)
+// RequireDirs returns directories of required modules whose state can make
+// this module in-flux: direct requires, plus tool-only requires when the
+// graph's ToolDepsInFlux is set (graph-aware)
 func (m *Module) RequireDirs() []ModuleDir {
 	m.chkSetGraph("RequireDirs")
 	var dirs []ModuleDir
 	for _, req := range m.Requires {
-		if !req.Indirect {
-			if mod, ok := m.Graph.modules[ModuleKey(req.Path)]; ok {
-				dirs = append(dirs, ModuleDir(mod.Dir()))
-			}
+		if req.Indirect && !(req.ToolOnly() && m.Graph.ToolDepsInFlux) {
+			continue
+		}
+		if mod, ok := m.Graph.modules[ModuleKey(req.Path)]; ok {
+			dirs = append(dirs, ModuleDir(mod.Dir()))
+		}
+	}
+	return dirs
+}
+
+// ToolDirs returns directories of modules required only to provide tools
+// (graph-aware)
+func (m *Module) ToolDirs() []ModuleDir {
+	m.chkSetGraph("ToolDirs")
+	var dirs []ModuleDir
+	for _, req := range m.Requires {
+		if !req.ToolOnly() {
+			continue
+		}
+		if mod, ok := m.Graph.modules[ModuleKey(req.Path)]; ok {
+			dirs = append(dirs, ModuleDir(mod.Dir()))
 		}
 	}
 	return dirs
 }
 
+// markToolRequires sets Tool on the requires providing the module's tools
+func (m *Module) markToolRequires() {
+	paths := make([]ModulePath, len(m.Requires))
+	for i, req := range m.Requires {
+		paths[i] = req.Path
+	}
+	for _, tool := range m.Tools {
+		provider, ok := ToolProvider(tool, paths)
+		if !ok {
+			continue
+		}
+		for i := range m.Requires {
+			if m.Requires[i].Path == provider {
+				m.Requires[i].Tool = true
+			}
+		}
+	}
+}
+
+// LocalReplaces returns the replace directives that point at a relative or
+// absolute directory. These only resolve on the machine that wrote them so a
+// module with any cannot be released.
+func (m *Module) LocalReplaces() (replaces []Replace) {
+	m.chkLoaded("LocalReplaces")
+	for _, rep := range m.Replaces {
+		if rep.IsLocal() {
+			replaces = append(replaces, rep)
+		}
+	}
+	return replaces
+}
+
 // HasReplaceDirectives returns true if module has any replace directives
 func (m *Module) HasReplaceDirectives() bool {
 	m.chkLoaded("HasReplaceDirectives")
@@ -3,6 +3,8 @@ package goutils
 import (
 	"errors"
 	"log/slog"
+	"slices"
+	"sort"
 
 	"github.com/mikeschinkel/go-cliutil"
 	"github.com/mikeschinkel/go-dt"
@@ -49,13 +51,18 @@ type ModuleGraph struct {
 	ReposByModuleDir      map[ModuleDir]*Repo
 	moduleDirVisited      map[dt.DirPath]struct{}
 
+	// ToolDepsInFlux lets modules required only for tool directives make their
+	// requirers in-flux; by default they are graph edges only
+	ToolDepsInFlux bool
+
 	Writer cliutil.Writer
 	Logger *slog.Logger
 }
 
 type ModuleGraphArgs struct {
-	Writer cliutil.Writer
-	Logger *slog.Logger
+	ToolDepsInFlux bool
+	Writer         cliutil.Writer
+	Logger         *slog.Logger
 }
 
 func NewGraph(repoDir dt.DirPath, files []dt.Filepath, args ModuleGraphArgs) *ModuleGraph {
@@ -72,6 +79,7 @@ func NewGraph(repoDir dt.DirPath, files []dt.Filepath, args ModuleGraphArgs) *Mo
 
 		// moduleDirVisited is a cache of visits so we don't repeatedly visit the same modules
 		moduleDirVisited: make(map[dt.DirPath]struct{}),
+		ToolDepsInFlux:   args.ToolDepsInFlux,
 		Writer:           args.Writer,
 		Logger:           args.Logger,
 	}
@@ -91,6 +99,9 @@ func (g *ModuleGraph) Traverse() (result *TraverseResult, err error) {
 		RepoModules: dtx.NewOrderedMap[RepoDir, []ModuleDir](10),
 	}
 
+	// Reset visits so Traverse can be called more than once per graph
+	g.moduleDirVisited = make(map[dt.DirPath]struct{})
+
 	// Get the modules required for this repo
 	repo, ok := g.ReposByRepoDir[g.RepoDir]
 	if !ok {
@@ -128,8 +139,9 @@ func (g *ModuleGraph) traverseModule(modDirs []ModuleDir, result *TraverseResult
 			continue
 		}
 
-		// Recursively process dependencies for THIS SPECIFIC MODULE first
-		err = g.traverseModule(module.RequireDirs(), result)
+		// Recursively process dependencies for THIS SPECIFIC MODULE first,
+		// including modules it requires for tools
+		err = g.traverseModule(append(module.RequireDirs(), module.ToolDirs()...), result)
 		if err != nil {
 			errs = append(errs, err)
 			continue
@@ -153,6 +165,33 @@ func (g *ModuleGraph) traverseModule(modDirs []ModuleDir, result *TraverseResult
 	return CombineErrs(errs)
 }
 
+// Dependents returns the modules in the graph that require modulePath, ordered
+// by directory for deterministic output
+func (g *ModuleGraph) Dependents(modulePath ModulePath) (dependents []*Module) {
+	return g.ReverseRequires()[modulePath]
+}
+
+// ReverseRequires returns the graph's require edges reversed: for each module
+// path required by any module in the graph, the modules requiring it, ordered
+// by directory for deterministic output
+func (g *ModuleGraph) ReverseRequires() (reverse map[ModulePath][]*Module) {
+	reverse = make(map[ModulePath][]*Module)
+	for _, module := range g.ModulesByModuleDir {
+		for _, req := range module.Requires {
+			if slices.Contains(reverse[req.Path], module) {
+				continue
+			}
+			reverse[req.Path] = append(reverse[req.Path], module)
+		}
+	}
+	for _, dependents := range reverse {
+		sort.Slice(dependents, func(i, j int) bool {
+			return dependents[i].Dir() < dependents[j].Dir()
+		})
+	}
+	return reverse
+}
+
 func (g *ModuleGraph) Build() (err error) {
 	var errs []error
 
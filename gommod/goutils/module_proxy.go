@@ -0,0 +1,249 @@
+package goutils
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json/jsontext"
+	jsonv2 "encoding/json/v2"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mikeschinkel/go-dt"
+	"golang.org/x/mod/module"
+	modzip "golang.org/x/mod/zip"
+)
+
+var ErrModuleProxy = errors.New("module proxy error")
+var ErrModuleNotResolved = errors.New("module version does not resolve through the module proxy")
+
+// verifyGoVersion is the go directive of the throwaway module used by
+// VerifyModuleResolves
+const verifyGoVersion = "1.21"
+
+// FileProxy is a GOPROXY directory in the module proxy protocol's layout,
+// served to the go command with a file:// URL
+type FileProxy struct {
+	Dir dt.DirPath
+}
+
+// URL returns the GOPROXY value for the proxy
+func (p FileProxy) URL() string {
+	return "file://" + filepath.ToSlash(string(p.Dir))
+}
+
+// AddProxyVersionArgs contains the input parameters for FileProxy.AddVersion
+type AddProxyVersionArgs struct {
+	ModulePath ModulePath
+	Version    dt.Version
+
+	// RepoRoot is the git worktree holding the module
+	RepoRoot dt.DirPath
+
+	// Revision is the git revision to take the module from, e.g. its tag
+	Revision string
+
+	// Subdir is the module's directory relative to RepoRoot, "" for the root
+	Subdir string
+
+	// Time is the commit time reported in the version's .info, if set
+	Time time.Time
+}
+
+// AddVersion adds the .info, .mod and .zip files for a version of a module
+// taken from a git repo, and lists it, as `go mod download` would fetch them
+func (p FileProxy) AddVersion(args AddProxyVersionArgs) (err error) {
+	var escPath, escVersion string
+	var versionDir dt.DirPath
+	var zipData bytes.Buffer
+	var goMod, info []byte
+	var m module.Version
+
+	m = module.Version{Path: string(args.ModulePath), Version: string(args.Version)}
+	escPath, err = module.EscapePath(m.Path)
+	if err != nil {
+		goto end
+	}
+	escVersion, err = module.EscapeVersion(m.Version)
+	if err != nil {
+		goto end
+	}
+	versionDir = dt.DirPathJoin3(p.Dir, dt.PathSegments(escPath), "@v")
+	err = versionDir.MkdirAll(0o755)
+	if err != nil {
+		goto end
+	}
+
+	err = modzip.CreateFromVCS(&zipData, m, string(args.RepoRoot), args.Revision, args.Subdir)
+	if err != nil {
+		goto end
+	}
+	goMod, err = zippedGoMod(zipData.Bytes(), m)
+	if err != nil {
+		goto end
+	}
+	info, err = jsonv2.Marshal(struct {
+		Version string
+		Time    time.Time `json:",omitzero"`
+	}{
+		Version: m.Version,
+		Time:    args.Time.UTC(),
+	}, jsontext.WithIndent("  "))
+	if err != nil {
+		goto end
+	}
+
+	err = dt.FilepathJoin(versionDir, escVersion+".zip").WriteFile(zipData.Bytes(), 0o644)
+	if err != nil {
+		goto end
+	}
+	err = dt.FilepathJoin(versionDir, escVersion+".mod").WriteFile(goMod, 0o644)
+	if err != nil {
+		goto end
+	}
+	err = dt.FilepathJoin(versionDir, escVersion+".info").WriteFile(info, 0o644)
+	if err != nil {
+		goto end
+	}
+	err = p.addToList(dt.FilepathJoin(versionDir, "list"), m.Version)
+
+end:
+	if err != nil {
+		err = NewErr(ErrModuleProxy, "module", args.ModulePath, "version", args.Version, "proxy_dir", p.Dir, err)
+	}
+	return err
+}
+
+// addToList adds version to the proxy's version list file unless present
+func (p FileProxy) addToList(list dt.Filepath, version string) (err error) {
+	var data []byte
+	var versions []string
+
+	data, err = list.ReadFile()
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+	if err != nil {
+		goto end
+	}
+	versions = strings.Fields(string(data))
+	if slices.Contains(versions, version) {
+		goto end
+	}
+	versions = append(versions, version)
+	err = list.WriteFile([]byte(strings.Join(versions, "\n")+"\n"), 0o644)
+
+end:
+	return err
+}
+
+// zippedGoMod returns the go.mod file at the root of module zip data, or a
+// synthesized one for a module without a go.mod, as the go command would
+func zippedGoMod(data []byte, m module.Version) (goMod []byte, err error) {
+	var zr *zip.Reader
+	var rc io.ReadCloser
+
+	zr, err = zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		goto end
+	}
+	for _, f := range zr.File {
+		if f.Name != m.Path+"@"+m.Version+"/go.mod" {
+			continue
+		}
+		rc, err = f.Open()
+		if err != nil {
+			goto end
+		}
+		goMod, err = io.ReadAll(rc)
+		dt.CloseOrLog(rc)
+		goto end
+	}
+	goMod = []byte("module " + modfileQuote(m.Path) + "\n")
+
+end:
+	return goMod, err
+}
+
+// modfileQuote quotes a module path for go.mod if it needs quoting
+func modfileQuote(path string) string {
+	if strings.ContainsAny(path, " \t\"'`") {
+		return `"` + strings.ReplaceAll(path, `"`, `\"`) + `"`
+	}
+	return path
+}
+
+// VerifyResolvesArgs contains the input parameters for VerifyModuleResolves
+type VerifyResolvesArgs struct {
+	ModulePath ModulePath
+	Version    dt.Version
+
+	// GoProxy is the GOPROXY to resolve through, e.g. "https://proxy.golang.org"
+	// or a FileProxy's URL
+	GoProxy string
+
+	// NoSumDB skips checksum database verification, which a version only
+	// available from a local proxy cannot pass
+	NoSumDB bool
+}
+
+// VerifyModuleResolves creates a throwaway module requiring a module version
+// and downloads it through args.GoProxy into an empty module cache. This fails
+// if the proxy cannot serve the version or if its go.mod declares a module path
+// other than args.ModulePath, e.g. when a tag's prefix does not match the
+// module's directory.
+func VerifyModuleResolves(ctx context.Context, args VerifyResolvesArgs) (err error) {
+	var tempDir string
+	var env []string
+	var goMod string
+
+	tempDir, err = os.MkdirTemp("", "gomion-verify-*")
+	if err != nil {
+		goto end
+	}
+	env = []string{
+		"GOPROXY=" + args.GoProxy,
+		"GOMODCACHE=" + filepath.Join(tempDir, "modcache"),
+		"GOFLAGS=-mod=mod",
+		"GOWORK=off",
+	}
+	if args.NoSumDB {
+		env = append(env, "GOSUMDB=off")
+	}
+	defer func() {
+		// The module cache is read-only so must be cleaned by the go command
+		_, _ = RunGoEnv(context.Background(), dt.DirPath(tempDir), env, "clean", "-modcache")
+		_ = os.RemoveAll(tempDir)
+	}()
+
+	goMod = "module gomion.invalid/verify\n\ngo " + verifyGoVersion + "\n\nrequire " +
+		modfileQuote(string(args.ModulePath)) + " " + string(args.Version) + "\n"
+	err = dt.FilepathJoin(dt.DirPath(tempDir), "go.mod").WriteFile([]byte(goMod), 0o644)
+	if err != nil {
+		goto end
+	}
+
+	// Download fetches and verifies the zip; graph loads the required go.mod,
+	// failing if it declares a different module path, without needing any of
+	// the module's own dependencies
+	_, err = RunGoEnv(ctx, dt.DirPath(tempDir), env, "mod", "download", string(args.ModulePath)+"@"+string(args.Version))
+	if err != nil {
+		err = NewErr(ErrModuleNotResolved, err)
+		goto end
+	}
+	_, err = RunGoEnv(ctx, dt.DirPath(tempDir), env, "mod", "graph")
+	if err != nil {
+		err = NewErr(ErrModuleNotResolved, err)
+	}
+
+end:
+	if err != nil {
+		err = WithErr(err, "module", args.ModulePath, "version", args.Version, "goproxy", args.GoProxy)
+	}
+	return err
+}
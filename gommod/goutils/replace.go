@@ -1,8 +1,15 @@
 package goutils
 
+import (
+	"fmt"
+)
+
 type Replace struct {
 	Old PathVersion
 	New PathVersion
+
+	// Line is the 1-based line of the directive in go.mod, or 0 if not yet saved
+	Line int
 }
 
 func NewReplace(old, new PathVersion) Replace {
@@ -11,3 +18,21 @@ func NewReplace(old, new PathVersion) Replace {
 		New: new,
 	}
 }
+
+// IsLocal returns true if the replacement is a relative or absolute directory
+// rather than another module version
+func (r Replace) IsLocal() bool {
+	return r.New.Version == "" && r.New.Path.maybeLocalPath()
+}
+
+// String returns the replace directive as it appears in go.mod
+func (r Replace) String() string {
+	old := string(r.Old.Path)
+	if r.Old.Version != "" {
+		old = r.Old.PathAtVersion()
+	}
+	if r.New.Version == "" {
+		return fmt.Sprintf("replace %s => %s", old, r.New.Path)
+	}
+	return fmt.Sprintf("replace %s => %s %s", old, r.New.Path, r.New.Version)
+}
@@ -1,10 +1,40 @@
 package goutils
 
+import (
+	"strings"
+)
+
 type Require struct {
 	PathVersion
 	Indirect bool
+
+	// Tool is true if the required module provides a package named by one of
+	// the requiring module's tool directives
+	Tool bool
 }
 
 func NewRequire(pv PathVersion, indirect bool) Require {
 	return Require{PathVersion: pv, Indirect: indirect}
 }
+
+// ToolOnly returns true if the module is required only to provide a tool; the
+// go command marks such requires indirect as no package of the requiring
+// module imports them
+func (r Require) ToolOnly() bool {
+	return r.Tool && r.Indirect
+}
+
+// ToolProvider returns the module among paths providing the tool package,
+// preferring the longest match as nested modules shadow their parents
+func ToolProvider(tool string, paths []ModulePath) (provider ModulePath, ok bool) {
+	for _, mp := range paths {
+		if tool != string(mp) && !strings.HasPrefix(tool, string(mp)+"/") {
+			continue
+		}
+		if len(mp) > len(provider) {
+			provider = mp
+			ok = true
+		}
+	}
+	return provider, ok
+}
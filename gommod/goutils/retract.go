@@ -0,0 +1,112 @@
+package goutils
+
+import (
+	"strings"
+
+	"github.com/mikeschinkel/go-dt"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+)
+
+// Retract is a retract directive of the versions from Low to High inclusive,
+// a single version when Low equals High
+type Retract struct {
+	Low       dt.Version `json:"low"`
+	High      dt.Version `json:"high"`
+	Rationale string     `json:"rationale,omitempty"`
+}
+
+// String formats the retracted versions as go.mod does, e.g. "v1.0.1" or
+// "[v1.0.0, v1.0.5]"
+func (r Retract) String() string {
+	if r.Low == r.High {
+		return string(r.Low)
+	}
+	return "[" + string(r.Low) + ", " + string(r.High) + "]"
+}
+
+// Contains returns true if version is in the retracted interval
+func (r Retract) Contains(version dt.Version) bool {
+	return semver.Compare(string(r.Low), string(version)) <= 0 &&
+		semver.Compare(string(version), string(r.High)) <= 0
+}
+
+// SameInterval returns true if r and other retract the same versions,
+// regardless of rationale
+func (r Retract) SameInterval(other Retract) bool {
+	return r.Low == other.Low && r.High == other.High
+}
+
+// ParseRetract parses a version or a "[low, high]" interval as written in a
+// go.mod retract directive
+func ParseRetract(s string, rationale string) (r Retract, err error) {
+	var low, high string
+
+	s = strings.TrimSpace(s)
+	inner, ok := strings.CutPrefix(s, "[")
+	if ok {
+		inner, ok = strings.CutSuffix(inner, "]")
+	}
+	switch {
+	case ok:
+		low, high, ok = strings.Cut(inner, ",")
+		if !ok {
+			err = NewErr(ErrInvalidRetract, "retract", s)
+			goto end
+		}
+	case strings.ContainsAny(s, "[],"):
+		err = NewErr(ErrInvalidRetract, "retract", s)
+		goto end
+	default:
+		low, high = s, s
+	}
+
+	r = Retract{
+		Low:       dt.Version(strings.TrimSpace(low)),
+		High:      dt.Version(strings.TrimSpace(high)),
+		Rationale: strings.TrimSpace(rationale),
+	}
+	if !semver.IsValid(string(r.Low)) || !semver.IsValid(string(r.High)) {
+		err = NewErr(ErrInvalidRetract, "retract", s, "reason", "versions must be valid semver")
+		goto end
+	}
+	if semver.Compare(string(r.Low), string(r.High)) > 0 {
+		err = NewErr(ErrInvalidRetract, "retract", s, "reason", "low version is above high version")
+		goto end
+	}
+
+end:
+	return r, err
+}
+
+// ParseRetracts returns the retract directives of the go.mod content in data
+func ParseRetracts(file dt.Filepath, data []byte) (retracts []Retract, err error) {
+	var parsed *modfile.File
+
+	parsed, err = modfile.ParseLax(string(file), data, nil)
+	if err != nil {
+		goto end
+	}
+	retracts = retractsOf(parsed)
+
+end:
+	return retracts, err
+}
+
+// Retracts returns the module's retract directives
+func (m *Module) Retracts() []Retract {
+	m.chkLoaded("Retracts")
+	return retractsOf(m.modfile)
+}
+
+func retractsOf(f *modfile.File) (retracts []Retract) {
+	retracts = make([]Retract, 0, len(f.Retract))
+	for _, r := range f.Retract {
+		retracts = append(retracts, Retract{
+			Low:       dt.Version(r.Low),
+			High:      dt.Version(r.High),
+			Rationale: r.Rationale,
+		})
+	}
+	return retracts
+}
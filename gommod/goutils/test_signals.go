@@ -2,12 +2,22 @@ package goutils
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/mikeschinkel/go-dt"
 	"github.com/mikeschinkel/go-dt/dtx"
 )
 
+var ErrTestSignals = errors.New("test signal analysis failed")
+
 // TestSignalsResult contains test change analysis results
 type TestSignalsResult struct {
 	VerdictType    VerdictType
@@ -16,29 +26,183 @@ type TestSignalsResult struct {
 	RemovedTests   []string // Deleted test functions
 	NewTestCount   int      // Count of new tests
 	CoverageSignal string   // "good", "poor", "unknown"
+
+	// UntestedChanges lists exported functions and methods added or changed
+	// without any added or modified test in their package referring to them
+	UntestedChanges []string
 }
 
-// AnalyzeTestSignals detects test changes between baseline and staged code
-//
-//goland:noinspection GoUnusedParameter
+// testFuncPrefixes are the prefixes of the functions the go command runs from
+// _test.go files
+var testFuncPrefixes = []string{"Test", "Benchmark", "Fuzz", "Example"}
+
+// testFunc is what AnalyzeTestSignals compares for a test function
+type testFunc struct {
+	pkgDir   string
+	bodyHash string
+	idents   map[string]bool // Identifiers the test refers to
+}
+
+// AnalyzeTestSignals detects test changes between baseline and staged code.
+// The _test.go files of both trees are parsed with go/parser and their Test,
+// Benchmark, Fuzz and Example functions matched by package directory and name;
+// a test is modified if the hash of its printed body changed. Exported
+// functions added or changed in the non-test code are then checked for an
+// added or modified test in the same package that refers to them.
 func AnalyzeTestSignals(ctx context.Context, baseline, staged dt.DirPath) (result TestSignalsResult, err error) {
-	// TODO: Implement using go/parser to find *_test.go files and test functions
-	// For now, return a skeleton result
+	var oldTests, newTests map[string]testFunc
+	var oldDecls, newDecls astDecls
+	var changed []testFunc
+
 	result = TestSignalsResult{
-		VerdictType:    VerdictUnknown,
-		NewTests:       []string{},
-		ModifiedTests:  []string{},
-		RemovedTests:   []string{},
-		NewTestCount:   0,
-		CoverageSignal: "unknown",
+		VerdictType:     VerdictUnknown,
+		NewTests:        []string{},
+		ModifiedTests:   []string{},
+		RemovedTests:    []string{},
+		NewTestCount:    0,
+		CoverageSignal:  CoverageUnknown,
+		UntestedChanges: []string{},
+	}
+
+	oldTests, err = parseTestFuncs(ctx, baseline)
+	if err != nil {
+		goto end
+	}
+	newTests, err = parseTestFuncs(ctx, staged)
+	if err != nil {
+		goto end
 	}
 
-	// Placeholder: This will find *_test.go files in both dirs,
-	// parse them, and detect new/modified/removed test functions
+	for _, name := range sortedKeys(oldTests, newTests) {
+		oldTest, inOld := oldTests[name]
+		newTest, inNew := newTests[name]
+		switch {
+		case !inNew:
+			result.RemovedTests = append(result.RemovedTests, name)
+		case !inOld:
+			result.NewTests = append(result.NewTests, name)
+			changed = append(changed, newTest)
+		case oldTest.bodyHash != newTest.bodyHash:
+			result.ModifiedTests = append(result.ModifiedTests, name)
+			changed = append(changed, newTest)
+		}
+	}
+	result.NewTestCount = len(result.NewTests)
+
+	oldDecls, err = parseASTDecls(ctx, baseline)
+	if err != nil {
+		goto end
+	}
+	newDecls, err = parseASTDecls(ctx, staged)
+	if err != nil {
+		goto end
+	}
 
+	for _, name := range sortedKeys(oldDecls.funcs, newDecls.funcs) {
+		oldFn, inOld := oldDecls.funcs[name]
+		newFn, inNew := newDecls.funcs[name]
+		if !inNew || !newFn.exported {
+			continue
+		}
+		if inOld && oldFn.signature == newFn.signature && oldFn.bodyHash == newFn.bodyHash {
+			continue
+		}
+		if testsReferTo(changed, newFn) {
+			continue
+		}
+		result.UntestedChanges = append(result.UntestedChanges, name)
+	}
+
+	switch {
+	case len(result.RemovedTests) > 0, len(result.UntestedChanges) > 0:
+		result.VerdictType = VerdictMaybeCompatible
+	case len(changed) > 0:
+		result.VerdictType = VerdictLikelyCompatible
+	default:
+		result.VerdictType = VerdictNoChanges
+	}
+
+end:
+	if err != nil {
+		err = NewErr(ErrTestSignals, "baseline", baseline, "staged", staged, err)
+	}
 	return result, err
 }
 
+// testsReferTo returns true if any of tests is in fn's package and refers to
+// fn by name
+func testsReferTo(tests []testFunc, fn astFunc) bool {
+	for _, test := range tests {
+		if test.pkgDir == fn.pkgDir && test.idents[fn.name] {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTestFuncs parses the _test.go files of the module in dir and returns
+// its test functions keyed by their package-qualified name
+func parseTestFuncs(ctx context.Context, dir dt.DirPath) (tests map[string]testFunc, err error) {
+	tests = make(map[string]testFunc)
+	fset := token.NewFileSet()
+	err = WalkGoFiles(dir, func(pkgDir string, file dt.Filepath) (err error) {
+		var f *ast.File
+
+		err = ctx.Err()
+		if err != nil || !isTestFile(file) {
+			return err
+		}
+		f, err = parser.ParseFile(fset, string(file), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || fn.Body == nil || !isTestFuncName(fn.Name.Name) {
+				continue
+			}
+			sum := sha256.Sum256([]byte(printNode(fn.Body)))
+			tests[qualifiedName(pkgDir, fn.Name.Name)] = testFunc{
+				pkgDir:   pkgDir,
+				bodyHash: hex.EncodeToString(sum[:]),
+				idents:   bodyIdents(fn.Body),
+			}
+		}
+		return nil
+	})
+	return tests, err
+}
+
+// isTestFuncName returns true if name has a test function prefix followed by
+// nothing or a character that does not start a lowercase word, the rule the go
+// command applies, e.g. "TestFoo" and "Test_foo" but not "Testify"
+func isTestFuncName(name string) bool {
+	for _, prefix := range testFuncPrefixes {
+		rest, ok := strings.CutPrefix(name, prefix)
+		if !ok {
+			continue
+		}
+		if rest == "" {
+			return true
+		}
+		r, _ := utf8.DecodeRuneInString(rest)
+		return !unicode.IsLower(r)
+	}
+	return false
+}
+
+// bodyIdents returns the set of identifiers used in body
+func bodyIdents(body *ast.BlockStmt) (idents map[string]bool) {
+	idents = make(map[string]bool)
+	ast.Inspect(body, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok {
+			idents[ident.Name] = true
+		}
+		return true
+	})
+	return idents
+}
+
 // AnalysisSummary implements AnalysisResult interface
 func (r TestSignalsResult) AnalysisSummary(format OutputFormat) string {
 	switch format {
@@ -83,8 +247,17 @@ func (r TestSignalsResult) formatAsMarkdown() string {
 		b.WriteString("\n")
 	}
 
-	if r.CoverageSignal != "unknown" {
+	if len(r.UntestedChanges) > 0 {
+		b.WriteString("### Exported Changes Without Test Changes\n")
+		for _, name := range r.UntestedChanges {
+			dtx.Fprintf(&b, "- `%s`\n", name)
+		}
+		b.WriteString("\n")
+	}
+
+	if r.CoverageSignal != CoverageUnknown {
 		b.WriteString("### Coverage Signals\n")
+		dtx.Fprintf(&b, "- Coverage signal: %s\n", r.CoverageSignal)
 		if r.NewTestCount > 0 {
 			dtx.Fprintf(&b, "- New functionality is well-tested (%d new tests added)\n", r.NewTestCount)
 		}
@@ -133,6 +306,14 @@ func (r TestSignalsResult) formatAsANSI() string {
 		b.WriteString("\n")
 	}
 
+	if len(r.UntestedChanges) > 0 {
+		dtx.Fprintf(&b, "%s%sExported Changes Without Test Changes:%s\n", bold, yellow, reset)
+		for _, name := range r.UntestedChanges {
+			dtx.Fprintf(&b, "  %s! %s%s\n", yellow, name, reset)
+		}
+		b.WriteString("\n")
+	}
+
 	if r.NewTestCount > 0 {
 		dtx.Fprintf(&b, "%sCoverage: %sGood (%d new tests)%s\n", bold, green, r.NewTestCount, reset)
 	}
@@ -170,6 +351,14 @@ func (r TestSignalsResult) formatAsPlainText() string {
 		b.WriteString("\n")
 	}
 
+	if len(r.UntestedChanges) > 0 {
+		b.WriteString("Exported Changes Without Test Changes:\n")
+		for _, name := range r.UntestedChanges {
+			dtx.Fprintf(&b, "  - %s\n", name)
+		}
+		b.WriteString("\n")
+	}
+
 	if r.NewTestCount > 0 {
 		dtx.Fprintf(&b, "Coverage: Good (%d new tests added)\n", r.NewTestCount)
 	}
@@ -0,0 +1,142 @@
+package goutils
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/mikeschinkel/go-dt"
+)
+
+// writeGoFiles writes files, keyed by their slash-separated path without the
+// .go extension, under dir
+func writeGoFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		writeGoFile(t, filepath.Dir(path), filepath.Base(path), content)
+	}
+}
+
+func TestAnalyzeTestSignals(t *testing.T) {
+	const foo = "package foo\n\nfunc Foo() int { return 1 }\n"
+	const fooTest = "package foo\n\nimport \"testing\"\n\nfunc TestFoo(t *testing.T) {\n\tif Foo() != 1 {\n\t\tt.Fail()\n\t}\n}\n"
+
+	tests := []struct {
+		name         string
+		baseline     map[string]string
+		staged       map[string]string
+		wantNew      []string
+		wantModified []string
+		wantRemoved  []string
+		wantUntested []string
+		wantVerdict  VerdictType
+	}{
+		{
+			name:        "NoChanges",
+			baseline:    map[string]string{"foo": foo, "foo_test": fooTest},
+			staged:      map[string]string{"foo": foo, "foo_test": fooTest},
+			wantVerdict: VerdictNoChanges,
+		},
+		{
+			name:     "AddedTestCoversAddedFunc",
+			baseline: map[string]string{"foo": foo, "foo_test": fooTest},
+			staged: map[string]string{
+				"foo":      foo + "\nfunc Bar() int { return 2 }\n",
+				"foo_test": fooTest + "\nfunc TestBar(t *testing.T) {\n\t_ = Bar()\n}\n",
+			},
+			wantNew:     []string{"TestBar"},
+			wantVerdict: VerdictLikelyCompatible,
+		},
+		{
+			name:     "ModifiedTestCoversChangedFunc",
+			baseline: map[string]string{"foo": foo, "foo_test": fooTest},
+			staged: map[string]string{
+				"foo":      "package foo\n\nfunc Foo() int { return 2 }\n",
+				"foo_test": "package foo\n\nimport \"testing\"\n\nfunc TestFoo(t *testing.T) {\n\tif Foo() != 2 {\n\t\tt.Fail()\n\t}\n}\n",
+			},
+			wantModified: []string{"TestFoo"},
+			wantVerdict:  VerdictLikelyCompatible,
+		},
+		{
+			name:     "ReformattedTestIsUnchanged",
+			baseline: map[string]string{"foo": foo, "foo_test": fooTest},
+			staged: map[string]string{
+				"foo":      foo,
+				"foo_test": "package foo\n\nimport \"testing\"\n\n// TestFoo checks Foo\nfunc TestFoo(t *testing.T) {\n\tif Foo() != 1 { t.Fail() }\n}\n",
+			},
+			wantVerdict: VerdictNoChanges,
+		},
+		{
+			name:        "RemovedTest",
+			baseline:    map[string]string{"foo": foo, "foo_test": fooTest},
+			staged:      map[string]string{"foo": foo, "foo_test": "package foo\n"},
+			wantRemoved: []string{"TestFoo"},
+			wantVerdict: VerdictMaybeCompatible,
+		},
+		{
+			name:     "TestifyIsNotATest",
+			baseline: map[string]string{"foo": foo, "foo_test": fooTest},
+			staged: map[string]string{
+				"foo":      foo,
+				"foo_test": fooTest + "\nfunc Testify(t *testing.T) {}\n\nfunc Test_foo(t *testing.T) {}\n\nfunc Example() {}\n",
+			},
+			wantNew:     []string{"Example", "Test_foo"},
+			wantVerdict: VerdictLikelyCompatible,
+		},
+		{
+			name:         "ChangedFuncWithoutTestChange",
+			baseline:     map[string]string{"foo": foo, "foo_test": fooTest},
+			staged:       map[string]string{"foo": "package foo\n\nfunc Foo() int { return 2 }\n", "foo_test": fooTest},
+			wantUntested: []string{"Foo"},
+			wantVerdict:  VerdictMaybeCompatible,
+		},
+		{
+			name: "TestInAnotherPackageDoesNotCover",
+			baseline: map[string]string{
+				"foo":     foo,
+				"bar/bar": "package bar\n\nfunc Bar() int { return 1 }\n",
+			},
+			staged: map[string]string{
+				"foo":      foo,
+				"foo_test": "package foo\n\nimport \"testing\"\n\nfunc TestBar(t *testing.T) {\n\t_ = bar.Bar()\n}\n",
+				"bar/bar":  "package bar\n\nfunc Bar() int { return 2 }\n",
+			},
+			wantNew:      []string{"TestBar"},
+			wantUntested: []string{"bar.Bar"},
+			wantVerdict:  VerdictMaybeCompatible,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			baseline := filepath.Join(root, "baseline")
+			staged := filepath.Join(root, "staged")
+			writeGoFiles(t, baseline, tt.baseline)
+			writeGoFiles(t, staged, tt.staged)
+
+			got, err := AnalyzeTestSignals(t.Context(), dt.DirPath(baseline), dt.DirPath(staged))
+			if err != nil {
+				t.Fatal(err)
+			}
+			check := func(field string, got, want []string) {
+				if want == nil {
+					want = []string{}
+				}
+				if !slices.Equal(got, want) {
+					t.Errorf("got %s %q, want %q", field, got, want)
+				}
+			}
+			check("new tests", got.NewTests, tt.wantNew)
+			check("modified tests", got.ModifiedTests, tt.wantModified)
+			check("removed tests", got.RemovedTests, tt.wantRemoved)
+			check("untested changes", got.UntestedChanges, tt.wantUntested)
+			if got.NewTestCount != len(tt.wantNew) {
+				t.Errorf("got new test count %d, want %d", got.NewTestCount, len(tt.wantNew))
+			}
+			if got.VerdictType != tt.wantVerdict {
+				t.Errorf("got verdict %s, want %s", got.VerdictType, tt.wantVerdict)
+			}
+		})
+	}
+}
@@ -11,8 +11,26 @@ import (
 type VersionSuggestions struct {
 	Compatible string
 	Breaking   string
+
+	// Minor is the next compatible release that adds to the exported API, e.g.
+	// "v1.3.0" for "v1.2.3". Empty when breaking, like Compatible.
+	Minor string
+
+	// Prerelease is the next prerelease in the latest tag's series, e.g.
+	// "v1.3.0-rc.2" after "v1.3.0-rc.1". Empty if the latest tag is not a
+	// prerelease.
+	Prerelease string
+
+	// Final is the release the latest tag's prerelease series leads to, e.g.
+	// "v1.3.0" for "v1.3.0-rc.2". Empty if the latest tag is not a prerelease.
+	Final string
 }
 
+// SuggestNextVersions suggests the versions that can follow latestTag. Build
+// metadata is ignored. When latestTag is a prerelease, Compatible promotes it to
+// its final version, Minor does too if the prerelease already carries a minor
+// bump (vX.Y.0), and Breaking does if it carries a breaking bump (vX.0.0 or
+// v0.Y.0).
 func SuggestNextVersions(latestTag string, breaking bool) (VersionSuggestions, error) {
 	if !semver.IsValid(latestTag) {
 		return VersionSuggestions{}, fmt.Errorf("invalid semver tag: %q", latestTag)
@@ -24,27 +42,119 @@ func SuggestNextVersions(latestTag string, breaking bool) (VersionSuggestions, e
 	}
 
 	var sugg VersionSuggestions
+	if semver.Prerelease(latestTag) != "" {
+		sugg.Final = fmt.Sprintf("v%d.%d.%d", major, minor, patch)
+		sugg.Prerelease, _ = NextPrerelease(latestTag, "")
+	}
+
+	sugg.Breaking = nextBreaking(major, minor, patch, sugg.Final != "")
 	if breaking {
-		if major == 0 {
-			sugg.Breaking = fmt.Sprintf("v%d.%d.%d", major, minor+1, 0)
-		} else {
-			sugg.Breaking = fmt.Sprintf("v%d.%d.%d", major+1, 0, 0)
-		}
 		return sugg, nil
 	}
 
-	sugg.Compatible = fmt.Sprintf("v%d.%d.%d", major, minor, patch+1)
-	if major == 0 {
-		sugg.Breaking = fmt.Sprintf("v%d.%d.%d", major, minor+1, 0)
-	} else {
-		sugg.Breaking = fmt.Sprintf("v%d.%d.%d", major+1, 0, 0)
+	sugg.Compatible = sugg.Final
+	if sugg.Compatible == "" {
+		sugg.Compatible = fmt.Sprintf("v%d.%d.%d", major, minor, patch+1)
+	}
+	sugg.Minor = fmt.Sprintf("v%d.%d.%d", major, minor+1, 0)
+	if sugg.Final != "" && patch == 0 {
+		// The prerelease already leads to a minor release
+		sugg.Minor = sugg.Final
 	}
 	return sugg, nil
 }
 
+// nextBreaking returns the next version allowed to break compatibility. A
+// prerelease of a version that is itself a breaking bump is promoted instead.
+func nextBreaking(major, minor, patch int, prerelease bool) string {
+	switch {
+	case prerelease && patch == 0 && major == 0 && minor > 0:
+		return fmt.Sprintf("v%d.%d.%d", major, minor, 0)
+	case prerelease && patch == 0 && minor == 0 && major > 0:
+		return fmt.Sprintf("v%d.%d.%d", major, 0, 0)
+	case major == 0:
+		return fmt.Sprintf("v%d.%d.%d", major, minor+1, 0)
+	default:
+		return fmt.Sprintf("v%d.%d.%d", major+1, 0, 0)
+	}
+}
+
+// NextPrerelease returns the prerelease that follows version in the series
+// named label, e.g. "v1.3.0-rc.1" → "v1.3.0-rc.2". An empty label continues
+// version's own series. If version is a prerelease from a different series, or
+// is not a prerelease at all, the label's series is started at ".1" on
+// version's major.minor.patch; a label is then required. To start a series
+// after a final release pass the intended release, e.g. a suggestion from
+// SuggestNextVersions, as a prerelease sorts before its final version.
+func NextPrerelease(version, label string) (next string, err error) {
+	var major, minor, patch int
+	var current string
+	var n int
+	var ok bool
+
+	if !semver.IsValid(version) {
+		err = fmt.Errorf("invalid semver version: %q", version)
+		goto end
+	}
+
+	major, minor, patch, err = splitSemver(version)
+	if err != nil {
+		goto end
+	}
+
+	current, n, ok = splitPrerelease(version)
+	if label == "" {
+		label = current
+	}
+	if label == "" {
+		err = fmt.Errorf("no prerelease label given for %q", version)
+		goto end
+	}
+	if !semver.IsValid("v0.0.0-" + label) {
+		err = fmt.Errorf("invalid prerelease label: %q", label)
+		goto end
+	}
+	if !ok || current != label {
+		n = 0
+	}
+	next = fmt.Sprintf("v%d.%d.%d-%s.%d", major, minor, patch, label, n+1)
+
+end:
+	return next, err
+}
+
+// IsPrerelease returns true if version has a prerelease suffix
+func IsPrerelease(version string) bool {
+	return semver.Prerelease(version) != ""
+}
+
+// splitPrerelease splits a "<label>.<n>" prerelease, e.g. "-rc.2" into "rc"
+// and 2. ok is false if version has no prerelease or it is not numbered, in
+// which case label is the whole prerelease without its leading "-".
+func splitPrerelease(version string) (label string, n int, ok bool) {
+	var i int
+	var err error
+
+	label = strings.TrimPrefix(semver.Prerelease(version), "-")
+	i = strings.LastIndex(label, ".")
+	if i == -1 {
+		goto end
+	}
+	n, err = strconv.Atoi(label[i+1:])
+	if err != nil {
+		goto end
+	}
+	label = label[:i]
+	ok = true
+
+end:
+	return label, n, ok
+}
+
 func splitSemver(v string) (major, minor, patch int, err error) {
 	v = strings.TrimPrefix(v, "v")
-	core, _, _ := strings.Cut(v, "-")
+	core, _, _ := strings.Cut(v, "+")
+	core, _, _ = strings.Cut(core, "-")
 	parts := strings.Split(core, ".")
 	if len(parts) < 3 {
 		return 0, 0, 0, fmt.Errorf("invalid version core: %q", v)
@@ -0,0 +1,124 @@
+package goutils
+
+import (
+	"testing"
+)
+
+func TestSuggestNextVersions(t *testing.T) {
+	tests := []struct {
+		name     string
+		latest   string
+		breaking bool
+		want     VersionSuggestions
+	}{
+		{
+			name:   "FinalCompatible",
+			latest: "v1.2.3",
+			want:   VersionSuggestions{Compatible: "v1.2.4", Minor: "v1.3.0", Breaking: "v2.0.0"},
+		},
+		{
+			name:     "FinalBreaking",
+			latest:   "v1.2.3",
+			breaking: true,
+			want:     VersionSuggestions{Breaking: "v2.0.0"},
+		},
+		{
+			name:     "ZeroMajorBreaking",
+			latest:   "v0.4.1",
+			breaking: true,
+			want:     VersionSuggestions{Breaking: "v0.5.0"},
+		},
+		{
+			name:   "PrereleasePromotesToFinal",
+			latest: "v1.3.0-rc.1",
+			want: VersionSuggestions{
+				Compatible: "v1.3.0",
+				Minor:      "v1.3.0",
+				Breaking:   "v2.0.0",
+				Prerelease: "v1.3.0-rc.2",
+				Final:      "v1.3.0",
+			},
+		},
+		{
+			name:   "PatchPrereleaseMinorSkipsFinal",
+			latest: "v1.2.4-rc.1",
+			want: VersionSuggestions{
+				Compatible: "v1.2.4",
+				Minor:      "v1.3.0",
+				Breaking:   "v2.0.0",
+				Prerelease: "v1.2.4-rc.2",
+				Final:      "v1.2.4",
+			},
+		},
+		{
+			name:     "BreakingPrereleasePromotesToFinal",
+			latest:   "v2.0.0-beta.3",
+			breaking: true,
+			want: VersionSuggestions{
+				Breaking:   "v2.0.0",
+				Prerelease: "v2.0.0-beta.4",
+				Final:      "v2.0.0",
+			},
+		},
+		{
+			name:   "BuildMetadataIgnored",
+			latest: "v1.2.3+meta",
+			want:   VersionSuggestions{Compatible: "v1.2.4", Minor: "v1.3.0", Breaking: "v2.0.0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SuggestNextVersions(tt.latest, tt.breaking)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("InvalidTag", func(t *testing.T) {
+		_, err := SuggestNextVersions("1.2.3", false)
+		if err == nil {
+			t.Error("got no error for a tag without the v prefix")
+		}
+	})
+}
+
+func TestNextPrerelease(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		label   string
+		want    string
+		wantErr bool
+	}{
+		{name: "ContinuesOwnSeries", version: "v1.3.0-rc.1", want: "v1.3.0-rc.2"},
+		{name: "ContinuesNamedSeries", version: "v1.3.0-rc.9", label: "rc", want: "v1.3.0-rc.10"},
+		{name: "StartsSeriesOnFinal", version: "v1.3.0", label: "beta", want: "v1.3.0-beta.1"},
+		{name: "RestartsOtherSeries", version: "v1.3.0-alpha.4", label: "beta", want: "v1.3.0-beta.1"},
+		{name: "UnnumberedPrerelease", version: "v1.3.0-rc", label: "rc", want: "v1.3.0-rc.1"},
+		{name: "DottedLabel", version: "v1.3.0-pre.rc.2", want: "v1.3.0-pre.rc.3"},
+		{name: "LabelRequiredOnFinal", version: "v1.3.0", wantErr: true},
+		{name: "InvalidLabel", version: "v1.3.0", label: "r_c", wantErr: true},
+		{name: "InvalidVersion", version: "1.3.0", label: "rc", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NextPrerelease(tt.version, tt.label)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("got %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 func ParseConfig(cfg *gomcfg.RootConfigV1, args gompkg.ConfigArgs) (c *gompkg.Config, err error) {
 	var scanDirs []dt.DirPath
 	var modSpecs []gompkg.ModuleSpec
+	var workspaces []gompkg.Workspace
 
 	scanDirs, err = dt.ParseDirPaths(cfg.ScanDirs)
 	if err != nil {
@@ -20,12 +21,22 @@ func ParseConfig(cfg *gomcfg.RootConfigV1, args gompkg.ConfigArgs) (c *gompkg.Co
 		goto end
 	}
 
+	workspaces, err = gompkg.ParseWorkspaces(cfg.Workspaces)
+	if err != nil {
+		goto end
+	}
+
 	c = &gompkg.Config{
-		Options:     args.Options,
-		ScanDirs:    scanDirs,
-		ModuleSpecs: modSpecs,
-		Logger:      args.Logger,
-		Writer:      args.Writer,
+		Options:           args.Options,
+		ScanDirs:          scanDirs,
+		ModuleSpecs:       modSpecs,
+		ToolDepsInFlux:    cfg.ToolDepsInFlux,
+		Concurrency:       cfg.Concurrency,
+		IgnorePrereleases: cfg.IgnorePrereleases,
+		Workspaces:        workspaces,
+		LintCommand:       cfg.LintCommand,
+		Logger:            args.Logger,
+		Writer:            args.Writer,
 	}
 end:
 	return c, err
@@ -4,18 +4,19 @@ import (
 	"context"
 	"io"
 
-	"github.com/mikeschinkel/go-dt"
 	"github.com/mikeschinkel/go-dt/dtx"
+	"github.com/mikeschinkel/gomion/gommod/goutils"
 )
 
 // AnalyzeWithCache runs pre-commit analysis with caching support
 // It tries to load from cache first, and if not found, runs fresh analysis
-func AnalyzeWithCache(ctx context.Context, moduleDir dt.DirPath, cacheKey string, writer io.Writer) (results *Results, err error) {
+func AnalyzeWithCache(ctx context.Context, args AnalyzeArgs, writer io.Writer) (results *Results, err error) {
 	var analysisResult Results
 
 	// Try to load from cache first
-	results, err = LoadPersistedResult(cacheKey)
-	if err == nil {
+	results, err = LoadPersistedResult(args.CacheKey)
+	// A result cached without coverage cannot serve a request for it
+	if err == nil && (!args.Coverage || results.Coverage.Verdict != goutils.VerdictUnspecified) {
 		if writer != nil {
 			dtx.Fprintf(writer, "Using cached analysis results\n\n")
 		}
@@ -23,10 +24,7 @@ func AnalyzeWithCache(ctx context.Context, moduleDir dt.DirPath, cacheKey string
 	}
 
 	// Run fresh analysis
-	analysisResult, err = Analyze(ctx, AnalyzeArgs{
-		ModuleDir: moduleDir,
-		CacheKey:  cacheKey,
-	})
+	analysisResult, err = Analyze(ctx, args)
 	if err != nil {
 		goto end
 	}
@@ -20,6 +20,9 @@ func Analyze(ctx context.Context, args AnalyzeArgs) (result Results, err error)
 	var cachedWT *gitutils.CachedWorktree
 	var stagedDir dt.DirPath
 	var tempDir string
+	var moduleDir dt.DirPath
+	var modRelDir dt.PathSegments
+	var baselineModDir, stagedModDir dt.DirPath
 
 	result.Timestamp = time.Now()
 
@@ -29,8 +32,18 @@ func Analyze(ctx context.Context, args AnalyzeArgs) (result Results, err error)
 		goto end
 	}
 
+	// Locate the module within the repo; both trees are exported whole
+	moduleDir, err = args.ModuleDir.Abs()
+	if err != nil {
+		goto end
+	}
+	modRelDir, err = moduleDir.Rel(repo.Root)
+	if err != nil {
+		goto end
+	}
+
 	// Find baseline tag
-	result.BaselineTag, err = repo.FindBaselineTag(ctx, "")
+	result.BaselineTag, err = repo.FindBaselineTag(ctx, modRelDir)
 	if err != nil {
 		// Not fatal - set verdict to unknown and continue
 		result.OverallVerdict = goutils.VerdictUnknown
@@ -59,8 +72,8 @@ func Analyze(ctx context.Context, args AnalyzeArgs) (result Results, err error)
 	stagedDir = dt.DirPath(tempDir)
 	defer os.RemoveAll(string(stagedDir))
 
-	// Export staged files to temp directory
-	err = gitutils.ExportStagedFiles(ctx, gitutils.ExportStagedArgs{
+	// Export the complete staged tree to temp directory so it can be tested
+	err = gitutils.ExportIndex(ctx, gitutils.ExportStagedArgs{
 		Repo:    repo,
 		DestDir: stagedDir,
 	})
@@ -68,28 +81,40 @@ func Analyze(ctx context.Context, args AnalyzeArgs) (result Results, err error)
 		goto end
 	}
 
+	baselineModDir = dt.DirPathJoin(cachedWT.Dir, modRelDir)
+	stagedModDir = dt.DirPathJoin(stagedDir, modRelDir)
+
 	// Call each analysis function directly (bespoke handling)
 	// This demonstrates: NO generic loop, direct function calls with specific types
 
-	result.API, err = goutils.AnalyzeAPICompatibility(ctx, cachedWT.Dir, stagedDir)
+	result.API, err = goutils.AnalyzeAPICompatibility(ctx, baselineModDir, stagedModDir)
 	if err != nil {
 		// Log but continue with other analyzers
 		// In production, would log: logger.Warn("API analysis failed", "error", err)
 		err = nil
 	}
 
-	result.AST, err = goutils.AnalyzeASTDiff(ctx, cachedWT.Dir, stagedDir)
+	result.AST, err = goutils.AnalyzeASTDiff(ctx, baselineModDir, stagedModDir)
 	if err != nil {
 		// Log but continue
 		err = nil
 	}
 
-	result.Tests, err = goutils.AnalyzeTestSignals(ctx, cachedWT.Dir, stagedDir)
+	result.Tests, err = goutils.AnalyzeTestSignals(ctx, baselineModDir, stagedModDir)
 	if err != nil {
 		// Log but continue
 		err = nil
 	}
 
+	if args.Coverage {
+		result.Coverage, err = goutils.AnalyzeCoverageDelta(ctx, baselineModDir, stagedModDir)
+		if err != nil {
+			// Log but continue; tests may not build in one of the trees
+			err = nil
+		}
+		result.Tests.CoverageSignal = result.Coverage.Signal()
+	}
+
 	// Compute overall verdict using bespoke logic
 	result.OverallVerdict = computeOverallVerdict(&result)
 
@@ -121,6 +146,16 @@ func computeOverallVerdict(r *Results) goutils.VerdictType {
 	return goutils.VerdictMaybeCompatible
 }
 
+// analyses returns the analyses that ran, leaving out coverage unless it was
+// requested
+func (r Results) analyses() (analyses []goutils.AnalysisResult) {
+	analyses = []goutils.AnalysisResult{r.API, r.AST, r.Tests}
+	if r.Coverage.Verdict != goutils.VerdictUnspecified {
+		analyses = append(analyses, r.Coverage)
+	}
+	return analyses
+}
+
 // FormatForAI generates markdown for AI prompts
 // This demonstrates generic formatting using the AnalysisResult interface
 func (r Results) FormatForAI() string {
@@ -128,7 +163,7 @@ func (r Results) FormatForAI() string {
 
 	// Use AnalysisResult interface for generic iteration
 	// This is where the interface adds value - same formatting code for all analyzers
-	for _, analyzer := range []goutils.AnalysisResult{r.API, r.AST, r.Tests} {
+	for _, analyzer := range r.analyses() {
 		combined += analyzer.AnalysisSummary(goutils.MarkdownFormat)
 		combined += "\n\n"
 	}
@@ -142,7 +177,7 @@ func (r Results) FormatForTerminal() string {
 	var combined string
 
 	// Same generic loop, different format
-	for _, analyzer := range []goutils.AnalysisResult{r.API, r.AST, r.Tests} {
+	for _, analyzer := range r.analyses() {
 		combined += analyzer.AnalysisSummary(goutils.ANSIEscapedFormat)
 		combined += "\n"
 	}
@@ -15,15 +15,20 @@ type Results struct {
 	OverallVerdict goutils.VerdictType
 
 	// Individual analysis results (bespoke types from goutils)
-	API   goutils.APICompatResult
-	AST   goutils.ASTDiffResult
-	Tests goutils.TestSignalsResult
+	API      goutils.APICompatResult
+	AST      goutils.ASTDiffResult
+	Tests    goutils.TestSignalsResult
+	Coverage goutils.CoverageDeltaResult
 }
 
 // AnalyzeArgs contains arguments for the Analyze function
 type AnalyzeArgs struct {
 	ModuleDir dt.DirPath
 	CacheKey  string // For persistence
+
+	// Coverage compares test coverage between the baseline and staged trees;
+	// off by default as it runs the module's tests once in each tree
+	Coverage bool
 }
 
 // CommitGroup represents a suggested grouping of files for a single commit